@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/tab58/go-integral/pkg/integral"
+)
+
+func addConfigFlag(fs *flag.FlagSet) *string {
+	return fs.String("config", "", "path of the configuration file (default: go-integral.yaml discovered from the working directory)")
+}
+
+// loadConfig loads the configuration file given on the command line, or the one discovered from the working directory
+func loadConfig(path string) (*integral.Config, error) {
+	if path == "" {
+		discovered, err := integral.DiscoverConfig(".")
+		if err != nil {
+			return nil, fmt.Errorf("unable to discover config: %w", err)
+		}
+		if discovered == "" {
+			return &integral.Config{}, nil
+		}
+		path = discovered
+	}
+
+	cfg, err := integral.LoadConfig(path)
+	if err != nil {
+		return nil, usageError("%w", err)
+	}
+	return cfg, nil
+}
+
+// schemaInputs returns the schema paths from the command line, falling back to the ones in the configuration
+func schemaInputs(fs *flag.FlagSet, cfg *integral.Config) []string {
+	if fs.NArg() > 0 {
+		return fs.Args()
+	}
+	return cfg.Schema
+}
+
+// setFlags returns the names of the flags that were explicitly set on the command line
+func setFlags(fs *flag.FlagSet) map[string]bool {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	return set
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/tab58/go-integral/pkg/integral"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+func runDataset(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := newFlagSet("dataset")
+	configPath := addConfigFlag(fs)
+	counts := fs.String("count", "", "comma separated row counts of tables, like users=10000")
+	perParent := fs.String("per", "", "comma separated rows per referenced row, like orders.user_id=3-8 or line_items=1-5")
+	seed := fs.Uint64("seed", 1, "seed of the fake values, the same seed generating the same dataset")
+	now := fs.String("now", "", "date or RFC 3339 time the fake past and future times are relative to, or today (default: 2025-01-01)")
+	format := fs.String("format", "sql", "output format: sql or csv")
+	out := fs.String("out", "", "file the SQL script is written to (default: stdout), or directory of the CSV files")
+	dsn := fs.String("db", "", "database URL to insert the rows into, in a transaction, instead of writing files")
+	transaction := fs.Bool("transaction", false, "wrap the SQL script in BEGIN and COMMIT")
+	copyRows := fs.Int("copy-rows", 0, "write batches of at least this many rows as COPY blocks in the SQL script, 0 for never")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if !slices.Contains([]string{"sql", "csv"}, *format) {
+		return usageError("unknown format %q, expected sql or csv", *format)
+	}
+	if *format == "csv" && *out == "" && *dsn == "" {
+		return usageError("-out is required with -format csv")
+	}
+	fakeNow, err := parseNow(*now)
+	if err != nil {
+		return usageError("invalid -now: %s", err)
+	}
+
+	plan := integral.DatasetPlan{Counts: make(map[string]int), PerParent: make(map[string]integral.Cardinality)}
+	for _, item := range splitList(*counts) {
+		tableName, count, err := integral.ParseDatasetCount(item)
+		if err != nil {
+			return usageError("invalid -count: %s", err)
+		}
+		plan.Counts[tableName] = count
+	}
+	for _, item := range splitList(*perParent) {
+		key, cardinality, err := integral.ParseCardinality(item)
+		if err != nil {
+			return usageError("invalid -per: %s", err)
+		}
+		plan.PerParent[key] = cardinality
+	}
+	if len(plan.Counts) == 0 && len(plan.PerParent) == 0 {
+		return usageError("nothing to generate, set -count or -per")
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	schema, err := loadSchema(schemaInputs(fs, cfg), stdin, cfg.Introspect)
+	if err != nil {
+		return err
+	}
+	seeder, err := integral.NewSeeder(schema)
+	if err != nil {
+		return invalidSchemaError(err)
+	}
+	faker := integral.NewFaker(*seed, integral.FakerOptions{Now: fakeNow, Overrides: cfg.FakeOverrides})
+
+	var generated map[string]int
+	switch {
+	case *dsn != "":
+		generated, err = insertDataset(seeder, faker, plan, *dsn)
+	case *format == "csv":
+		generated, err = writeCSVDataset(seeder, faker, plan, *out)
+	default:
+		scriptOpts := integral.SQLScriptOptions{Transaction: *transaction, CopyRows: *copyRows}
+		generated, err = writeSQLDataset(seeder, faker, plan, scriptOpts, *out, stdout)
+	}
+	if err != nil {
+		return err
+	}
+
+	// the summary would end up in the script written to stdout
+	if *format == "sql" && *out == "" && *dsn == "" {
+		return nil
+	}
+	for _, table := range seeder.Tables() {
+		if count, ok := generated[table.Name]; ok {
+			fmt.Fprintf(stdout, "%s: %d rows\n", table.Name, count)
+		}
+	}
+	return nil
+}
+
+// parseNow parses the -now flag, a date, an RFC 3339 time or today for the start of the current day, returning the
+// zero time when it isn't set
+func parseNow(value string) (time.Time, error) {
+	switch value {
+	case "":
+		return time.Time{}, nil
+	case "today":
+		return time.Now().UTC().Truncate(24 * time.Hour), nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a date, an RFC 3339 time or today", value)
+	}
+	return t.UTC(), nil
+}
+
+func insertDataset(seeder *integral.Seeder, faker *integral.Faker, plan integral.DatasetPlan, dsn string) (map[string]int, error) {
+	ctx := context.Background()
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to database: %w", err)
+	}
+	defer tx.Rollback()
+
+	generated, err := integral.GenerateDataset(seeder, faker, plan, integral.NewDatabaseSink(ctx, seeder, tx))
+	if err != nil {
+		return nil, err
+	}
+	return generated, tx.Commit()
+}
+
+func writeCSVDataset(seeder *integral.Seeder, faker *integral.Faker, plan integral.DatasetPlan, dir string) (map[string]int, error) {
+	sink, err := integral.NewCSVSink(dir, seeder.Schema())
+	if err != nil {
+		return nil, err
+	}
+	defer sink.Close()
+	return integral.GenerateDataset(seeder, faker, plan, sink)
+}
+
+func writeSQLDataset(seeder *integral.Seeder, faker *integral.Faker, plan integral.DatasetPlan, opts integral.SQLScriptOptions, path string, stdout io.Writer) (map[string]int, error) {
+	w := stdout
+	if path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		w = file
+	}
+	sink := integral.NewSQLSink(w, seeder.Schema(), opts)
+	generated, err := integral.GenerateDataset(seeder, faker, plan, sink)
+	if err != nil {
+		return nil, err
+	}
+	return generated, sink.Close()
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/tab58/go-integral/pkg/integral"
+	"io"
+	"os"
+	"slices"
+
+	_ "github.com/lib/pq"
+)
+
+func runExtract(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := newFlagSet("extract")
+	configPath := addConfigFlag(fs)
+	dsn := fs.String("db", "", "database URL to read the rows from, and the schema when no schema input is given")
+	var selections []integral.RowSelection
+	fs.Func("select", "rows to start from, like 'orders WHERE id = 42' or a table name for all of its rows, can be repeated", func(value string) error {
+		selection, err := integral.ParseRowSelection(value)
+		if err != nil {
+			return err
+		}
+		selections = append(selections, selection)
+		return nil
+	})
+	children := fs.Bool("children", false, "also take the rows referencing the selected rows, recursively")
+	format := fs.String("format", "yaml", "output format: yaml fixtures, go models literal or sql script")
+	out := fs.String("out", "", "file the rows are written to (default: stdout)")
+	packageName := fs.String("package", integral.DefaultPackageName, "package name of the go output, which goes with the generated seed package")
+	modelsTypeName := fs.String("models-type", integral.DefaultModelsTypeName, "name of the models type of the go output")
+	varName := fs.String("var", integral.DefaultFixturesVarName, "name of the variable declared by the go output")
+	maskSecret := fs.String("mask-secret", "", "secret keying the masked values of the masking rules of the configuration (default: masking.secret)")
+	transaction := fs.Bool("transaction", false, "wrap the sql script in BEGIN and COMMIT")
+	copyRows := fs.Int("copy-rows", 0, "write the tables with at least this many rows as COPY blocks in the sql script, 0 for never")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if !slices.Contains([]string{"yaml", "go", "sql"}, *format) {
+		return usageError("unknown format %q, expected yaml, go or sql", *format)
+	}
+	if *dsn == "" {
+		return usageError("-db is required")
+	}
+	if len(selections) == 0 {
+		return usageError("nothing to extract, set -select")
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	inputs := schemaInputs(fs, cfg)
+	if len(inputs) == 0 {
+		inputs = []string{*dsn}
+	}
+	schema, err := loadSchema(inputs, stdin, cfg.Introspect)
+	if err != nil {
+		return err
+	}
+	seeder, err := integral.NewSeeder(schema)
+	if err != nil {
+		return invalidSchemaError(err)
+	}
+
+	data, err := extractRows(seeder, *dsn, selections, integral.ExtractOptions{Children: *children})
+	if err != nil {
+		return err
+	}
+	if len(cfg.MaskRules) > 0 {
+		secret := cfg.MaskSecret
+		if setFlags(fs)["mask-secret"] {
+			secret = *maskSecret
+		}
+		masker, err := integral.NewMasker(cfg.MaskRules, secret)
+		if err != nil {
+			return usageError("%w", err)
+		}
+		if data, err = masker.Mask(schema, data); err != nil {
+			return fmt.Errorf("unable to mask rows: %w", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	switch *format {
+	case "sql":
+		err = integral.WriteSQLScript(&buf, seeder, data, integral.SQLScriptOptions{Transaction: *transaction, CopyRows: *copyRows})
+	case "yaml", "go":
+		var fixtures *integral.Fixtures
+		if fixtures, err = integral.FixturesFromDataset(seeder, data); err != nil {
+			break
+		}
+		if *format == "yaml" {
+			err = integral.WriteFixturesYAML(&buf, seeder, fixtures)
+			break
+		}
+		// the literal goes with the seed package generated with the same options
+		opts := cfg.Options
+		set := setFlags(fs)
+		if set["package"] {
+			opts.PackageName = *packageName
+		}
+		if set["models-type"] {
+			opts.ModelsTypeName = *modelsTypeName
+		}
+		err = writeFixturesGo(&buf, schema, opts, *varName, fixtures)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		_, err := stdout.Write(buf.Bytes())
+		return err
+	}
+	if err := os.WriteFile(*out, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	for _, table := range seeder.Tables() {
+		if rows, ok := data[table.Name]; ok {
+			fmt.Fprintf(stdout, "%s: %d rows\n", table.Name, len(rows))
+		}
+	}
+	return nil
+}
+
+func extractRows(seeder *integral.Seeder, dsn string, selections []integral.RowSelection, opts integral.ExtractOptions) (integral.Dataset, error) {
+	ctx := context.Background()
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	// a read only transaction sees the rows as they were when the extract started
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to database: %w", err)
+	}
+	defer tx.Rollback()
+	return integral.ExtractRows(ctx, seeder, tx, selections, opts)
+}
+
+// writeFixturesGo writes fixtures as a models literal of the seed package generated with the options
+func writeFixturesGo(w io.Writer, schema *integral.Schema, opts integral.Options, varName string, fixtures *integral.Fixtures) error {
+	builder, err := integral.NewGenerator(schema, opts)
+	if err != nil {
+		return invalidSchemaError(err)
+	}
+	file, err := builder.GenerateFixturesFile(fixtures, varName)
+	if err != nil {
+		return fmt.Errorf("failed to generate fixtures: %w", err)
+	}
+	_, err = io.WriteString(w, file.Contents)
+	return err
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"github.com/tab58/go-integral/pkg/integral"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+const defaultOutputDir = "generated/seed"
+
+func runGenerate(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := newFlagSet("generate")
+	configPath := addConfigFlag(fs)
+	outputDir := fs.String("out", defaultOutputDir, "directory the generated files are written to")
+	packageName := fs.String("package", integral.DefaultPackageName, "package name of the generated files")
+	seedFuncName := fs.String("seed-func", integral.DefaultSeedFuncName, "name of the exported seeding function")
+	modelsTypeName := fs.String("models-type", integral.DefaultModelsTypeName, "name of the exported struct holding all models")
+	include := fs.String("include", "", "comma separated tables or globs to generate, along with the tables they require")
+	exclude := fs.String("exclude", "", "comma separated tables or globs not to generate")
+	fixturePaths := fs.String("fixtures", "", "comma separated fixture files or directories to generate a models literal from")
+	sqlPath := fs.String("sql", "", "file to also write the fixtures to as an SQL script")
+	transaction := fs.Bool("transaction", false, "wrap the -sql script in BEGIN and COMMIT")
+	copyRows := fs.Int("copy-rows", 0, "write the tables with at least this many fixtures as COPY blocks in the -sql script, 0 for never")
+	seedtest := fs.Bool("seedtest", false, "also generate the seedtest package of helpers for go test, in a directory of the output directory")
+	importPath := fs.String("import-path", "", "import path of the generated package for the seedtest package, found from go.mod by default")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+
+	// flags set on the command line take precedence over the configuration file
+	opts := cfg.Options
+	dir := cfg.Output.Dir
+	set := setFlags(fs)
+	if set["out"] || dir == "" {
+		dir = *outputDir
+	}
+	if set["package"] {
+		opts.PackageName = *packageName
+	}
+	if set["seed-func"] {
+		opts.SeedFuncName = *seedFuncName
+	}
+	if set["models-type"] {
+		opts.ModelsTypeName = *modelsTypeName
+	}
+	if set["include"] {
+		opts.IncludeTables = splitList(*include)
+	}
+	if set["exclude"] {
+		opts.ExcludeTables = splitList(*exclude)
+	}
+
+	builder, err := newBuilder(schemaInputs(fs, cfg), stdin, cfg.Introspect, opts)
+	if err != nil {
+		return fmt.Errorf("failed to create builder: %w", err)
+	}
+
+	files, err := builder.GenerateFiles()
+	if err != nil {
+		return fmt.Errorf("failed to generate table schemas: %w", err)
+	}
+
+	fixtures := cfg.Fixtures
+	if set["fixtures"] {
+		fixtures = splitList(*fixturePaths)
+	}
+	if len(fixtures) > 0 {
+		loaded, err := integral.LoadFixtures(fixtures...)
+		if err != nil {
+			return usageError("%w", err)
+		}
+		fixturesFile, err := builder.GenerateFixturesFile(loaded, integral.DefaultFixturesVarName)
+		if err != nil {
+			return usageError("failed to generate fixtures: %w", err)
+		}
+		files = append(files, fixturesFile)
+
+		if *sqlPath != "" {
+			script, err := builder.GenerateFixturesSQL(loaded, integral.SQLScriptOptions{Transaction: *transaction, CopyRows: *copyRows})
+			if err != nil {
+				return usageError("failed to generate fixtures script: %w", err)
+			}
+			if err := os.WriteFile(*sqlPath, script, 0o644); err != nil {
+				return err
+			}
+			fmt.Fprintf(stdout, "wrote the fixtures script to %s\n", *sqlPath)
+		}
+	} else if *sqlPath != "" {
+		return usageError("-sql requires fixtures, from -fixtures or the configuration")
+	}
+
+	withSeedtest := cfg.Output.Seedtest
+	if set["seedtest"] {
+		withSeedtest = *seedtest
+	}
+	if withSeedtest {
+		path := cfg.Output.ImportPath
+		if set["import-path"] {
+			path = *importPath
+		}
+		if path == "" {
+			if path, err = integral.ImportPath(dir); err != nil {
+				return usageError("unable to find the import path of the generated package, set -import-path: %w", err)
+			}
+		}
+		seedtestFile, err := builder.GenerateSeedtestFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to generate the seedtest package: %w", err)
+		}
+		files = append(files, seedtestFile)
+	}
+
+	if err := integral.WriteFiles(dir, files); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "wrote %d files to %s\n", len(files), dir)
+	return nil
+}
+
+// splitList splits a comma separated flag value, ignoring empty items
+func splitList(value string) []string {
+	return slices.DeleteFunc(strings.Split(value, ","), func(item string) bool {
+		return item == ""
+	})
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"github.com/tab58/go-integral/pkg/integral"
+	"io"
+	"slices"
+)
+
+func runGraph(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := newFlagSet("graph")
+	configPath := addConfigFlag(fs)
+	format := fs.String("format", "text", "output format: text, dot or mermaid")
+	cluster := fs.Bool("cluster", false, "group the tables by PostgreSQL schema (dot only)")
+	focus := fs.String("focus", "", "comma separated tables to restrict the graph to, with their neighbors")
+	depth := fs.Int("depth", 1, "number of foreign keys to follow from the -focus tables, -1 for all")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if !slices.Contains([]string{"text", "dot", "mermaid"}, *format) {
+		return usageError("unknown format %q, expected text, dot or mermaid", *format)
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+
+	schemaGraph, err := loadSchemaGraph(schemaInputs(fs, cfg), stdin, cfg.Introspect)
+	if err != nil {
+		return err
+	}
+	if *focus != "" {
+		schemaGraph, err = integral.FocusGraph(schemaGraph, splitList(*focus), *depth)
+		if err != nil {
+			return usageError("invalid -focus: %s", err)
+		}
+	}
+
+	exportOpts := integral.ExportOptions{ClusterBySchema: *cluster}
+	switch *format {
+	case "dot":
+		return integral.WriteDOT(stdout, schemaGraph, exportOpts)
+	case "mermaid":
+		return integral.WriteMermaid(stdout, schemaGraph, exportOpts)
+	}
+
+	tables, err := schemaGraph.InsertOrder()
+	if err != nil {
+		return invalidSchemaError(fmt.Errorf("failed to get table relationships: %w", err))
+	}
+
+	fmt.Fprintf(stdout, "insert order:\n")
+	for i, table := range tables {
+		fmt.Fprintf(stdout, "  %d. %s\n", i+1, table.Name)
+	}
+	fmt.Fprintf(stdout, "dependencies:\n")
+	for _, dependency := range schemaGraph.Dependencies() {
+		fmt.Fprintf(stdout, "  %s.%s -> %s.%s\n", dependency.ReferencedTable, dependency.ReferencedColumn, dependency.Table, dependency.Column)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"github.com/tab58/go-integral/pkg/integral"
+	"io"
+)
+
+const defaultSchemaPath = "schema.sql"
+
+// schemaSource picks the schema source for the input paths, where "-" reads from stdin
+func schemaSource(paths []string, stdin io.Reader, introspectOpts integral.IntrospectOptions) (integral.SchemaSource, error) {
+	if len(paths) == 0 {
+		paths = []string{defaultSchemaPath}
+	}
+	src, err := integral.SourceFromInputs(paths, stdin, introspectOpts)
+	if err != nil {
+		return nil, usageError("%s", err)
+	}
+	return src, nil
+}
+
+func loadSchema(paths []string, stdin io.Reader, introspectOpts integral.IntrospectOptions) (*integral.Schema, error) {
+	src, err := schemaSource(paths, stdin, introspectOpts)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := src.LoadSchema(context.Background())
+	if err != nil {
+		var parseErr *integral.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, invalidSchemaError(err)
+		}
+		return nil, err
+	}
+	return schema, nil
+}
+
+func loadSchemaGraph(paths []string, stdin io.Reader, introspectOpts integral.IntrospectOptions) (*integral.Graph, error) {
+	schema, err := loadSchema(paths, stdin, introspectOpts)
+	if err != nil {
+		return nil, err
+	}
+	schemaGraph, err := integral.BuildGraph(schema)
+	if err != nil {
+		return nil, invalidSchemaError(err)
+	}
+	return schemaGraph, nil
+}
+
+// newBuilder creates the seed code builder from the schema of SQL files, migrations directories or globs, or a database
+func newBuilder(paths []string, stdin io.Reader, introspectOpts integral.IntrospectOptions, opts integral.Options) (*integral.Generator, error) {
+	schema, err := loadSchema(paths, stdin, introspectOpts)
+	if err != nil {
+		return nil, err
+	}
+	builder, err := integral.NewGenerator(schema, opts)
+	if err != nil {
+		return nil, invalidSchemaError(err)
+	}
+	return builder, nil
+}
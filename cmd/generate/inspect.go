@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/tab58/go-integral/pkg/integral"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+func runInspect(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := newFlagSet("inspect")
+	configPath := addConfigFlag(fs)
+	format := fs.String("format", "text", "output format: text, json or yaml")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if !slices.Contains([]string{"text", "json", "yaml"}, *format) {
+		return usageError("unknown format %q, expected text, json or yaml", *format)
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+
+	schema, err := loadSchema(schemaInputs(fs, cfg), stdin, cfg.Introspect)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "json":
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(schema)
+	case "yaml":
+		encoder := yaml.NewEncoder(stdout)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(schema); err != nil {
+			return err
+		}
+		return encoder.Close()
+	}
+
+	enums := schema.Enums()
+	for _, enumName := range slices.Sorted(maps.Keys(enums)) {
+		fmt.Fprintf(stdout, "enum %s (%s)\n", enumName, strings.Join(enums[enumName], ", "))
+	}
+	for _, table := range schema.Tables() {
+		printTable(stdout, table)
+	}
+	return nil
+}
+
+func printTable(w io.Writer, table integral.Table) {
+	fmt.Fprintf(w, "table %s\n", table.Name)
+	uniqueKeys := table.UniqueKeys
+	if len(table.PrimaryKey) > 0 {
+		fmt.Fprintf(w, "  primary key (%s)\n", strings.Join(table.PrimaryKey, ", "))
+		uniqueKeys = uniqueKeys[1:]
+	}
+	for _, column := range table.Columns {
+		var constraints []string
+		if column.NotNull && !slices.Contains(table.PrimaryKey, column.Name) {
+			constraints = append(constraints, "not_null")
+		}
+		if column.HasDefault {
+			constraints = append(constraints, "default="+column.Default)
+		}
+		if column.Identity {
+			constraints = append(constraints, "identity")
+		}
+		if column.Generated != "" {
+			constraints = append(constraints, "generated="+column.Generated)
+		}
+		for _, check := range column.Checks {
+			constraints = append(constraints, "check="+check)
+		}
+		fmt.Fprintf(w, "  column %s %s", column.Name, column.DataType)
+		if len(constraints) > 0 {
+			fmt.Fprintf(w, " [%s]", strings.Join(constraints, ", "))
+		}
+		fmt.Fprintln(w)
+	}
+	for _, foreignKey := range table.ForeignKeys {
+		if len(foreignKey.Columns) == 1 {
+			fmt.Fprintf(w, "  foreign key %s -> %s.%s\n", foreignKey.Columns[0], foreignKey.ReferencedTable, foreignKey.ReferencedColumns[0])
+			continue
+		}
+		fmt.Fprintf(w, "  foreign key (%s) -> %s (%s)\n", strings.Join(foreignKey.Columns, ", "), foreignKey.ReferencedTable, strings.Join(foreignKey.ReferencedColumns, ", "))
+	}
+	for _, key := range uniqueKeys {
+		fmt.Fprintf(w, "  unique (%s)\n", strings.Join(key, ", "))
+	}
+	for _, check := range table.Checks {
+		fmt.Fprintf(w, "  check (%s)\n", check)
+	}
+}
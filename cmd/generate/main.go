@@ -1,32 +1,127 @@
 package main
 
 import (
-	"go-integral/internal/seedgen"
-	"log"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"os"
+	"strings"
 )
 
+const (
+	exitOK            = 0
+	exitFailure       = 1
+	exitUsage         = 2
+	exitInvalidSchema = 3
+)
+
+type command struct {
+	Name    string
+	Summary string
+	Run     func(args []string, stdin io.Reader, stdout io.Writer) error
+}
+
+var commands = []command{
+	{Name: "dataset", Summary: "generate a volume of fake rows with valid foreign keys", Run: runDataset},
+	{Name: "extract", Summary: "extract rows and the rows they reference from a database as fixtures", Run: runExtract},
+	{Name: "generate", Summary: "generate the Go seed package from a schema", Run: runGenerate},
+	{Name: "graph", Summary: "print the table dependency graph", Run: runGraph},
+	{Name: "inspect", Summary: "print the parsed schema", Run: runInspect},
+	{Name: "validate", Summary: "check that a schema can be used to generate seed code", Run: runValidate},
+}
+
+// cliError is an error that carries the exit code the process should terminate with
+type cliError struct {
+	code int
+	err  error
+	// reported is set when the error has already been printed, like flag parsing errors
+	reported bool
+}
+
+func (e *cliError) Error() string {
+	return e.err.Error()
+}
+
+func (e *cliError) Unwrap() error {
+	return e.err
+}
+
+func usageError(format string, args ...any) error {
+	return &cliError{code: exitUsage, err: fmt.Errorf(format, args...)}
+}
+
+func invalidSchemaError(err error) error {
+	return &cliError{code: exitInvalidSchema, err: err}
+}
+
 func main() {
-	sqlFilePath := "schema.sql"
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	// running without a subcommand keeps the original behavior of generating from schema.sql
+	name := "generate"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
 
-	sqlContents, err := os.ReadFile(sqlFilePath)
-	if err != nil {
-		log.Fatalf("failed to read schema.sql: %v", err)
+	if name == "help" {
+		printUsage(stdout)
+		return exitOK
 	}
 
-	builder, err := seedgen.NewFromSQLSchema(string(sqlContents))
-	if err != nil {
-		log.Fatalf("failed to create builder: %v", err)
+	for _, cmd := range commands {
+		if cmd.Name != name {
+			continue
+		}
+		err := cmd.Run(args, stdin, stdout)
+		if err == nil {
+			return exitOK
+		}
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		var cliErr *cliError
+		if !errors.As(err, &cliErr) {
+			fmt.Fprintf(stderr, "%s: %v\n", name, err)
+			return exitFailure
+		}
+		if !cliErr.reported {
+			fmt.Fprintf(stderr, "%s: %v\n", name, err)
+		}
+		return cliErr.code
 	}
 
-	files, err := builder.GenerateTemplateFiles()
-	if err != nil {
-		log.Fatalf("failed to generate table schemas: %v", err)
+	fmt.Fprintf(stderr, "unknown command %q\n\n", name)
+	printUsage(stderr)
+	return exitUsage
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: generate <command> [flags] [schema.sql ...]\n\n")
+	fmt.Fprintf(w, "Commands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.Name, cmd.Summary)
+	}
+	fmt.Fprintf(w, "\nSchema inputs default to schema.sql; use - to read from stdin.\n")
+	fmt.Fprintf(w, "Run 'generate <command> -h' for the flags of a command.\n")
+}
+
+// newFlagSet creates a flag set that reports parse errors instead of exiting the process
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: generate %s [flags] [schema.sql ...]\n\nFlags:\n", name)
+		fs.PrintDefaults()
 	}
+	return fs
+}
 
-	generatedFolder := "generated/seed"
-	os.MkdirAll(generatedFolder, 0755)
-	for _, file := range files {
-		os.WriteFile(generatedFolder+"/"+file.Filename, []byte(file.Contents), 0644)
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return err
 	}
+	return &cliError{code: exitUsage, err: err, reported: true}
 }
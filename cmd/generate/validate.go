@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+func runValidate(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := newFlagSet("validate")
+	configPath := addConfigFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+
+	builder, err := newBuilder(schemaInputs(fs, cfg), stdin, cfg.Introspect, cfg.Options)
+	if err != nil {
+		return err
+	}
+
+	// generate the files in memory to surface template errors without writing anything
+	if _, err := builder.GenerateFiles(); err != nil {
+		return invalidSchemaError(err)
+	}
+
+	fmt.Fprintf(stdout, "schema is valid: %d tables\n", len(builder.Tables()))
+	return nil
+}
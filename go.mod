@@ -1,4 +1,4 @@
-module go-integral
+module github.com/tab58/go-integral
 
 go 1.24.5
 
@@ -6,8 +6,10 @@ require (
 	github.com/google/go-cmp v0.7.0
 	github.com/iancoleman/strcase v0.3.0
 	github.com/jmoiron/sqlx v1.4.0
+	github.com/lib/pq v1.10.9
 	github.com/pganalyze/pg_query_go/v6 v6.1.0
 	github.com/segmentio/ksuid v1.0.4
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require google.golang.org/protobuf v1.31.0 // indirect
@@ -0,0 +1,236 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/tab58/go-integral/internal/fake"
+	"github.com/tab58/go-integral/internal/introspect"
+	"github.com/tab58/go-integral/internal/mask"
+	"github.com/tab58/go-integral/internal/seedgen"
+	"github.com/tab58/go-integral/internal/utils"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileNames are the names a project configuration file is discovered by, in order of preference
+var FileNames = []string{"go-integral.yaml", "go-integral.yml"}
+
+type Config struct {
+	Schema        []string         `yaml:"schema"`
+	Fixtures      []string         `yaml:"fixtures"`
+	Output        OutputConfig     `yaml:"output"`
+	IncludeTables []string         `yaml:"include_tables"`
+	ExcludeTables []string         `yaml:"exclude_tables"`
+	TypeOverrides []TypeOverride   `yaml:"type_overrides"`
+	FakeValues    []FakeValue      `yaml:"fake_values"`
+	Naming        NamingConfig     `yaml:"naming"`
+	Introspect    IntrospectConfig `yaml:"introspect"`
+	Masking       MaskingConfig    `yaml:"masking"`
+
+	// Path is the file the configuration was loaded from, empty if it wasn't loaded from a file
+	Path string `yaml:"-"`
+}
+
+type OutputConfig struct {
+	Dir        string `yaml:"dir"`
+	Package    string `yaml:"package"`
+	SeedFunc   string `yaml:"seed_func"`
+	ModelsType string `yaml:"models_type"`
+	// Seedtest also generates the seedtest companion package of test helpers
+	Seedtest bool `yaml:"seedtest"`
+	// ImportPath is the import path of the generated package, which the seedtest package imports, found from the
+	// go.mod of the output directory when empty
+	ImportPath string `yaml:"import_path"`
+}
+
+type TypeOverride struct {
+	Column string `yaml:"column"`
+	DBType string `yaml:"db_type"`
+	GoType string `yaml:"go_type"`
+	Import string `yaml:"import"`
+}
+
+// FakeValue sets the kind of fake values of the columns matching Column, a table.column name or glob
+type FakeValue struct {
+	Column string `yaml:"column"`
+	Kind   string `yaml:"kind"`
+}
+
+type NamingConfig struct {
+	Acronyms           []string `yaml:"acronyms"`
+	StripTablePrefixes []string `yaml:"strip_table_prefixes"`
+}
+
+type IntrospectConfig struct {
+	// Schemas lists the PostgreSQL schemas whose tables are loaded when the schema input is a database DSN
+	Schemas []string `yaml:"schemas"`
+}
+
+// MaskingConfig sets how the values of extracted rows are masked
+type MaskingConfig struct {
+	// Secret keys the hashes the masked values are derived from
+	Secret string     `yaml:"secret"`
+	Rules  []MaskRule `yaml:"rules"`
+}
+
+// MaskRule masks the values of the columns matching Column, a table.column name or glob, or of type Type
+type MaskRule struct {
+	Column   string `yaml:"column"`
+	Type     string `yaml:"type"`
+	Strategy string `yaml:"strategy"`
+	Kind     string `yaml:"kind"`
+}
+
+// Discover looks for a configuration file in dir and then in each of its parents. It returns an empty path if no
+// configuration file is found.
+func Discover(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		for _, name := range FileNames {
+			path := filepath.Join(dir, name)
+			info, err := os.Stat(path)
+			if err == nil && !info.IsDir() {
+				return path, nil
+			}
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return "", err
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// Load reads and validates a configuration file. Relative paths in the file are resolved against its directory.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read config: %w", err)
+	}
+
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	cfg.Path = path
+
+	baseDir := filepath.Dir(path)
+	cfg.Schema = resolvePaths(baseDir, cfg.Schema)
+	cfg.Fixtures = resolvePaths(baseDir, cfg.Fixtures)
+	if cfg.Output.Dir != "" {
+		cfg.Output.Dir = resolvePath(baseDir, cfg.Output.Dir)
+	}
+	return cfg, nil
+}
+
+// Parse decodes and validates the YAML contents of a configuration file
+func Parse(data []byte) (*Config, error) {
+	cfg := &Config{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate checks the values of the configuration, reporting every problem at once
+func (c *Config) Validate() error {
+	var errs []error
+	fieldErr := func(field string, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+	}
+
+	if err := c.SeedgenOptions().Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	for i, schema := range c.Introspect.Schemas {
+		if schema == "" {
+			fieldErr(fmt.Sprintf("introspect.schemas[%d]", i), "schema name must not be empty")
+		}
+	}
+	for i, rule := range c.MaskRules() {
+		if err := rule.Validate(); err != nil {
+			fieldErr(fmt.Sprintf("masking.rules[%d]", i), "%s", strings.ReplaceAll(err.Error(), "\n", ", "))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// IntrospectOptions converts the configuration to the options of the database introspection
+func (c *Config) IntrospectOptions() introspect.Options {
+	return introspect.Options{
+		Schemas: c.Introspect.Schemas,
+	}
+}
+
+// SeedgenOptions converts the configuration to the options of the seed code builder
+func (c *Config) SeedgenOptions() seedgen.Options {
+	overrides := make([]seedgen.TypeOverride, 0, len(c.TypeOverrides))
+	for _, override := range c.TypeOverrides {
+		overrides = append(overrides, seedgen.TypeOverride{
+			Column: override.Column,
+			DBType: override.DBType,
+			GoType: override.GoType,
+			Import: override.Import,
+		})
+	}
+	return seedgen.Options{
+		PackageName:    c.Output.Package,
+		SeedFuncName:   c.Output.SeedFunc,
+		ModelsTypeName: c.Output.ModelsType,
+		IncludeTables:  c.IncludeTables,
+		ExcludeTables:  c.ExcludeTables,
+		TypeOverrides:  overrides,
+		FakeValues: utils.Map(c.FakeValues, func(value FakeValue) seedgen.FakeValue {
+			return seedgen.FakeValue{Column: value.Column, Kind: value.Kind}
+		}),
+		Naming: seedgen.NamingOptions{
+			Acronyms:           c.Naming.Acronyms,
+			StripTablePrefixes: c.Naming.StripTablePrefixes,
+		},
+	}
+}
+
+// FakeOverrides converts the fake values of the configuration to the overrides of a runtime faker
+func (c *Config) FakeOverrides() []fake.Override {
+	return utils.Map(c.FakeValues, func(value FakeValue) fake.Override {
+		return fake.Override{Column: value.Column, Kind: fake.Kind(value.Kind)}
+	})
+}
+
+// MaskRules converts the masking rules of the configuration to the rules of a masker
+func (c *Config) MaskRules() []mask.Rule {
+	return utils.Map(c.Masking.Rules, func(rule MaskRule) mask.Rule {
+		return mask.Rule{Column: rule.Column, Type: rule.Type, Strategy: mask.Strategy(rule.Strategy), Kind: fake.Kind(rule.Kind)}
+	})
+}
+
+func resolvePaths(baseDir string, paths []string) []string {
+	resolved := make([]string, len(paths))
+	for i, path := range paths {
+		resolved[i] = resolvePath(baseDir, path)
+	}
+	return resolved
+}
+
+func resolvePath(baseDir string, path string) string {
+	if path == "-" || filepath.IsAbs(path) || introspect.IsDSN(path) {
+		return path
+	}
+	return filepath.Join(baseDir, path)
+}
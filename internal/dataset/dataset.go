@@ -0,0 +1,328 @@
+package dataset
+
+import (
+	"fmt"
+	"github.com/tab58/go-integral/internal/fake"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"github.com/tab58/go-integral/internal/seeder"
+	"github.com/tab58/go-integral/internal/sqlscript"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Sink receives the generated rows, table by table in insert order
+type Sink interface {
+	// WriteRow receives a generated row of a table
+	WriteRow(table nodes.Table, row seeder.Row) error
+	// EndTable is called after the last row of a table, with the serial and identity columns the rows set explicitly,
+	// whose sequences must be moved past the generated values
+	EndTable(table nodes.Table, sequenceColumns []string) error
+}
+
+// reference is a foreign key of a table, with the columns of a composite foreign key grouped together
+type reference struct {
+	parentTable   string
+	columns       []string
+	parentColumns []string
+	// nullable is set when the rows can leave the reference null
+	nullable bool
+	// unique is set when a parent row can be referenced by one row at most
+	unique bool
+}
+
+// Generate generates the rows of a plan, with values from the faker, and writes them to the sink. Tables are generated
+// in insert order, so that every foreign key references rows already written: the foreign key a table's rows are
+// planned per parent row of goes through the parent rows in order, and the other foreign keys reference random rows
+// of their table. Only the key columns of the tables other tables reference are kept in memory, so large datasets can
+// be streamed. Columns with sequences that are referenced get explicit values counting from 1, so the plan is meant
+// for empty tables. It returns the number of rows generated for each table.
+func Generate(s *seeder.Seeder, faker *fake.Faker, plan Plan, sink Sink) (map[string]int, error) {
+	schema := s.Schema()
+	drivers, err := resolvePlan(schema, plan)
+	if err != nil {
+		return nil, err
+	}
+
+	referenced := referencedColumns(schema)
+	keys := make(map[string][]seeder.Row)
+	counts := make(map[string]int)
+	for _, table := range s.Tables() {
+		driver, hasDriver := drivers[table.Name]
+		count := plan.Counts[table.Name]
+		if !hasDriver && count == 0 {
+			continue
+		}
+
+		g := &tableGenerator{
+			seeder:     s,
+			faker:      faker,
+			table:      table,
+			references: references(table),
+			referenced: referenced[table.Name],
+			keys:       keys,
+			sequences:  make(map[string]int64),
+		}
+		emit := func(row seeder.Row) error {
+			if err := sink.WriteRow(table, row); err != nil {
+				return fmt.Errorf("unable to write row of table %s: %w", table.Name, err)
+			}
+			if len(g.referenced) > 0 {
+				keys[table.Name] = append(keys[table.Name], project(row, g.referenced))
+			}
+			counts[table.Name]++
+			return nil
+		}
+
+		if hasDriver {
+			for _, parent := range keys[driver.reference.parentTable] {
+				for range faker.Int(int64(driver.cardinality.Min), int64(driver.cardinality.Max)) {
+					row, err := g.row(counts[table.Name], &driver.reference, parent)
+					if err != nil {
+						return nil, err
+					}
+					if err := emit(row); err != nil {
+						return nil, err
+					}
+				}
+			}
+		} else {
+			for i := range count {
+				row, err := g.row(i, nil, nil)
+				if err != nil {
+					return nil, err
+				}
+				if err := emit(row); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		sequenceColumns := slices.Sorted(maps.Keys(g.sequences))
+		if err := sink.EndTable(table, sequenceColumns); err != nil {
+			return nil, fmt.Errorf("unable to write rows of table %s: %w", table.Name, err)
+		}
+	}
+	return counts, nil
+}
+
+type driver struct {
+	reference   reference
+	cardinality Cardinality
+}
+
+// resolvePlan checks the plan against the schema and finds the foreign keys of the per-parent cardinalities
+func resolvePlan(schema *nodes.PostgreSQLSchema, plan Plan) (map[string]driver, error) {
+	for tableName, count := range plan.Counts {
+		if _, ok := schema.Tables[tableName]; !ok {
+			return nil, fmt.Errorf("unknown table %q", tableName)
+		}
+		if count < 0 {
+			return nil, fmt.Errorf("negative row count for table %s", tableName)
+		}
+	}
+
+	drivers := make(map[string]driver)
+	for _, key := range slices.Sorted(maps.Keys(plan.PerParent)) {
+		cardinality := plan.PerParent[key]
+		if cardinality.Min < 0 || cardinality.Max < cardinality.Min {
+			return nil, fmt.Errorf("invalid cardinality %d-%d for %s", cardinality.Min, cardinality.Max, key)
+		}
+		tableName, columnName := key, ""
+		if _, ok := schema.Tables[key]; !ok {
+			if i := strings.LastIndex(key, "."); i >= 0 {
+				tableName, columnName = key[:i], key[i+1:]
+			}
+		}
+		table, ok := schema.Tables[tableName]
+		if !ok {
+			return nil, fmt.Errorf("unknown table %q", tableName)
+		}
+		if _, ok := drivers[tableName]; ok {
+			return nil, fmt.Errorf("table %s has more than one per-parent cardinality", tableName)
+		}
+		if _, ok := plan.Counts[tableName]; ok {
+			return nil, fmt.Errorf("table %s has both a count and a per-parent cardinality", tableName)
+		}
+
+		refs := references(table)
+		if columnName != "" {
+			refs = slices.DeleteFunc(refs, func(ref reference) bool { return !slices.Contains(ref.columns, columnName) })
+			if len(refs) == 0 {
+				return nil, fmt.Errorf("%s is not a foreign key column", key)
+			}
+		} else if len(refs) != 1 {
+			return nil, fmt.Errorf("table %s has %d foreign keys, name the column as %s.<column>", tableName, len(refs), tableName)
+		}
+		if refs[0].unique && cardinality.Max > 1 {
+			return nil, fmt.Errorf("%s is unique, so its cardinality can't be more than 1", key)
+		}
+		drivers[tableName] = driver{reference: refs[0], cardinality: cardinality}
+	}
+
+	// the rows of the planned tables must have rows to reference
+	planned := func(tableName string) bool {
+		_, ok := drivers[tableName]
+		return ok || plan.Counts[tableName] > 0
+	}
+	for _, tableName := range slices.Sorted(maps.Keys(schema.Tables)) {
+		if !planned(tableName) {
+			continue
+		}
+		for _, ref := range references(schema.Tables[tableName]) {
+			if !ref.nullable && !planned(ref.parentTable) {
+				return nil, fmt.Errorf("table %s references table %s, which has no rows in the plan", tableName, ref.parentTable)
+			}
+		}
+	}
+	return drivers, nil
+}
+
+type tableGenerator struct {
+	seeder     *seeder.Seeder
+	faker      *fake.Faker
+	table      nodes.Table
+	references []reference
+	// referenced are the columns of the table that foreign keys reference
+	referenced []string
+	keys       map[string][]seeder.Row
+	// sequences are the last values given to the referenced columns with sequences
+	sequences map[string]int64
+}
+
+// row generates the i-th row of the table, whose driving reference, if any, points to the parent row
+func (g *tableGenerator) row(i int, driving *reference, parent seeder.Row) (seeder.Row, error) {
+	row, err := g.seeder.FakeRow(g.faker, g.table.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ref := range g.references {
+		if driving != nil && slices.Equal(ref.columns, driving.columns) {
+			setReference(row, ref, parent)
+			continue
+		}
+		parents := g.keys[ref.parentTable]
+		index := -1
+		if ref.unique {
+			// a parent row can't be referenced twice, so the rows take the parent rows in order
+			if i < len(parents) {
+				index = i
+			}
+		} else if len(parents) > 0 {
+			index = int(g.faker.Int(0, int64(len(parents)-1)))
+		}
+		if index < 0 {
+			if !ref.nullable {
+				return nil, fmt.Errorf("table %s: not enough rows of table %s to reference", g.table.Name, ref.parentTable)
+			}
+			setReference(row, ref, nil)
+			continue
+		}
+		setReference(row, ref, parents[index])
+	}
+
+	// the referenced columns need values to be referenced with, even when the database could fill them
+	for _, columnName := range g.referenced {
+		if _, ok := row[columnName]; ok {
+			continue
+		}
+		column, _ := findColumn(g.table, columnName)
+		if sqlscript.HasSequence(column) {
+			g.sequences[columnName]++
+			row[columnName] = g.sequences[columnName]
+			continue
+		}
+		value, ok := g.faker.Value(g.table, column, g.seeder.Schema().Enums)
+		if !ok {
+			return nil, fmt.Errorf("table %s: no fake values for referenced column %s of type %s", g.table.Name, columnName, column.DataType)
+		}
+		row[columnName] = value
+	}
+	return row, nil
+}
+
+// setReference sets the columns of a reference to the key of a parent row, or to null without one
+func setReference(row seeder.Row, ref reference, parent seeder.Row) {
+	for i, columnName := range ref.columns {
+		if parent == nil {
+			row[columnName] = nil
+			continue
+		}
+		row[columnName] = parent[ref.parentColumns[i]]
+	}
+}
+
+// references returns the foreign keys of a table
+func references(table nodes.Table) []reference {
+	refs := make([]reference, 0)
+	for _, foreignKey := range table.ForeignKeys() {
+		refs = append(refs, reference{
+			parentTable:   foreignKey.ReferencedTable,
+			columns:       foreignKey.Columns,
+			parentColumns: foreignKey.ReferencedColumns,
+			nullable:      !slices.ContainsFunc(foreignKey.Columns, table.IsNotNullColumn),
+			unique:        isUniqueKey(table, foreignKey.Columns),
+		})
+	}
+	return refs
+}
+
+// isUniqueKey returns whether columns are the primary key or a unique key of a table on their own
+func isUniqueKey(table nodes.Table, columnNames []string) bool {
+	sameColumns := func(keyColumns []string) bool {
+		return len(keyColumns) == len(columnNames) && !slices.ContainsFunc(columnNames, func(columnName string) bool {
+			return !slices.Contains(keyColumns, columnName)
+		})
+	}
+	if sameColumns(table.PrimaryKey) {
+		return true
+	}
+	for _, constraint := range table.Constraints {
+		if info, ok := constraint.Constraint.(*nodes.UniqueConstraintInfo); ok && sameColumns(info.ColumnNames) {
+			return true
+		}
+	}
+	if len(columnNames) != 1 {
+		return false
+	}
+	column, _ := findColumn(table, columnNames[0])
+	return slices.ContainsFunc(column.Constraints, func(c nodes.ColumnConstraint) bool {
+		return c.Type == nodes.ConstraintInfoTypeUnique || c.Type == nodes.ConstraintInfoTypePrimaryKey
+	})
+}
+
+// referencedColumns returns the columns of each table that foreign keys reference
+func referencedColumns(schema *nodes.PostgreSQLSchema) map[string][]string {
+	referenced := make(map[string][]string)
+	for _, table := range schema.Tables {
+		for _, ref := range references(table) {
+			for _, columnName := range ref.parentColumns {
+				if !slices.Contains(referenced[ref.parentTable], columnName) {
+					referenced[ref.parentTable] = append(referenced[ref.parentTable], columnName)
+				}
+			}
+		}
+	}
+	for _, columnNames := range referenced {
+		slices.Sort(columnNames)
+	}
+	return referenced
+}
+
+func project(row seeder.Row, columnNames []string) seeder.Row {
+	projected := make(seeder.Row, len(columnNames))
+	for _, columnName := range columnNames {
+		projected[columnName] = row[columnName]
+	}
+	return projected
+}
+
+func findColumn(table nodes.Table, columnName string) (nodes.Column, bool) {
+	for _, column := range table.Columns {
+		if column.Name == columnName {
+			return column, true
+		}
+	}
+	return nodes.Column{}, false
+}
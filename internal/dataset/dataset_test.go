@@ -0,0 +1,281 @@
+package dataset
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tab58/go-integral/internal/fake"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"github.com/tab58/go-integral/internal/seeder"
+)
+
+const datasetSchema = `
+CREATE TABLE users (id serial PRIMARY KEY, email text NOT NULL);
+CREATE TABLE coupons (code text PRIMARY KEY, percent int NOT NULL);
+CREATE TABLE profiles (id serial PRIMARY KEY, user_id int NOT NULL UNIQUE REFERENCES users (id), bio text);
+CREATE TABLE orders (
+  id serial PRIMARY KEY,
+  user_id int NOT NULL REFERENCES users (id),
+  coupon_code text REFERENCES coupons (code)
+);
+CREATE TABLE order_items (order_id int NOT NULL REFERENCES orders (id), quantity int NOT NULL);
+`
+
+func newTestSeeder(t *testing.T) *seeder.Seeder {
+	t.Helper()
+	schema, err := nodes.NewPostgreSQLSchema(datasetSchema)
+	if err != nil {
+		t.Fatalf("NewPostgreSQLSchema() error = %v", err)
+	}
+	s, err := seeder.New(schema)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return s
+}
+
+func TestResolvePlan(t *testing.T) {
+	s := newTestSeeder(t)
+	tests := []struct {
+		name    string
+		plan    Plan
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "counts only",
+			plan: Plan{Counts: map[string]int{"users": 10, "orders": 20}},
+			want: map[string]string{},
+		},
+		{
+			name: "per parent of a table with one foreign key",
+			plan: Plan{Counts: map[string]int{"users": 10}, PerParent: map[string]Cardinality{"profiles": {Min: 0, Max: 1}}},
+			want: map[string]string{"profiles": "user_id -> users 0-1 unique"},
+		},
+		{
+			name: "per parent of a foreign key column",
+			plan: Plan{Counts: map[string]int{"users": 10}, PerParent: map[string]Cardinality{"orders.user_id": {Min: 1, Max: 5}}},
+			want: map[string]string{"orders": "user_id -> users 1-5"},
+		},
+		{
+			name: "nullable reference to a table without rows",
+			plan: Plan{Counts: map[string]int{"users": 1, "orders": 1}},
+			want: map[string]string{},
+		},
+		{
+			name:    "unknown table of a count",
+			plan:    Plan{Counts: map[string]int{"carts": 1}},
+			wantErr: `unknown table "carts"`,
+		},
+		{
+			name:    "negative count",
+			plan:    Plan{Counts: map[string]int{"users": -1}},
+			wantErr: "negative row count for table users",
+		},
+		{
+			name:    "invalid cardinality",
+			plan:    Plan{PerParent: map[string]Cardinality{"profiles": {Min: 2, Max: 1}}},
+			wantErr: "invalid cardinality 2-1 for profiles",
+		},
+		{
+			name:    "unknown table of a cardinality",
+			plan:    Plan{PerParent: map[string]Cardinality{"carts.user_id": {Min: 1, Max: 1}}},
+			wantErr: `unknown table "carts"`,
+		},
+		{
+			name:    "column that isn't a foreign key",
+			plan:    Plan{PerParent: map[string]Cardinality{"orders.id": {Min: 1, Max: 1}}},
+			wantErr: "orders.id is not a foreign key column",
+		},
+		{
+			name:    "table with more than one foreign key",
+			plan:    Plan{PerParent: map[string]Cardinality{"orders": {Min: 1, Max: 1}}},
+			wantErr: "table orders has 2 foreign keys, name the column as orders.<column>",
+		},
+		{
+			name:    "table with two cardinalities",
+			plan:    Plan{PerParent: map[string]Cardinality{"orders.user_id": {Min: 1, Max: 1}, "orders.coupon_code": {Min: 1, Max: 1}}},
+			wantErr: "table orders has more than one per-parent cardinality",
+		},
+		{
+			name:    "count and cardinality of a table",
+			plan:    Plan{Counts: map[string]int{"profiles": 1}, PerParent: map[string]Cardinality{"profiles": {Min: 1, Max: 1}}},
+			wantErr: "table profiles has both a count and a per-parent cardinality",
+		},
+		{
+			name:    "unique foreign key with more than one row per parent",
+			plan:    Plan{PerParent: map[string]Cardinality{"profiles.user_id": {Min: 1, Max: 2}}},
+			wantErr: "profiles.user_id is unique, so its cardinality can't be more than 1",
+		},
+		{
+			name:    "required reference to a table without rows",
+			plan:    Plan{Counts: map[string]int{"users": 0, "orders": 1}},
+			wantErr: "table orders references table users, which has no rows in the plan",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			drivers, err := resolvePlan(s.Schema(), tt.plan)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("resolvePlan() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolvePlan() error = %v", err)
+			}
+			got := make(map[string]string, len(drivers))
+			for tableName, d := range drivers {
+				got[tableName] = fmt.Sprintf("%s -> %s %d-%d", strings.Join(d.reference.columns, ", "), d.reference.parentTable,
+					d.cardinality.Min, d.cardinality.Max)
+				if d.reference.unique {
+					got[tableName] += " unique"
+				}
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("resolvePlan() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// recordingSink records the rows written to it by table, and the sequence columns each table ended with
+type recordingSink struct {
+	rows            map[string][]seeder.Row
+	sequenceColumns map[string][]string
+	ended           []string
+}
+
+func newRecordingSink() *recordingSink {
+	return &recordingSink{rows: make(map[string][]seeder.Row), sequenceColumns: make(map[string][]string)}
+}
+
+func (s *recordingSink) WriteRow(table nodes.Table, row seeder.Row) error {
+	s.rows[table.Name] = append(s.rows[table.Name], row)
+	return nil
+}
+
+func (s *recordingSink) EndTable(table nodes.Table, sequenceColumns []string) error {
+	s.ended = append(s.ended, table.Name)
+	s.sequenceColumns[table.Name] = sequenceColumns
+	return nil
+}
+
+func TestGenerate(t *testing.T) {
+	s := newTestSeeder(t)
+	sink := newRecordingSink()
+	plan := Plan{
+		Counts: map[string]int{"users": 4, "coupons": 3, "profiles": 3},
+		PerParent: map[string]Cardinality{
+			"orders.user_id": {Min: 2, Max: 2},
+			"order_items":    {Min: 1, Max: 3},
+		},
+	}
+	counts, err := Generate(s, fake.New(1), plan, sink)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	itemCount := len(sink.rows["order_items"])
+	if itemCount < 8 || itemCount > 24 {
+		t.Errorf("order_items has %d rows, want 1 to 3 for each of the 8 orders", itemCount)
+	}
+	wantCounts := map[string]int{"users": 4, "coupons": 3, "profiles": 3, "orders": 8, "order_items": itemCount}
+	if diff := cmp.Diff(wantCounts, counts); diff != "" {
+		t.Errorf("Generate() counts mismatch (-want +got):\n%s", diff)
+	}
+	wantEnded := []string{"coupons", "users", "orders", "profiles", "order_items"}
+	if diff := cmp.Diff(wantEnded, sink.ended); diff != "" {
+		t.Errorf("ended tables mismatch (-want +got):\n%s", diff)
+	}
+
+	// the referenced serial columns count from 1, and the other columns are left to the database
+	wantSequenceColumns := map[string][]string{
+		"coupons":     nil,
+		"users":       {"id"},
+		"orders":      {"id"},
+		"order_items": nil,
+		"profiles":    nil,
+	}
+	if diff := cmp.Diff(wantSequenceColumns, sink.sequenceColumns); diff != "" {
+		t.Errorf("sequence columns mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]any{int64(1), int64(2), int64(3), int64(4)}, columnValues(sink.rows["users"], "id")); diff != "" {
+		t.Errorf("users ids mismatch (-want +got):\n%s", diff)
+	}
+	for _, profile := range sink.rows["profiles"] {
+		if _, ok := profile["id"]; ok {
+			t.Errorf("profile %v sets its id, which no foreign key references", profile)
+		}
+	}
+
+	// the driving foreign key goes through the parent rows in order
+	wantUserIDs := []any{int64(1), int64(1), int64(2), int64(2), int64(3), int64(3), int64(4), int64(4)}
+	if diff := cmp.Diff(wantUserIDs, columnValues(sink.rows["orders"], "user_id")); diff != "" {
+		t.Errorf("orders user_id mismatch (-want +got):\n%s", diff)
+	}
+	// a unique foreign key takes each parent row once
+	if diff := cmp.Diff([]any{int64(1), int64(2), int64(3)}, columnValues(sink.rows["profiles"], "user_id")); diff != "" {
+		t.Errorf("profiles user_id mismatch (-want +got):\n%s", diff)
+	}
+	// the other foreign keys reference generated rows
+	couponCodes := columnValues(sink.rows["coupons"], "code")
+	for _, order := range sink.rows["orders"] {
+		if !containsValue(couponCodes, order["coupon_code"]) {
+			t.Errorf("order %v references no generated coupon", order)
+		}
+	}
+	orderIDs := columnValues(sink.rows["orders"], "id")
+	for _, item := range sink.rows["order_items"] {
+		if !containsValue(orderIDs, item["order_id"]) {
+			t.Errorf("order item %v references no generated order", item)
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		plan    Plan
+		wantErr string
+	}{
+		{
+			name:    "more rows of a unique foreign key than parent rows",
+			plan:    Plan{Counts: map[string]int{"users": 2, "profiles": 3}},
+			wantErr: "table profiles: not enough rows of table users to reference",
+		},
+		{
+			name:    "invalid plan",
+			plan:    Plan{Counts: map[string]int{"orders": 1}},
+			wantErr: "table orders references table users, which has no rows in the plan",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Generate(newTestSeeder(t), fake.New(1), tt.plan, newRecordingSink())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Generate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func columnValues(rows []seeder.Row, columnName string) []any {
+	values := make([]any, 0, len(rows))
+	for _, row := range rows {
+		values = append(values, row[columnName])
+	}
+	return values
+}
+
+func containsValue(values []any, value any) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package dataset
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Cardinality is the range of the number of rows of a table generated for each row of a table it references, drawn
+// uniformly between Min and Max, inclusive
+type Cardinality struct {
+	Min int
+	Max int
+}
+
+// Plan sets the number of rows generated for each table, either as a count or relative to a table it references.
+// Tables the plan leaves out get no rows.
+type Plan struct {
+	// Counts are the numbers of rows of tables, keyed by table name
+	Counts map[string]int
+	// PerParent are the numbers of rows of tables for each row of the table a foreign key references, keyed by the
+	// "table.column" name of the foreign key column, or by the table name when it has a single foreign key
+	PerParent map[string]Cardinality
+}
+
+// ParseCount parses a table row count of the form "users=10000"
+func ParseCount(s string) (tableName string, count int, err error) {
+	tableName, value, ok := strings.Cut(s, "=")
+	if !ok || tableName == "" {
+		return "", 0, fmt.Errorf("%q is not of the form table=count", s)
+	}
+	count, err = strconv.Atoi(value)
+	if err != nil || count < 0 {
+		return "", 0, fmt.Errorf("%q: the count must be a number of rows", s)
+	}
+	return tableName, count, nil
+}
+
+// ParseCardinality parses a per-parent cardinality of the form "orders.user_id=3-8", or "orders=3" for exactly 3
+// rows per parent
+func ParseCardinality(s string) (key string, cardinality Cardinality, err error) {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok || key == "" {
+		return "", Cardinality{}, fmt.Errorf("%q is not of the form table.column=min-max", s)
+	}
+	minValue, maxValue, isRange := strings.Cut(value, "-")
+	if !isRange {
+		maxValue = minValue
+	}
+	cardinality.Min, err = strconv.Atoi(minValue)
+	if err == nil {
+		cardinality.Max, err = strconv.Atoi(maxValue)
+	}
+	if err != nil || cardinality.Min < 0 || cardinality.Max < cardinality.Min {
+		return "", Cardinality{}, fmt.Errorf("%q: the cardinality must be a number or a min-max range", s)
+	}
+	return key, cardinality, nil
+}
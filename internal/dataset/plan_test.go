@@ -0,0 +1,83 @@
+package dataset
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseCount(t *testing.T) {
+	tests := []struct {
+		name          string
+		s             string
+		wantTableName string
+		wantCount     int
+		wantErr       string
+	}{
+		{name: "table count", s: "users=10000", wantTableName: "users", wantCount: 10000},
+		{name: "zero rows", s: "users=0", wantTableName: "users", wantCount: 0},
+		{name: "qualified table", s: "billing.invoices=5", wantTableName: "billing.invoices", wantCount: 5},
+		{name: "missing count", s: "users", wantErr: `"users" is not of the form table=count`},
+		{name: "missing table", s: "=3", wantErr: `"=3" is not of the form table=count`},
+		{name: "count that isn't a number", s: "users=many", wantErr: `"users=many": the count must be a number of rows`},
+		{name: "negative count", s: "users=-1", wantErr: `"users=-1": the count must be a number of rows`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tableName, count, err := ParseCount(tt.s)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseCount() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseCount() error = %v", err)
+			}
+			if tableName != tt.wantTableName || count != tt.wantCount {
+				t.Errorf("ParseCount() = %s, %d, want %s, %d", tableName, count, tt.wantTableName, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestParseCardinality(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		wantKey string
+		want    Cardinality
+		wantErr string
+	}{
+		{name: "range of a foreign key column", s: "orders.user_id=3-8", wantKey: "orders.user_id", want: Cardinality{Min: 3, Max: 8}},
+		{name: "exact number of a table", s: "orders=3", wantKey: "orders", want: Cardinality{Min: 3, Max: 3}},
+		{name: "range starting at zero", s: "orders=0-2", wantKey: "orders", want: Cardinality{Min: 0, Max: 2}},
+		{name: "missing cardinality", s: "orders.user_id", wantErr: `"orders.user_id" is not of the form table.column=min-max`},
+		{name: "missing key", s: "=1-2", wantErr: `"=1-2" is not of the form table.column=min-max`},
+		{name: "reversed range", s: "orders=8-3", wantErr: `"orders=8-3": the cardinality must be a number or a min-max range`},
+		{name: "open range", s: "orders=3-", wantErr: `"orders=3-": the cardinality must be a number or a min-max range`},
+		{name: "negative number", s: "orders=-2", wantErr: `"orders=-2": the cardinality must be a number or a min-max range`},
+		{name: "number that isn't one", s: "orders=few", wantErr: `"orders=few": the cardinality must be a number or a min-max range`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, cardinality, err := ParseCardinality(tt.s)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseCardinality() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseCardinality() error = %v", err)
+			}
+			if key != tt.wantKey {
+				t.Errorf("ParseCardinality() key = %s, want %s", key, tt.wantKey)
+			}
+			if diff := cmp.Diff(tt.want, cardinality); diff != "" {
+				t.Errorf("ParseCardinality() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package dataset
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"github.com/tab58/go-integral/internal/seeder"
+	"github.com/tab58/go-integral/internal/sqlscript"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// batchRows is the number of rows the sinks buffer before inserting or writing them in one statement
+const batchRows = 1000
+
+// DatabaseSink inserts the rows into a database in batches, with multi-row INSERT statements
+type DatabaseSink struct {
+	ctx    context.Context
+	seeder *seeder.Seeder
+	db     seeder.Querier
+	batch  []seeder.Row
+}
+
+// NewDatabaseSink creates a sink inserting the rows into a database, a transaction being the way to make the whole
+// dataset inserted or none of it
+func NewDatabaseSink(ctx context.Context, s *seeder.Seeder, db seeder.Querier) *DatabaseSink {
+	return &DatabaseSink{ctx: ctx, seeder: s, db: db}
+}
+
+func (d *DatabaseSink) WriteRow(table nodes.Table, row seeder.Row) error {
+	d.batch = append(d.batch, row)
+	if len(d.batch) < batchRows {
+		return nil
+	}
+	return d.flush(table)
+}
+
+func (d *DatabaseSink) EndTable(table nodes.Table, sequenceColumns []string) error {
+	if err := d.flush(table); err != nil {
+		return err
+	}
+	for _, columnName := range sequenceColumns {
+		rows, err := d.db.QueryContext(d.ctx, sqlscript.SequenceReset(table, columnName))
+		if err != nil {
+			return fmt.Errorf("unable to reset the sequence of column %s: %w", columnName, err)
+		}
+		if err := rows.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *DatabaseSink) flush(table nodes.Table) error {
+	if len(d.batch) == 0 {
+		return nil
+	}
+	err := d.seeder.InsertMany(d.ctx, d.db, table.Name, d.batch)
+	d.batch = d.batch[:0]
+	return err
+}
+
+// SQLSink writes the rows as an SQL script
+type SQLSink struct {
+	script *sqlscript.Writer
+	size   int
+	batch  []seeder.Row
+}
+
+// NewSQLSink creates a sink writing the rows as a script of INSERT statements, or of COPY blocks for the batches of
+// at least opts.CopyRows rows. enums are the labels of the enum types of the schema. Close must be called once the
+// rows are generated.
+func NewSQLSink(w io.Writer, enums map[string][]string, opts sqlscript.Options) *SQLSink {
+	return &SQLSink{script: sqlscript.NewWriter(w, enums, opts), size: max(batchRows, opts.CopyRows)}
+}
+
+func (s *SQLSink) WriteRow(table nodes.Table, row seeder.Row) error {
+	s.batch = append(s.batch, row)
+	if len(s.batch) < s.size {
+		return nil
+	}
+	return s.flush(table)
+}
+
+func (s *SQLSink) EndTable(table nodes.Table, sequenceColumns []string) error {
+	if err := s.flush(table); err != nil {
+		return err
+	}
+	return s.script.WriteSequenceResets(table, sequenceColumns)
+}
+
+// Close ends the script and flushes it to the underlying writer
+func (s *SQLSink) Close() error {
+	return s.script.Close()
+}
+
+func (s *SQLSink) flush(table nodes.Table) error {
+	err := s.script.WriteRows(table, s.batch)
+	s.batch = s.batch[:0]
+	return err
+}
+
+// CSVSink writes the rows of each table to a <table>.csv file of a directory, in the format LoadCSV reads: a header
+// row of column names and \N for NULL
+type CSVSink struct {
+	dir     string
+	enums   map[string][]string
+	file    *os.File
+	writer  *csv.Writer
+	columns []nodes.Column
+	header  map[string]bool
+}
+
+// NewCSVSink creates a sink writing CSV files into a directory, which is created if needed. enums are the labels of
+// the enum types of the schema. Close must be called once the rows are generated.
+func NewCSVSink(dir string, enums map[string][]string) (*CSVSink, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("unable to create CSV directory: %w", err)
+	}
+	return &CSVSink{dir: dir, enums: enums}, nil
+}
+
+func (c *CSVSink) WriteRow(table nodes.Table, row seeder.Row) error {
+	if c.file == nil {
+		if err := c.open(table, row); err != nil {
+			return err
+		}
+	}
+
+	record := make([]string, len(c.columns))
+	for i, column := range c.columns {
+		value, err := seeder.Coerce(column, row[column.Name], c.enums)
+		if err != nil {
+			return err
+		}
+		if value == nil {
+			record[i] = `\N`
+			continue
+		}
+		if record[i], err = sqlscript.Text(column, value); err != nil {
+			return err
+		}
+	}
+	for columnName := range row {
+		if !c.header[columnName] {
+			return fmt.Errorf("column %s is missing from the header of %s", columnName, c.file.Name())
+		}
+	}
+	return c.writer.Write(record)
+}
+
+// EndTable closes the file of the table. The sequences are left to the program loading the files.
+func (c *CSVSink) EndTable(table nodes.Table, sequenceColumns []string) error {
+	return c.Close()
+}
+
+// Close closes the file being written, if any
+func (c *CSVSink) Close() error {
+	if c.file == nil {
+		return nil
+	}
+	c.writer.Flush()
+	err := c.writer.Error()
+	if closeErr := c.file.Close(); err == nil {
+		err = closeErr
+	}
+	c.file, c.writer = nil, nil
+	return err
+}
+
+// open creates the file of a table, with the columns of its first row in the header
+func (c *CSVSink) open(table nodes.Table, row seeder.Row) error {
+	file, err := os.Create(filepath.Join(c.dir, table.Name+".csv"))
+	if err != nil {
+		return fmt.Errorf("unable to create CSV file: %w", err)
+	}
+	c.file, c.writer = file, csv.NewWriter(file)
+	c.columns, c.header = nil, make(map[string]bool, len(row))
+	header := make([]string, 0, len(row))
+	for _, column := range table.Columns {
+		if _, ok := row[column.Name]; ok {
+			c.columns = append(c.columns, column)
+			c.header[column.Name] = true
+			header = append(header, column.Name)
+		}
+	}
+	return c.writer.Write(header)
+}
@@ -0,0 +1,310 @@
+package extract
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"github.com/tab58/go-integral/internal/seeder"
+	"math"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/lib/pq"
+)
+
+// maxLookupKeys bounds the keys a query looks rows up by, which PostgreSQL also limits to 65535 parameters
+const maxLookupKeys = 1000
+
+// Selection is a set of rows an extract starts from
+type Selection struct {
+	Table string
+	// Where is the SQL condition of the rows, inserted in the query as is. Empty selects every row of the table.
+	Where string
+}
+
+// Options controls which rows an extract takes besides the selected ones and the rows they reference
+type Options struct {
+	// Children also takes the rows referencing the selected rows, recursively, along with the rows they reference
+	Children bool
+}
+
+// ParseSelection parses a selection like "orders WHERE id = 42", or a table name alone for all of its rows
+func ParseSelection(s string) (Selection, error) {
+	tableName, condition := cutSpace(strings.TrimSpace(s))
+	if tableName == "" {
+		return Selection{}, errors.New("expected a table name, like orders WHERE id = 42")
+	}
+	condition = strings.TrimSpace(condition)
+	if condition == "" {
+		return Selection{Table: tableName}, nil
+	}
+	keyword, where := cutSpace(condition)
+	if !strings.EqualFold(keyword, "WHERE") || strings.TrimSpace(where) == "" {
+		return Selection{}, fmt.Errorf("%q: expected WHERE and a condition after the table name", s)
+	}
+	return Selection{Table: tableName, Where: strings.TrimSpace(where)}, nil
+}
+
+// cutSpace splits s around its first whitespace
+func cutSpace(s string) (before, after string) {
+	i := strings.IndexFunc(s, unicode.IsSpace)
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], strings.TrimSpace(s[i:])
+}
+
+// Rows reads the selected rows from a database, along with the rows they reference, recursively, so that the result
+// can be inserted into another database on its own. With Options.Children, the rows referencing the selected rows are
+// taken too, recursively, but the rows only taken because they are referenced don't bring the other rows referencing
+// them. The rows of each table are sorted by primary key, which only keeps the rows insertable in order because the
+// seeder rejects the schemas whose foreign keys form cycles, tables referencing themselves included: the rows of such
+// a table would have to come after the rows they reference.
+func Rows(ctx context.Context, s *seeder.Seeder, db seeder.Querier, selections []Selection, opts Options) (seeder.Dataset, error) {
+	t := &taker{
+		ctx:    ctx,
+		seeder: s,
+		db:     db,
+		opts:   opts,
+		data:   make(seeder.Dataset),
+		seen:   make(map[string]map[string]bool),
+	}
+	for _, selection := range selections {
+		table, ok := s.Schema().Tables[selection.Table]
+		if !ok {
+			return nil, fmt.Errorf("unknown table %q", selection.Table)
+		}
+		rows, err := s.Select(ctx, db, table.Name, selection.Where)
+		if err != nil {
+			return nil, fmt.Errorf("unable to select rows of %s: %w", table.Name, err)
+		}
+		t.add(table, rows, opts.Children)
+	}
+
+	for len(t.pending) > 0 {
+		next := t.pending[0]
+		t.pending = t.pending[1:]
+		if err := t.follow(next); err != nil {
+			return nil, err
+		}
+	}
+
+	for tableName, rows := range t.data {
+		table := s.Schema().Tables[tableName]
+		slices.SortStableFunc(rows, func(a, b seeder.Row) int {
+			for _, columnName := range table.PrimaryKey {
+				if n := compareValues(a[columnName], b[columnName]); n != 0 {
+					return n
+				}
+			}
+			return 0
+		})
+	}
+	return t.data, nil
+}
+
+// taker collects the rows of an extract, following the foreign keys of the rows it hasn't followed yet
+type taker struct {
+	ctx    context.Context
+	seeder *seeder.Seeder
+	db     seeder.Querier
+	opts   Options
+	data   seeder.Dataset
+	// seen holds the keys of the rows taken by table, with whether the rows referencing them are taken too
+	seen    map[string]map[string]bool
+	pending []pending
+}
+
+// pending are rows whose foreign keys are still to be followed
+type pending struct {
+	table    nodes.Table
+	rows     []seeder.Row
+	children bool
+}
+
+// add takes the rows not taken yet, and queues the rows whose foreign keys haven't been followed as far as asked
+func (t *taker) add(table nodes.Table, rows []seeder.Row, children bool) {
+	if t.seen[table.Name] == nil {
+		t.seen[table.Name] = make(map[string]bool)
+	}
+	queued := make([]seeder.Row, 0)
+	for _, row := range rows {
+		key := rowKey(table, row)
+		followedChildren, ok := t.seen[table.Name][key]
+		if !ok {
+			t.data[table.Name] = append(t.data[table.Name], row)
+		}
+		if ok && (followedChildren || !children) {
+			continue
+		}
+		t.seen[table.Name][key] = children
+		queued = append(queued, row)
+	}
+	if len(queued) > 0 {
+		t.pending = append(t.pending, pending{table: table, rows: queued, children: children})
+	}
+}
+
+// follow takes the rows the pending rows reference, and the rows referencing them when their children are followed
+func (t *taker) follow(p pending) error {
+	schema := t.seeder.Schema()
+	for _, foreignKey := range p.table.ForeignKeys() {
+		parent := schema.Tables[foreignKey.ReferencedTable]
+		keys := slices.DeleteFunc(keyValues(p.rows, foreignKey.Columns), func(key []any) bool {
+			return t.taken(parent, foreignKey.ReferencedColumns, key)
+		})
+		rows, err := t.lookup(parent, foreignKey.ReferencedColumns, keys)
+		if err != nil {
+			return fmt.Errorf("unable to read the %s rows %s references: %w", parent.Name, p.table.Name, err)
+		}
+		t.add(parent, rows, false)
+	}
+	if !p.children {
+		return nil
+	}
+
+	for _, child := range t.seeder.Tables() {
+		for _, foreignKey := range child.ForeignKeys() {
+			if foreignKey.ReferencedTable != p.table.Name {
+				continue
+			}
+			rows, err := t.lookup(child, foreignKey.Columns, keyValues(p.rows, foreignKey.ReferencedColumns))
+			if err != nil {
+				return fmt.Errorf("unable to read the %s rows referencing %s: %w", child.Name, p.table.Name, err)
+			}
+			t.add(child, rows, true)
+		}
+	}
+	return nil
+}
+
+// taken returns whether the row with values for columns is already taken, which is only known when the columns are
+// the primary key of the table
+func (t *taker) taken(table nodes.Table, columnNames []string, key []any) bool {
+	if len(table.PrimaryKey) == 0 || len(table.PrimaryKey) != len(columnNames) {
+		return false
+	}
+	row := make(seeder.Row, len(columnNames))
+	for i, columnName := range columnNames {
+		row[columnName] = key[i]
+	}
+	for _, columnName := range table.PrimaryKey {
+		if _, ok := row[columnName]; !ok {
+			return false
+		}
+	}
+	_, ok := t.seen[table.Name][rowKey(table, row)]
+	return ok
+}
+
+// lookup reads the rows of a table whose columns have one of the keys
+func (t *taker) lookup(table nodes.Table, columnNames []string, keys [][]any) ([]seeder.Row, error) {
+	quoted := make([]string, len(columnNames))
+	for i, columnName := range columnNames {
+		quoted[i] = pq.QuoteIdentifier(columnName)
+	}
+	batchKeys := min(maxLookupKeys, math.MaxUint16/len(columnNames))
+
+	found := make([]seeder.Row, 0)
+	for start := 0; start < len(keys); start += batchKeys {
+		batch := keys[start:min(start+batchKeys, len(keys))]
+		args := make([]any, 0, len(batch)*len(columnNames))
+		tuples := make([]string, len(batch))
+		for i, key := range batch {
+			placeholders := make([]string, len(key))
+			for j, value := range key {
+				args = append(args, value)
+				placeholders[j] = fmt.Sprintf("$%d", len(args))
+			}
+			tuples[i] = "(" + strings.Join(placeholders, ", ") + ")"
+		}
+		condition := fmt.Sprintf("(%s) IN (%s)", strings.Join(quoted, ", "), strings.Join(tuples, ", "))
+		rows, err := t.seeder.Select(t.ctx, t.db, table.Name, condition, args...)
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, rows...)
+	}
+	return found, nil
+}
+
+// keyValues returns the distinct values the rows have for columns, leaving out the ones with nulls, which reference
+// no row
+func keyValues(rows []seeder.Row, columnNames []string) [][]any {
+	keys := make([][]any, 0)
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		key := make([]any, len(columnNames))
+		for i, columnName := range columnNames {
+			key[i] = row[columnName]
+		}
+		if slices.Contains(key, nil) {
+			continue
+		}
+		text := keyText(key)
+		if !seen[text] {
+			seen[text] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// rowKey identifies a row of a table by its primary key, or by all of its values when the table has none
+func rowKey(table nodes.Table, row seeder.Row) string {
+	columnNames := table.PrimaryKey
+	if len(columnNames) == 0 {
+		columnNames = make([]string, len(table.Columns))
+		for i, column := range table.Columns {
+			columnNames[i] = column.Name
+		}
+	}
+	key := make([]any, len(columnNames))
+	for i, columnName := range columnNames {
+		key[i] = row[columnName]
+	}
+	return keyText(key)
+}
+
+func keyText(key []any) string {
+	parts := make([]string, len(key))
+	for i, value := range key {
+		parts[i] = fmt.Sprintf("%T:%v", value, value)
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// compareValues orders the values read from the database, nulls first
+func compareValues(a, b any) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	switch a := a.(type) {
+	case int64:
+		if b, ok := b.(int64); ok {
+			return cmp.Compare(a, b)
+		}
+	case float64:
+		if b, ok := b.(float64); ok {
+			return cmp.Compare(a, b)
+		}
+	case string:
+		if b, ok := b.(string); ok {
+			return cmp.Compare(a, b)
+		}
+	case time.Time:
+		if b, ok := b.(time.Time); ok {
+			return a.Compare(b)
+		}
+	}
+	return cmp.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
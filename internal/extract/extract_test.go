@@ -0,0 +1,400 @@
+package extract
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"github.com/tab58/go-integral/internal/seeder"
+)
+
+const extractSchema = `
+CREATE TABLE users (id int PRIMARY KEY, name text);
+CREATE TABLE orders (
+  id int PRIMARY KEY,
+  user_id int NOT NULL REFERENCES users (id),
+  placed_by int REFERENCES users (id)
+);
+CREATE TABLE order_items (order_id int REFERENCES orders (id), sku text, PRIMARY KEY (order_id, sku));
+`
+
+// extractRows are the rows of the tables of extractSchema in the fake database
+var extractRows = map[string][]seeder.Row{
+	"users": {
+		{"id": int64(1), "name": "ann"},
+		{"id": int64(2), "name": "bob"},
+		{"id": int64(3), "name": "cid"},
+	},
+	"orders": {
+		{"id": int64(12), "user_id": int64(3), "placed_by": int64(3)},
+		{"id": int64(10), "user_id": int64(1), "placed_by": int64(2)},
+		{"id": int64(11), "user_id": int64(1), "placed_by": nil},
+	},
+	"order_items": {
+		{"order_id": int64(10), "sku": "b"},
+		{"order_id": int64(10), "sku": "a"},
+		{"order_id": int64(11), "sku": "a"},
+		{"order_id": int64(12), "sku": "c"},
+	},
+}
+
+func newTestSeeder(t *testing.T) *seeder.Seeder {
+	t.Helper()
+	schema, err := nodes.NewPostgreSQLSchema(extractSchema)
+	if err != nil {
+		t.Fatalf("NewPostgreSQLSchema() error = %v", err)
+	}
+	s, err := seeder.New(schema)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return s
+}
+
+func TestParseSelection(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    Selection
+		wantErr string
+	}{
+		{name: "table alone", s: "orders", want: Selection{Table: "orders"}},
+		{name: "condition", s: "orders WHERE id = 42", want: Selection{Table: "orders", Where: "id = 42"}},
+		{name: "lower case keyword", s: "  orders where id = 42 ", want: Selection{Table: "orders", Where: "id = 42"}},
+		{
+			name: "condition with line breaks",
+			s:    "orders\nWHERE\tstatus = 'paid'\n  AND total > 10",
+			want: Selection{Table: "orders", Where: "status = 'paid'\n  AND total > 10"},
+		},
+		{name: "empty", s: "  ", wantErr: "expected a table name"},
+		{name: "condition without WHERE", s: "orders id = 42", wantErr: `"orders id = 42": expected WHERE and a condition after the table name`},
+		{name: "WHERE without a condition", s: "orders WHERE ", wantErr: "expected WHERE and a condition after the table name"},
+		{name: "keyword glued to the condition", s: "orders WHEREid = 1", wantErr: "expected WHERE and a condition after the table name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSelection(tt.s)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseSelection() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSelection() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ParseSelection() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestKeyValues(t *testing.T) {
+	rows := []seeder.Row{
+		{"a": int64(1), "b": "x"},
+		{"a": int64(1), "b": "x"},
+		{"a": "1", "b": "x"},
+		{"a": int64(1), "b": "y"},
+		{"a": nil, "b": "x"},
+		{"a": int64(2), "b": nil},
+		{"b": "z"},
+	}
+	tests := []struct {
+		name        string
+		columnNames []string
+		want        [][]any
+	}{
+		{name: "single column", columnNames: []string{"a"}, want: [][]any{{int64(1)}, {"1"}, {int64(2)}}},
+		{
+			name:        "composite key",
+			columnNames: []string{"a", "b"},
+			want:        [][]any{{int64(1), "x"}, {"1", "x"}, {int64(1), "y"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, keyValues(rows, tt.columnNames)); diff != "" {
+				t.Errorf("keyValues() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRowKey(t *testing.T) {
+	schema, err := nodes.NewPostgreSQLSchema(extractSchema + "CREATE TABLE tags (name text, color text);")
+	if err != nil {
+		t.Fatalf("NewPostgreSQLSchema() error = %v", err)
+	}
+	tests := []struct {
+		name      string
+		tableName string
+		a, b      seeder.Row
+		wantSame  bool
+	}{
+		{
+			name:      "same primary key",
+			tableName: "users",
+			a:         seeder.Row{"id": int64(1), "name": "ann"},
+			b:         seeder.Row{"id": int64(1), "name": "bob"},
+			wantSame:  true,
+		},
+		{
+			name:      "primary key values of different types",
+			tableName: "users",
+			a:         seeder.Row{"id": int64(1)},
+			b:         seeder.Row{"id": "1"},
+		},
+		{
+			name:      "composite primary key",
+			tableName: "order_items",
+			a:         seeder.Row{"order_id": int64(10), "sku": "a"},
+			b:         seeder.Row{"order_id": int64(10), "sku": "b"},
+		},
+		{
+			name:      "every value of a table without primary key",
+			tableName: "tags",
+			a:         seeder.Row{"name": "new", "color": "red"},
+			b:         seeder.Row{"name": "new", "color": "blue"},
+		},
+		{
+			name:      "same values of a table without primary key",
+			tableName: "tags",
+			a:         seeder.Row{"name": "new", "color": nil},
+			b:         seeder.Row{"name": "new", "color": nil},
+			wantSame:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := schema.Tables[tt.tableName]
+			if same := rowKey(table, tt.a) == rowKey(table, tt.b); same != tt.wantSame {
+				t.Errorf("rowKey() of %v and %v are the same = %t, want %t", tt.a, tt.b, same, tt.wantSame)
+			}
+		})
+	}
+}
+
+func TestRows(t *testing.T) {
+	tests := []struct {
+		name        string
+		selections  []Selection
+		opts        Options
+		want        map[string][]string
+		wantQueries []string
+		wantErr     string
+	}{
+		{
+			name:       "referenced rows",
+			selections: []Selection{{Table: "orders", Where: "id = 10"}},
+			want:       map[string][]string{"orders": {"10"}, "users": {"1", "2"}},
+			wantQueries: []string{
+				"orders WHERE id = 10",
+				`users WHERE ("id") IN (($1)) [1]`,
+				`users WHERE ("id") IN (($1)) [2]`,
+			},
+		},
+		{
+			name:       "rows already taken",
+			selections: []Selection{{Table: "orders", Where: "id = 10"}, {Table: "orders", Where: "id = 11"}},
+			want:       map[string][]string{"orders": {"10", "11"}, "users": {"1", "2"}},
+			wantQueries: []string{
+				"orders WHERE id = 10",
+				"orders WHERE id = 11",
+				`users WHERE ("id") IN (($1)) [1]`,
+				`users WHERE ("id") IN (($1)) [2]`,
+			},
+		},
+		{
+			name:       "children",
+			selections: []Selection{{Table: "users", Where: "id = 1"}},
+			opts:       Options{Children: true},
+			want: map[string][]string{
+				"users":       {"1", "2"},
+				"orders":      {"10", "11"},
+				"order_items": {"10 a", "10 b", "11 a"},
+			},
+			wantQueries: []string{
+				"users WHERE id = 1",
+				`orders WHERE ("user_id") IN (($1)) [1]`,
+				`orders WHERE ("placed_by") IN (($1)) [1]`,
+				`users WHERE ("id") IN (($1)) [2]`,
+				`order_items WHERE ("order_id") IN (($1), ($2)) [10 11]`,
+			},
+		},
+		{
+			name:       "children of every row of a table",
+			selections: []Selection{{Table: "orders"}},
+			opts:       Options{Children: true},
+			want: map[string][]string{
+				"users":       {"1", "2", "3"},
+				"orders":      {"10", "11", "12"},
+				"order_items": {"10 a", "10 b", "11 a", "12 c"},
+			},
+			wantQueries: []string{
+				"orders",
+				`users WHERE ("id") IN (($1), ($2)) [3 1]`,
+				`users WHERE ("id") IN (($1)) [2]`,
+				`order_items WHERE ("order_id") IN (($1), ($2), ($3)) [12 10 11]`,
+			},
+		},
+		{
+			name:       "unknown table",
+			selections: []Selection{{Table: "carts"}},
+			wantErr:    `unknown table "carts"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := &tableConn{tables: extractRows}
+			db := sql.OpenDB(conn)
+			defer db.Close()
+			data, err := Rows(context.Background(), newTestSeeder(t), db, tt.selections, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Rows() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Rows() error = %v", err)
+			}
+
+			got := make(map[string][]string, len(data))
+			for tableName, rows := range data {
+				keyColumns := []string{"id"}
+				if tableName == "order_items" {
+					keyColumns = []string{"order_id", "sku"}
+				}
+				for _, row := range rows {
+					got[tableName] = append(got[tableName], strings.Trim(fmt.Sprint(rowValues(row, keyColumns)), "[]"))
+				}
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Rows() mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantQueries, conn.queries); diff != "" {
+				t.Errorf("queries mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+var (
+	selectPattern  = regexp.MustCompile(`^SELECT (.+) FROM "(\w+)"(?: WHERE (.+))?$`)
+	equalPattern   = regexp.MustCompile(`^(\w+) = (\d+)$`)
+	inTuplePattern = regexp.MustCompile(`^\(([^)]+)\) IN `)
+)
+
+// tableConn is a database/sql driver connection answering the SELECT statements of an extract from rows held in
+// memory. It understands the conditions of the lookups of foreign keys and the column = integer conditions of the
+// selections, and records the statements it's given by table, condition and arguments.
+type tableConn struct {
+	tables  map[string][]seeder.Row
+	queries []string
+}
+
+func (c *tableConn) Connect(ctx context.Context) (driver.Conn, error) { return c, nil }
+func (c *tableConn) Driver() driver.Driver                            { return nil }
+func (c *tableConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("prepared statements are not supported")
+}
+func (c *tableConn) Close() error { return nil }
+func (c *tableConn) Begin() (driver.Tx, error) {
+	return nil, fmt.Errorf("transactions are not supported")
+}
+
+func (c *tableConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	match := selectPattern.FindStringSubmatch(query)
+	if match == nil {
+		return nil, fmt.Errorf("unexpected statement %s", query)
+	}
+	columnNames := unquote(strings.Split(match[1], ", "))
+	tableName, condition := match[2], match[3]
+	values := make([]any, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+
+	recorded := tableName
+	if condition != "" {
+		recorded += " WHERE " + condition
+	}
+	if len(values) > 0 {
+		recorded += fmt.Sprintf(" %v", values)
+	}
+	c.queries = append(c.queries, recorded)
+
+	var matches func(row seeder.Row) bool
+	switch {
+	case condition == "":
+		matches = func(row seeder.Row) bool { return true }
+	case equalPattern.MatchString(condition):
+		equal := equalPattern.FindStringSubmatch(condition)
+		value, _ := strconv.ParseInt(equal[2], 10, 64)
+		matches = func(row seeder.Row) bool { return row[equal[1]] == value }
+	case inTuplePattern.MatchString(condition):
+		keyColumns := unquote(strings.Split(inTuplePattern.FindStringSubmatch(condition)[1], ", "))
+		matches = func(row seeder.Row) bool {
+			for start := 0; start < len(values); start += len(keyColumns) {
+				if slices.Equal(rowValues(row, keyColumns), values[start:start+len(keyColumns)]) {
+					return true
+				}
+			}
+			return false
+		}
+	default:
+		return nil, fmt.Errorf("unexpected condition %s", condition)
+	}
+
+	rows := &memoryRows{columns: columnNames}
+	for _, row := range c.tables[tableName] {
+		if matches(row) {
+			rows.rows = append(rows.rows, rowValues(row, columnNames))
+		}
+	}
+	return rows, nil
+}
+
+func rowValues(row seeder.Row, columnNames []string) []any {
+	values := make([]any, len(columnNames))
+	for i, columnName := range columnNames {
+		values[i] = row[columnName]
+	}
+	return values
+}
+
+func unquote(names []string) []string {
+	for i, name := range names {
+		names[i] = strings.Trim(name, `"`)
+	}
+	return names
+}
+
+// memoryRows are the rows a tableConn returns
+type memoryRows struct {
+	columns []string
+	rows    [][]any
+}
+
+func (r *memoryRows) Columns() []string { return r.columns }
+func (r *memoryRows) Close() error      { return nil }
+func (r *memoryRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	for i, value := range r.rows[0] {
+		dest[i] = value
+	}
+	r.rows = r.rows[1:]
+	return nil
+}
@@ -0,0 +1,298 @@
+package fake
+
+import (
+	"fmt"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Faker generates realistic and random values for columns, the same ones for the same seed. It's the runtime
+// counterpart of the Faker of the generated code, which the tests of package seedgen check generates the same values.
+type Faker struct {
+	// Now is the time past and future times are relative to, DefaultNow unless it's set to the current time
+	Now time.Time
+	// Overrides set the kinds of columns, taking precedence over the kinds guessed from their names
+	Overrides []Override
+
+	rand *rand.Rand
+	seqs map[string]int64
+}
+
+// DefaultNow is the time the times of fakers are relative to by default, fixed rather than the current time so that
+// the same seed generates the same values on any day
+var DefaultNow = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// New creates a faker whose values are determined by the seed
+func New(seed uint64) *Faker {
+	return &Faker{
+		Now:  DefaultNow,
+		rand: rand.New(rand.NewPCG(seed, seed)),
+		seqs: make(map[string]int64),
+	}
+}
+
+// Text returns a value of a kind of text, at most maxLength characters long when maxLength isn't 0
+func (f *Faker) Text(kind Kind, maxLength int) string {
+	var s string
+	switch kind {
+	case KindFirstName:
+		s = f.pick(firstNames)
+	case KindLastName:
+		s = f.pick(lastNames)
+	case KindFullName:
+		s = f.pick(firstNames) + " " + f.pick(lastNames)
+	case KindUsername:
+		s = fmt.Sprintf("%s%s%d", strings.ToLower(f.pick(firstNames)), strings.ToLower(f.pick(lastNames)[:1]), f.rand.IntN(100))
+	case KindEmail:
+		s = fmt.Sprintf("%s.%s@%s", strings.ToLower(f.pick(firstNames)), strings.ToLower(f.pick(lastNames)), f.pick(domains))
+	case KindPhone:
+		s = fmt.Sprintf("+1-555-%03d-%04d", f.rand.IntN(1000), f.rand.IntN(10000))
+	case KindURL:
+		s = fmt.Sprintf("https://%s/%s", f.pick(domains), f.slug())
+	case KindStreetAddress:
+		s = fmt.Sprintf("%d %s %s", 1+f.rand.IntN(9999), f.pick(streets), f.pick(streetSuffixes))
+	case KindCity:
+		s = f.pick(cities)
+	case KindState:
+		s = f.pick(states)
+	case KindCountry:
+		s = f.pick(countries)
+	case KindPostalCode:
+		s = fmt.Sprintf("%05d", f.rand.IntN(100000))
+	case KindCompany:
+		s = f.pick(lastNames) + " " + f.pick(companySuffixes)
+	case KindTitle:
+		s = capitalize(f.words(2 + f.rand.IntN(4)))
+	case KindParagraph:
+		sentences := make([]string, 2+f.rand.IntN(3))
+		for i := range sentences {
+			sentences[i] = capitalize(f.words(5+f.rand.IntN(8))) + "."
+		}
+		s = strings.Join(sentences, " ")
+	case KindSlug:
+		s = f.slug()
+	case KindColor:
+		s = f.pick(colors)
+	case KindIPAddress:
+		s = fmt.Sprintf("10.%d.%d.%d", f.rand.IntN(256), f.rand.IntN(256), 1+f.rand.IntN(254))
+	default:
+		s = f.letters(1 + f.rand.IntN(16))
+	}
+	return truncate(s, maxLength)
+}
+
+// UniqueText returns a value of a kind of text that includes a sequence number, so that values with different
+// numbers differ, at most maxLength characters long when maxLength isn't 0
+func (f *Faker) UniqueText(kind Kind, maxLength int, seq int64) string {
+	suffix := fmt.Sprint(seq)
+	if kind == KindEmail {
+		local, domain, _ := strings.Cut(f.Text(kind, 0), "@")
+		if maxLength == 0 || len(local)+len(suffix)+1+len(domain) <= maxLength {
+			return local + suffix + "@" + domain
+		}
+	}
+	if maxLength > 0 && len(suffix)+1 >= maxLength {
+		return suffix[max(len(suffix)-maxLength, 0):]
+	}
+	textLength := 0
+	if maxLength > 0 {
+		textLength = maxLength - len(suffix) - 1
+	}
+	return f.Text(kind, textLength) + "-" + suffix
+}
+
+// Time returns a value of a kind of time, in UTC and to the microsecond like PostgreSQL stores it
+func (f *Faker) Time(kind Kind) time.Time {
+	const day = 24 * time.Hour
+	switch kind {
+	case KindPastTime:
+		return f.Now.Add(-time.Duration(f.rand.Int64N(int64(730 * day)))).Truncate(time.Microsecond)
+	case KindFutureTime:
+		return f.Now.Add(time.Duration(f.rand.Int64N(int64(365*day))) + time.Hour).Truncate(time.Microsecond)
+	case KindBirthDate:
+		return f.Now.AddDate(-18-f.rand.IntN(62), 0, -f.rand.IntN(365)).Truncate(day)
+	}
+	start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	return start.Add(time.Duration(f.rand.Int64N(int64(end.Sub(start))))).Truncate(time.Microsecond)
+}
+
+// Value returns a value for a column of a table, realistic for the kind of the column and otherwise random for its
+// type, in a form the seeder coerces to the column type. Unique columns get distinct values. ok is false for the
+// types the faker has no values for.
+func (f *Faker) Value(table nodes.Table, column nodes.Column, enums map[string][]string) (value any, ok bool) {
+	dataType := strings.ToLower(column.DataType)
+	if elementType, isArray := strings.CutSuffix(dataType, "[]"); isArray {
+		element := column
+		element.DataType = elementType
+		value, ok := f.scalar(table, element, enums, false)
+		if !ok {
+			return nil, false
+		}
+		return []any{value}, true
+	}
+	return f.scalar(table, column, enums, IsUniqueColumn(table, column.Name))
+}
+
+func (f *Faker) scalar(table nodes.Table, column nodes.Column, enums map[string][]string, unique bool) (any, bool) {
+	dataType := strings.ToLower(column.DataType)
+	if labels, ok := enums[dataType]; ok && len(labels) > 0 {
+		return f.pick(labels), true
+	}
+	var seq int64
+	if unique {
+		key := table.Name + "." + column.Name
+		f.seqs[key]++
+		seq = f.seqs[key]
+	}
+	modifier := func(i int) int {
+		if i < len(column.TypeModifiers) {
+			return int(column.TypeModifiers[i])
+		}
+		return 0
+	}
+
+	switch dataType {
+	case "smallint", "int2", "smallserial":
+		return f.integer(unique, seq, math.MaxInt16), true
+	case "integer", "int", "int4", "serial":
+		return f.integer(unique, seq, math.MaxInt32), true
+	case "bigint", "int8", "bigserial":
+		return f.integer(unique, seq, 1<<53-1), true
+	case "real", "float4", "double precision", "float8", "float":
+		if unique {
+			return float64(seq), true
+		}
+		return f.rand.Float64() * 1000, true
+	case "numeric", "decimal", "money":
+		if unique {
+			return float64(seq), true
+		}
+		return f.Decimal(modifier(0), modifier(1)), true
+	case "bool", "boolean":
+		return f.rand.IntN(2) == 0, true
+	case "date":
+		return f.Time(KindOf(table.Name, column, f.Overrides)).Truncate(24 * time.Hour), true
+	case "timestamp", "timestamptz":
+		return f.Time(KindOf(table.Name, column, f.Overrides)), true
+	case "time", "timetz":
+		return fmt.Sprintf("%02d:%02d:%02d", f.rand.IntN(24), f.rand.IntN(60), f.rand.IntN(60)), true
+	case "text", "varchar", "char", "bpchar", "citext", "name":
+		maxLength := modifier(0)
+		if (dataType == "char" || dataType == "bpchar") && maxLength == 0 {
+			maxLength = 1
+		}
+		kind := KindOf(table.Name, column, f.Overrides)
+		if unique {
+			return f.UniqueText(kind, maxLength, seq), true
+		}
+		return f.Text(kind, maxLength), true
+	case "uuid":
+		b := f.bytes(16)
+		b[6] = b[6]&0x0f | 0x40
+		b[8] = b[8]&0x3f | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), true
+	case "json", "jsonb":
+		return map[string]any{"value": f.Text(KindRandom, 0)}, true
+	case "bytea":
+		return f.bytes(16), true
+	case "inet", "cidr":
+		return f.Text(KindIPAddress, 0), true
+	}
+	return nil, false
+}
+
+// Int returns an integer between min and max, inclusive
+func (f *Faker) Int(min, max int64) int64 {
+	return min + f.rand.Int64N(max-min+1)
+}
+
+// Decimal returns a number that fits a numeric(precision, scale), with numeric(10, 2) assumed when precision is 0
+func (f *Faker) Decimal(precision, scale int) float64 {
+	if precision <= 0 {
+		precision, scale = 10, 2
+	}
+	digits := min(precision-scale, 6)
+	unit := math.Pow10(scale)
+	return math.Floor(f.rand.Float64()*math.Pow10(digits)*unit) / unit
+}
+
+func (f *Faker) integer(unique bool, seq int64, max int64) int64 {
+	if unique {
+		return seq
+	}
+	return 1 + f.rand.Int64N(max)
+}
+
+func (f *Faker) pick(words []string) string {
+	return words[f.rand.IntN(len(words))]
+}
+
+func (f *Faker) words(n int) string {
+	words := make([]string, n)
+	for i := range words {
+		words[i] = f.pick(loremWords)
+	}
+	return strings.Join(words, " ")
+}
+
+func (f *Faker) slug() string {
+	return strings.ReplaceAll(f.words(2+f.rand.IntN(3)), " ", "-")
+}
+
+func (f *Faker) letters(n int) string {
+	const letters = "abcdefghijklmnopqrstuvwxyz"
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = letters[f.rand.IntN(len(letters))]
+	}
+	return string(b)
+}
+
+func (f *Faker) bytes(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(f.rand.IntN(256))
+	}
+	return b
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// truncate cuts s to at most maxLength characters, when maxLength isn't 0
+func truncate(s string, maxLength int) string {
+	if maxLength <= 0 || utf8.RuneCountInString(s) <= maxLength {
+		return s
+	}
+	return strings.TrimSpace(string([]rune(s)[:maxLength]))
+}
+
+// IsUniqueColumn returns whether a column is part of the primary key or of a unique constraint
+func IsUniqueColumn(table nodes.Table, columnName string) bool {
+	if slices.Contains(table.PrimaryKey, columnName) {
+		return true
+	}
+	for _, constraint := range table.Constraints {
+		if info, ok := constraint.Constraint.(*nodes.UniqueConstraintInfo); ok && slices.Contains(info.ColumnNames, columnName) {
+			return true
+		}
+	}
+	for _, column := range table.Columns {
+		if column.Name == columnName {
+			return slices.ContainsFunc(column.Constraints, func(c nodes.ColumnConstraint) bool {
+				return c.Type == nodes.ConstraintInfoTypeUnique || c.Type == nodes.ConstraintInfoTypePrimaryKey
+			})
+		}
+	}
+	return false
+}
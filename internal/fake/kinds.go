@@ -0,0 +1,155 @@
+package fake
+
+import (
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Kind is the sort of realistic value a column holds, like an email address or a creation time
+type Kind string
+
+const (
+	// KindRandom is the kind of the columns that get random values of their type
+	KindRandom Kind = "random"
+
+	KindFirstName     Kind = "first_name"
+	KindLastName      Kind = "last_name"
+	KindFullName      Kind = "full_name"
+	KindUsername      Kind = "username"
+	KindEmail         Kind = "email"
+	KindPhone         Kind = "phone"
+	KindURL           Kind = "url"
+	KindStreetAddress Kind = "street_address"
+	KindCity          Kind = "city"
+	KindState         Kind = "state"
+	KindCountry       Kind = "country"
+	KindPostalCode    Kind = "postal_code"
+	KindCompany       Kind = "company"
+	KindTitle         Kind = "title"
+	KindParagraph     Kind = "paragraph"
+	KindSlug          Kind = "slug"
+	KindColor         Kind = "color"
+	KindIPAddress     Kind = "ip_address"
+
+	KindPastTime   Kind = "past_time"
+	KindFutureTime Kind = "future_time"
+	KindBirthDate  Kind = "birth_date"
+)
+
+// TextKinds are the kinds of values of text columns
+var TextKinds = []Kind{
+	KindFirstName, KindLastName, KindFullName, KindUsername, KindEmail, KindPhone, KindURL, KindStreetAddress,
+	KindCity, KindState, KindCountry, KindPostalCode, KindCompany, KindTitle, KindParagraph, KindSlug, KindColor,
+	KindIPAddress,
+}
+
+// TimeKinds are the kinds of values of date and time columns
+var TimeKinds = []Kind{KindPastTime, KindFutureTime, KindBirthDate}
+
+// Override sets the kind of the columns matching Column, a "table.column" name or glob like "*.phone"
+type Override struct {
+	Column string
+	Kind   Kind
+}
+
+// IsKind reports whether a name is a known kind
+func IsKind(name string) bool {
+	kind := Kind(name)
+	return kind == KindRandom || kind.IsText() || kind.IsTime()
+}
+
+// textHeuristics map column names to the kinds of text columns, the first match winning
+var textHeuristics = []struct {
+	names []string
+	kind  Kind
+}{
+	{[]string{"email", "email_address", "*_email"}, KindEmail},
+	{[]string{"first_name", "firstname", "given_name", "fname"}, KindFirstName},
+	{[]string{"last_name", "lastname", "surname", "family_name", "lname"}, KindLastName},
+	{[]string{"full_name", "fullname", "display_name", "contact_name", "author", "author_name"}, KindFullName},
+	{[]string{"username", "user_name", "login", "handle", "nickname", "screen_name"}, KindUsername},
+	{[]string{"phone", "phone_number", "mobile", "telephone", "fax", "*_phone"}, KindPhone},
+	{[]string{"url", "website", "homepage", "link", "*_url", "*_link"}, KindURL},
+	{[]string{"address", "street", "street_address", "address_line1", "address_line_1", "address1"}, KindStreetAddress},
+	{[]string{"city", "town"}, KindCity},
+	{[]string{"province", "region", "state_name", "us_state"}, KindState},
+	{[]string{"country", "country_name"}, KindCountry},
+	{[]string{"zip", "zip_code", "zipcode", "postcode", "postal_code"}, KindPostalCode},
+	{[]string{"company", "company_name", "organization", "organisation", "employer"}, KindCompany},
+	{[]string{"title", "subject", "headline", "caption"}, KindTitle},
+	{[]string{"description", "bio", "body", "content", "notes", "note", "comment", "comments", "summary", "about", "message"}, KindParagraph},
+	{[]string{"slug", "*_slug"}, KindSlug},
+	{[]string{"color", "colour", "*_color", "*_colour"}, KindColor},
+	{[]string{"ip", "ip_address", "ipaddress", "*_ip"}, KindIPAddress},
+}
+
+// timeHeuristics map column names to the kinds of date and time columns, the first match winning
+var timeHeuristics = []struct {
+	names []string
+	kind  Kind
+}{
+	{[]string{"birthday", "birth_date", "birthdate", "date_of_birth", "dob"}, KindBirthDate},
+	{[]string{"expires_at", "expires_on", "expiry", "expiration", "due_at", "due_date", "due_on", "starts_at", "scheduled_at", "scheduled_for", "valid_until", "ends_at"}, KindFutureTime},
+	{[]string{"*_at", "*_on", "*_date", "date", "timestamp"}, KindPastTime},
+}
+
+// KindOf returns the kind of a column, from the first override matching it or else from its name. Only text, date
+// and time columns have kinds other than KindRandom, and an override of the wrong category is ignored.
+func KindOf(tableName string, column nodes.Column, overrides []Override) Kind {
+	var isKind func(Kind) bool
+	heuristics := textHeuristics
+	switch strings.ToLower(column.DataType) {
+	case "text", "varchar", "citext", "bpchar", "char":
+		isKind = Kind.IsText
+	case "date", "timestamp", "timestamptz":
+		isKind = Kind.IsTime
+		heuristics = timeHeuristics
+	default:
+		return KindRandom
+	}
+
+	for _, override := range overrides {
+		if matched, _ := path.Match(override.Column, tableName+"."+column.Name); matched {
+			if !isKind(override.Kind) {
+				return KindRandom
+			}
+			return override.Kind
+		}
+	}
+
+	name := strings.ToLower(column.Name)
+	if name == "name" && isKind(KindFullName) {
+		// a name is a person's name in tables of people, and a title in the others
+		if isPeopleTable(tableName) {
+			return KindFullName
+		}
+		return KindTitle
+	}
+	for _, heuristic := range heuristics {
+		for _, pattern := range heuristic.names {
+			if matched, _ := path.Match(pattern, name); matched {
+				return heuristic.kind
+			}
+		}
+	}
+	return KindRandom
+}
+
+var peopleWords = []string{"user", "person", "people", "customer", "author", "contact", "employee", "member", "profile", "student", "teacher", "patient", "client", "owner"}
+
+func isPeopleTable(tableName string) bool {
+	tableName = strings.ToLower(tableName)
+	return slices.ContainsFunc(peopleWords, func(word string) bool { return strings.Contains(tableName, word) })
+}
+
+// IsText reports whether the kind is a kind of text values
+func (k Kind) IsText() bool {
+	return slices.Contains(TextKinds, k)
+}
+
+// IsTime reports whether the kind is a kind of date or time values
+func (k Kind) IsTime() bool {
+	return slices.Contains(TimeKinds, k)
+}
@@ -0,0 +1,39 @@
+package fake
+
+import (
+	"embed"
+	"strings"
+)
+
+//go:embed words/*.txt
+var wordFiles embed.FS
+
+// WordListNames are the names of the embedded word lists
+var WordListNames = []string{
+	"first_names", "last_names", "lorem", "cities", "states", "countries", "streets", "street_suffixes", "colors",
+	"domains", "company_suffixes",
+}
+
+// Words returns the words of an embedded word list, one per line of its file
+func Words(listName string) []string {
+	data, err := wordFiles.ReadFile("words/" + listName + ".txt")
+	if err != nil {
+		panic("unknown word list " + listName)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+var (
+	firstNames = Words("first_names")
+	lastNames  = Words("last_names")
+	loremWords = Words("lorem")
+	cities     = Words("cities")
+	states     = Words("states")
+	countries  = Words("countries")
+	streets    = Words("streets")
+	colors     = Words("colors")
+	domains    = Words("domains")
+
+	streetSuffixes  = Words("street_suffixes")
+	companySuffixes = Words("company_suffixes")
+)
@@ -0,0 +1,263 @@
+package fixtures
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"github.com/tab58/go-integral/internal/seeder"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// DefaultCSVNullValues are the cell values read as NULL when CSVOptions.NullValues isn't set
+var DefaultCSVNullValues = []string{"", `\N`}
+
+// CSVOptions controls how CSV files are read
+type CSVOptions struct {
+	// Comma is the field delimiter, a comma by default
+	Comma rune
+	// NullValues are the cell values read as NULL. A NULL in a NOT NULL column that has a default leaves the column
+	// out of the row, so the database fills it.
+	NullValues []string
+}
+
+// LoadCSV reads the <table>.csv files of a directory as fixtures of the tables of a schema. The header row of each
+// file names the columns, and the cells are parsed according to the types of their columns. Every file must match a
+// table, and the columns a row can't be inserted without must be present.
+func LoadCSV(dir string, schema *nodes.PostgreSQLSchema, opts CSVOptions) (*Fixtures, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read CSV directory: %w", err)
+	}
+
+	fixtures := &Fixtures{Tables: make(map[string][]Record)}
+	var errs []error
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".csv") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		tableName := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		table, ok := schema.Tables[tableName]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: unknown table %q", path, tableName))
+			continue
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CSV file: %w", err)
+		}
+		records, err := ReadCSV(file, table, schema.Enums, opts)
+		file.Close()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		fixtures.Tables[tableName] = records
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return fixtures, nil
+}
+
+// ReadCSV reads the rows of a table from CSV with a header row. enums are the labels of the enum types of the schema.
+func ReadCSV(r io.Reader, table nodes.Table, enums map[string][]string, opts CSVOptions) ([]Record, error) {
+	reader := csv.NewReader(r)
+	if opts.Comma != 0 {
+		reader.Comma = opts.Comma
+	}
+	nullValues := opts.NullValues
+	if nullValues == nil {
+		nullValues = DefaultCSVNullValues
+	}
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("missing header row")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	columns, err := headerColumns(table, header)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]Record, 0)
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		values := make(map[string]any, len(columns))
+		var errs []error
+		for i, column := range columns {
+			if slices.Contains(nullValues, row[i]) {
+				if table.IsRequiredColumn(column.Name) {
+					errs = append(errs, fmt.Errorf("line %d: column %s can't be null", line, column.Name))
+				} else if !table.IsNotNullColumn(column.Name) {
+					values[column.Name] = nil
+				}
+				continue
+			}
+			value, err := parseCSVValue(strings.ToLower(column.DataType), row[i])
+			if err == nil {
+				// check the value the way it's inserted, so that errors point at the line
+				_, err = seeder.Coerce(column, value, enums)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("line %d: %w", line, err))
+				continue
+			}
+			values[column.Name] = value
+		}
+		if err := errors.Join(errs...); err != nil {
+			return nil, err
+		}
+		records = append(records, Record{Values: values})
+	}
+	return records, nil
+}
+
+// headerColumns matches the header row to the columns of the table, exactly or else ignoring case
+func headerColumns(table nodes.Table, header []string) ([]nodes.Column, error) {
+	columns := make([]nodes.Column, len(header))
+	seen := make(map[string]bool, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		index := slices.IndexFunc(table.Columns, func(c nodes.Column) bool { return c.Name == name })
+		if index < 0 {
+			index = slices.IndexFunc(table.Columns, func(c nodes.Column) bool { return strings.EqualFold(c.Name, name) })
+		}
+		if index < 0 {
+			return nil, fmt.Errorf("table %s has no column %q", table.Name, name)
+		}
+		column := table.Columns[index]
+		if seen[column.Name] {
+			return nil, fmt.Errorf("column %s appears more than once in the header", column.Name)
+		}
+		seen[column.Name] = true
+		columns[i] = column
+	}
+
+	var missing []string
+	for _, column := range table.Columns {
+		if !seen[column.Name] && table.IsRequiredColumn(column.Name) {
+			missing = append(missing, column.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required columns %s", strings.Join(missing, ", "))
+	}
+	return columns, nil
+}
+
+// parseCSVValue decodes the cells of array columns and checks JSON documents, leaving the other values as text for the
+// seeder to coerce
+func parseCSVValue(dataType string, cell string) (any, error) {
+	if elementType, ok := strings.CutSuffix(dataType, "[]"); ok {
+		trimmed := strings.TrimSpace(cell)
+		if strings.HasPrefix(trimmed, "[") {
+			var elements []any
+			if err := json.Unmarshal([]byte(trimmed), &elements); err != nil {
+				return nil, fmt.Errorf("%q is not a JSON array: %w", cell, err)
+			}
+			return elements, nil
+		}
+		return parseArrayLiteral(elementType, trimmed)
+	}
+
+	switch dataType {
+	case "json", "jsonb":
+		if !json.Valid([]byte(cell)) {
+			return nil, fmt.Errorf("%q is not a JSON document", cell)
+		}
+		return cell, nil
+	}
+	return cell, nil
+}
+
+// parseArrayLiteral parses a one-dimensional PostgreSQL array literal, like {a,"b c",NULL}
+func parseArrayLiteral(elementType string, literal string) ([]any, error) {
+	inner, ok := strings.CutPrefix(literal, "{")
+	if ok {
+		inner, ok = strings.CutSuffix(inner, "}")
+	}
+	if !ok {
+		return nil, fmt.Errorf("%q is not an array, expected {a,b} or [\"a\",\"b\"]", literal)
+	}
+	elements := make([]any, 0)
+	if strings.TrimSpace(inner) == "" {
+		return elements, nil
+	}
+
+	var (
+		current strings.Builder
+		quoted  bool
+		inQuote bool
+		escaped bool
+	)
+	finish := func() error {
+		element := current.String()
+		if !quoted {
+			element = strings.TrimSpace(element)
+			if element == "" {
+				return fmt.Errorf("%q has an empty element", literal)
+			}
+			if strings.EqualFold(element, "NULL") {
+				elements = append(elements, nil)
+				return nil
+			}
+		}
+		value, err := parseCSVValue(elementType, element)
+		if err != nil {
+			return err
+		}
+		elements = append(elements, value)
+		return nil
+	}
+	for _, r := range inner {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			inQuote = !inQuote
+			quoted = true
+		case r == '{' && !inQuote:
+			return nil, fmt.Errorf("%q: multidimensional arrays aren't supported", literal)
+		case r == ',' && !inQuote:
+			if err := finish(); err != nil {
+				return nil, err
+			}
+			current.Reset()
+			quoted = false
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if inQuote || escaped {
+		return nil, fmt.Errorf("%q is not a valid array", literal)
+	}
+	if err := finish(); err != nil {
+		return nil, err
+	}
+	return elements, nil
+}
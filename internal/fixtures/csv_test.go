@@ -0,0 +1,218 @@
+package fixtures
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"github.com/tab58/go-integral/internal/utils"
+)
+
+const csvSchema = `
+CREATE TYPE mood AS ENUM ('happy', 'sad');
+CREATE TABLE users (
+  id serial PRIMARY KEY,
+  email text NOT NULL,
+  nickname text,
+  active boolean NOT NULL DEFAULT true,
+  tags text[],
+  scores int[],
+  moods mood[],
+  settings jsonb
+);
+`
+
+func csvTable(t *testing.T) (nodes.Table, map[string][]string) {
+	t.Helper()
+	schema, err := nodes.NewPostgreSQLSchema(csvSchema)
+	if err != nil {
+		t.Fatalf("NewPostgreSQLSchema() error = %v", err)
+	}
+	return schema.Tables["users"], schema.Enums
+}
+
+func TestParseArrayLiteral(t *testing.T) {
+	tests := []struct {
+		name        string
+		elementType string
+		literal     string
+		want        []any
+		wantErr     string
+	}{
+		{name: "empty array", elementType: "text", literal: "{}", want: []any{}},
+		{name: "unquoted elements", elementType: "text", literal: "{a,b}", want: []any{"a", "b"}},
+		{name: "spaces around unquoted elements", elementType: "int4", literal: "{ 1 , 2 }", want: []any{"1", "2"}},
+		{name: "quoted elements", elementType: "text", literal: `{"b c"," d ",e}`, want: []any{"b c", " d ", "e"}},
+		{name: "quoted comma and braces", elementType: "text", literal: `{"a,b","{c}"}`, want: []any{"a,b", "{c}"}},
+		{name: "escaped quote and backslash", elementType: "text", literal: `{"say \"hi\"","back\\slash"}`, want: []any{`say "hi"`, `back\slash`}},
+		{name: "escaped comma outside quotes", elementType: "text", literal: `{a\,b,c}`, want: []any{"a,b", "c"}},
+		{name: "NULL elements", elementType: "text", literal: "{a,NULL,null}", want: []any{"a", nil, nil}},
+		{name: "quoted NULL is text", elementType: "text", literal: `{"NULL"}`, want: []any{"NULL"}},
+		{name: "quoted empty element", elementType: "text", literal: `{"",a}`, want: []any{"", "a"}},
+		{name: "JSON elements", elementType: "jsonb", literal: `{"{\"a\": 1}"}`, want: []any{`{"a": 1}`}},
+		{name: "invalid JSON element", elementType: "jsonb", literal: `{"{a"}`, wantErr: `"{a" is not a JSON document`},
+		{name: "empty unquoted element", elementType: "text", literal: "{a,,b}", wantErr: `"{a,,b}" has an empty element`},
+		{name: "multidimensional array", elementType: "int4", literal: "{{1,2},{3,4}}", wantErr: "multidimensional arrays aren't supported"},
+		{name: "unterminated quote", elementType: "text", literal: `{"a}`, wantErr: `"{\"a}" is not a valid array`},
+		{name: "trailing backslash", elementType: "text", literal: `{a\}`, wantErr: `is not a valid array`},
+		{name: "missing braces", elementType: "text", literal: "a,b", wantErr: `"a,b" is not an array, expected {a,b}`},
+		{name: "missing closing brace", elementType: "text", literal: "{a,b", wantErr: `"{a,b" is not an array`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseArrayLiteral(tt.elementType, tt.literal)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseArrayLiteral() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseArrayLiteral() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("parseArrayLiteral() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHeaderColumns(t *testing.T) {
+	table, _ := csvTable(t)
+	tests := []struct {
+		name    string
+		header  []string
+		want    []string
+		wantErr string
+	}{
+		{name: "columns in any order", header: []string{"nickname", "email"}, want: []string{"nickname", "email"}},
+		{name: "byte order mark", header: []string{"\ufeffemail", "id"}, want: []string{"email", "id"}},
+		{name: "case and spaces", header: []string{" Email ", "NICKNAME"}, want: []string{"email", "nickname"}},
+		{name: "missing required column", header: []string{"id", "nickname"}, wantErr: "missing required columns email"},
+		{name: "unknown column", header: []string{"email", "name"}, wantErr: `table users has no column "name"`},
+		{name: "repeated column", header: []string{"email", "EMAIL"}, wantErr: "column email appears more than once in the header"},
+		{name: "byte order mark after the first column", header: []string{"email", "\ufeffid"}, wantErr: `has no column "\ufeffid"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columns, err := headerColumns(table, tt.header)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("headerColumns() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("headerColumns() error = %v", err)
+			}
+			got := utils.Map(columns, func(column nodes.Column) string { return column.Name })
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("headerColumns() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	table, enums := csvTable(t)
+	tests := []struct {
+		name    string
+		csv     string
+		opts    CSVOptions
+		want    []map[string]any
+		wantErr string
+	}{
+		{
+			name: "values and arrays",
+			csv: "\ufeffemail,tags,scores,moods,settings\n" +
+				`a@example.com,"{x,""y z"",NULL}","[1, 2]",{happy},"{""a"": 1}"` + "\n",
+			want: []map[string]any{{
+				"email":    "a@example.com",
+				"tags":     []any{"x", "y z", nil},
+				"scores":   []any{float64(1), float64(2)},
+				"moods":    []any{"happy"},
+				"settings": `{"a": 1}`,
+			}},
+		},
+		{
+			name: "null values of nullable columns",
+			csv:  "email,nickname,tags\na@example.com,,\\N\n",
+			want: []map[string]any{{"email": "a@example.com", "nickname": nil, "tags": nil}},
+		},
+		{
+			name: "null in a NOT NULL column with a default",
+			csv:  "email,active\na@example.com,\nb@example.com,false\n",
+			want: []map[string]any{{"email": "a@example.com"}, {"email": "b@example.com", "active": "false"}},
+		},
+		{
+			name: "null in a NOT NULL serial column",
+			csv:  "id,email\n,a@example.com\n",
+			want: []map[string]any{{"email": "a@example.com"}},
+		},
+		{
+			name: "custom delimiter and null values",
+			csv:  "email;nickname\na@example.com;-\n;\n",
+			opts: CSVOptions{Comma: ';', NullValues: []string{"-"}},
+			want: []map[string]any{{"email": "a@example.com", "nickname": nil}, {"email": "", "nickname": ""}},
+		},
+		{
+			name:    "null in a required column",
+			csv:     "email,nickname\na@example.com,bob\n,carl\n",
+			wantErr: "line 3: column email can't be null",
+		},
+		{
+			name:    "missing required column",
+			csv:     "nickname\nbob\n",
+			wantErr: "missing required columns email",
+		},
+		{
+			name:    "multidimensional array",
+			csv:     "email,scores\na@example.com,\"{{1,2},{3,4}}\"\n",
+			wantErr: "line 2: \"{{1,2},{3,4}}\": multidimensional arrays aren't supported",
+		},
+		{
+			name:    "invalid array element",
+			csv:     "email,scores\na@example.com,\"{1,x}\"\n",
+			wantErr: `line 2: column scores: element 1: "x" is not an integer`,
+		},
+		{
+			name:    "unknown enum label",
+			csv:     "email,moods\na@example.com,{angry}\n",
+			wantErr: `line 2: column moods: element 0: "angry" is not a label of enum mood`,
+		},
+		{
+			name:    "invalid JSON document",
+			csv:     "email,settings\na@example.com,{a}\n",
+			wantErr: `line 2: "{a}" is not a JSON document`,
+		},
+		{
+			name:    "invalid JSON array",
+			csv:     "email,tags\na@example.com,[a]\n",
+			wantErr: `line 2: "[a]" is not a JSON array`,
+		},
+		{
+			name:    "empty file",
+			csv:     "",
+			wantErr: "missing header row",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := ReadCSV(strings.NewReader(tt.csv), table, enums, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ReadCSV() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadCSV() error = %v", err)
+			}
+			got := utils.Map(records, func(record Record) map[string]any { return record.Values })
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ReadCSV() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
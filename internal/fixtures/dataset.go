@@ -0,0 +1,245 @@
+package fixtures
+
+import (
+	"fmt"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"github.com/tab58/go-integral/internal/seeder"
+	"github.com/tab58/go-integral/internal/sqlscript"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FromDataset turns rows read from a database into fixture records, like extracts are written. Each record is
+// labelled with its table and primary key, like orders_42, and the foreign keys referencing rows of the dataset become
+// references to their labels, so that the records can be edited and seeded like written ones. The tables are given in
+// insert order.
+func FromDataset(tables []nodes.Table, data seeder.Dataset) (*Fixtures, error) {
+	for tableName := range data {
+		if !slices.ContainsFunc(tables, func(table nodes.Table) bool { return table.Name == tableName }) {
+			return nil, fmt.Errorf("unknown table %q", tableName)
+		}
+	}
+
+	fixtures := &Fixtures{Tables: make(map[string][]Record)}
+	// the labels of the rows of each table, by table and key text of the referenced columns
+	labels := make(map[string]map[string]string)
+	for _, table := range tables {
+		rows := data[table.Name]
+		if len(rows) == 0 {
+			continue
+		}
+		used := make(map[string]bool)
+		records := make([]Record, len(rows))
+		for i, row := range rows {
+			label := rowLabel(table, row, i)
+			for n := 2; used[label]; n++ {
+				label = fmt.Sprintf("%s_%d", rowLabel(table, row, i), n)
+			}
+			used[label] = true
+
+			values, err := recordValues(table, row)
+			if err != nil {
+				return nil, fmt.Errorf("row %d of table %s: %w", i, table.Name, err)
+			}
+			for _, foreignKey := range table.ForeignKeys() {
+				if !canReference(table, foreignKey) {
+					continue
+				}
+				referenced, ok := labels[foreignKey.ReferencedTable][keyText(row, foreignKey.Columns, foreignKey.ReferencedColumns)]
+				if !ok {
+					continue
+				}
+				for _, columnName := range foreignKey.Columns {
+					values[columnName] = Reference{Label: referenced}
+				}
+			}
+			records[i] = Record{Label: label, Values: values}
+		}
+		fixtures.Tables[table.Name] = records
+
+		// index the rows by the values of every column set another table references
+		for _, other := range tables {
+			for _, foreignKey := range other.ForeignKeys() {
+				if foreignKey.ReferencedTable != table.Name {
+					continue
+				}
+				if labels[table.Name] == nil {
+					labels[table.Name] = make(map[string]string)
+				}
+				for i, row := range rows {
+					key := keyText(row, foreignKey.ReferencedColumns, foreignKey.ReferencedColumns)
+					if _, ok := labels[table.Name][key]; !ok {
+						labels[table.Name][key] = records[i].Label
+					}
+				}
+			}
+		}
+	}
+	return fixtures, nil
+}
+
+// WriteYAML writes the fixtures as a fixture document, with the tables in the given order and the values of each
+// record in column order
+func (f *Fixtures) WriteYAML(w io.Writer, tables []nodes.Table) error {
+	for tableName := range f.Tables {
+		if !slices.ContainsFunc(tables, func(table nodes.Table) bool { return table.Name == tableName }) {
+			return fmt.Errorf("unknown table %q", tableName)
+		}
+	}
+
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	for _, table := range tables {
+		records := f.Tables[table.Name]
+		if len(records) == 0 {
+			continue
+		}
+		labelled := !slices.ContainsFunc(records, func(record Record) bool { return record.Label == "" })
+		tableNode := &yaml.Node{Kind: yaml.SequenceNode}
+		if labelled {
+			tableNode.Kind = yaml.MappingNode
+		}
+		for i, record := range records {
+			recordNode, err := yamlRecord(table, record)
+			if err != nil {
+				return fmt.Errorf("%s: %w", recordName(table.Name, i, record), err)
+			}
+			if labelled {
+				tableNode.Content = append(tableNode.Content, yamlString(record.Label))
+			}
+			tableNode.Content = append(tableNode.Content, recordNode)
+		}
+		root.Content = append(root.Content, yamlString(table.Name), tableNode)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}); err != nil {
+		return fmt.Errorf("unable to write fixtures: %w", err)
+	}
+	return encoder.Close()
+}
+
+func yamlRecord(table nodes.Table, record Record) (*yaml.Node, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	columnNames := slices.Sorted(maps.Keys(record.Values))
+	slices.SortStableFunc(columnNames, func(a, b string) int {
+		return columnIndex(table, a) - columnIndex(table, b)
+	})
+	for _, columnName := range columnNames {
+		value := record.Values[columnName]
+		switch v := value.(type) {
+		case Reference:
+			value = ReferencePrefix + v.Label
+		case string:
+			if strings.HasPrefix(v, ReferencePrefix) {
+				value = ReferencePrefix + v
+			}
+		case time.Time:
+			column, _ := findColumn(table, columnName)
+			text, err := sqlscript.Text(column, v)
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %w", columnName, err)
+			}
+			value = text
+		}
+		valueNode := &yaml.Node{}
+		if err := valueNode.Encode(value); err != nil {
+			return nil, fmt.Errorf("column %s: %w", columnName, err)
+		}
+		node.Content = append(node.Content, yamlString(columnName), valueNode)
+	}
+	return node, nil
+}
+
+func yamlString(s string) *yaml.Node {
+	node := &yaml.Node{}
+	// encoding picks the quoting that keeps the string a string
+	_ = node.Encode(s)
+	return node
+}
+
+// recordValues copies the values of a row, decoding the array literals drivers return as text and giving bytea values
+// as strings
+func recordValues(table nodes.Table, row seeder.Row) (map[string]any, error) {
+	values := make(map[string]any, len(row))
+	for columnName, value := range row {
+		column, ok := findColumn(table, columnName)
+		if !ok {
+			return nil, fmt.Errorf("table has no column %q", columnName)
+		}
+		dataType := strings.ToLower(column.DataType)
+		switch v := value.(type) {
+		case string:
+			if elementType, ok := strings.CutSuffix(dataType, "[]"); ok {
+				elements, err := parseArrayLiteral(elementType, v)
+				if err != nil {
+					return nil, fmt.Errorf("column %s: %w", columnName, err)
+				}
+				value = elements
+			}
+		case []byte:
+			value = string(v)
+		}
+		values[columnName] = value
+	}
+	return values, nil
+}
+
+// canReference returns whether the columns of a foreign key can reference a record, which is resolved through the
+// foreign key of each column
+func canReference(table nodes.Table, foreignKey nodes.ForeignKey) bool {
+	for i, columnName := range foreignKey.Columns {
+		info, ok := ForeignKey(table, columnName)
+		if !ok || info.ForeignKeyTableName != foreignKey.ReferencedTable || info.ForeignKeyColumnName != foreignKey.ReferencedColumns[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// rowLabel labels a row with its table and primary key, or its position when the table has none
+func rowLabel(table nodes.Table, row seeder.Row, index int) string {
+	parts := []string{table.Name}
+	for _, columnName := range table.PrimaryKey {
+		parts = append(parts, fmt.Sprint(row[columnName]))
+	}
+	if len(table.PrimaryKey) == 0 {
+		parts = append(parts, fmt.Sprint(index+1))
+	}
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.' {
+			return r
+		}
+		return '_'
+	}, strings.Join(parts, "_"))
+}
+
+// keyText identifies the values of columns of a row, named after the referenced columns so that the keys of
+// referencing and referenced rows match
+func keyText(row seeder.Row, columnNames []string, referencedColumnNames []string) string {
+	parts := make([]string, len(columnNames))
+	for i, columnName := range columnNames {
+		value := row[columnName]
+		parts[i] = fmt.Sprintf("%s=%T:%v", referencedColumnNames[i], value, value)
+	}
+	slices.Sort(parts)
+	return strings.Join(parts, "\x00")
+}
+
+func columnIndex(table nodes.Table, columnName string) int {
+	return slices.IndexFunc(table.Columns, func(c nodes.Column) bool { return c.Name == columnName })
+}
+
+func findColumn(table nodes.Table, columnName string) (nodes.Column, bool) {
+	index := columnIndex(table, columnName)
+	if index < 0 {
+		return nodes.Column{}, false
+	}
+	return table.Columns[index], true
+}
@@ -0,0 +1,256 @@
+package fixtures
+
+import (
+	"errors"
+	"fmt"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ReferencePrefix starts a value that references another record by its label, like "$alice". A value starting with
+// "$$" is the literal string without the first "$".
+const ReferencePrefix = "$"
+
+// Record is a row of a fixture file, with an optional label other records can reference it by
+type Record struct {
+	Label  string
+	Values map[string]any
+}
+
+// Fixtures holds the records of each table, in file order
+type Fixtures struct {
+	Tables map[string][]Record
+}
+
+// Reference is a value of a record that references another record by label
+type Reference struct {
+	Label string
+}
+
+// Load reads fixture files, or the .yaml, .yml and .json files of directories, in order
+func Load(paths ...string) (*Fixtures, error) {
+	fixtures := &Fixtures{Tables: make(map[string][]Record)}
+	for _, path := range paths {
+		files := []string{path}
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read fixtures directory: %w", err)
+			}
+			files = make([]string, 0, len(entries))
+			for _, entry := range entries {
+				ext := strings.ToLower(filepath.Ext(entry.Name()))
+				if !entry.IsDir() && slices.Contains([]string{".yaml", ".yml", ".json"}, ext) {
+					files = append(files, filepath.Join(path, entry.Name()))
+				}
+			}
+		}
+
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read fixtures: %w", err)
+			}
+			parsed, err := Parse(data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+			if err := fixtures.merge(parsed); err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+		}
+	}
+	return fixtures, nil
+}
+
+// Parse decodes a YAML or JSON fixture document. Each top level key is a table, holding either a mapping of labels to
+// records or a sequence of unlabelled records.
+func Parse(data []byte) (*Fixtures, error) {
+	// JSON is valid YAML, and decoding to a node keeps the order of the tables and records
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid fixtures: %w", err)
+	}
+	fixtures := &Fixtures{Tables: make(map[string][]Record)}
+	if len(doc.Content) == 0 {
+		return fixtures, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, errors.New("invalid fixtures: expected a mapping of table names")
+	}
+
+	for i := 0; i < len(root.Content); i += 2 {
+		tableName, tableNode := root.Content[i].Value, root.Content[i+1]
+		records, err := parseRecords(tableNode)
+		if err != nil {
+			return nil, fmt.Errorf("table %s: %w", tableName, err)
+		}
+		if err := fixtures.merge(&Fixtures{Tables: map[string][]Record{tableName: records}}); err != nil {
+			return nil, err
+		}
+	}
+	return fixtures, nil
+}
+
+func parseRecords(node *yaml.Node) ([]Record, error) {
+	records := make([]Record, 0)
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i < len(node.Content); i += 2 {
+			label := node.Content[i].Value
+			values, err := parseValues(node.Content[i+1])
+			if err != nil {
+				return nil, fmt.Errorf("record %s: %w", label, err)
+			}
+			records = append(records, Record{Label: label, Values: values})
+		}
+	case yaml.SequenceNode:
+		for i, recordNode := range node.Content {
+			values, err := parseValues(recordNode)
+			if err != nil {
+				return nil, fmt.Errorf("record %d: %w", i, err)
+			}
+			records = append(records, Record{Values: values})
+		}
+	default:
+		return nil, errors.New("expected a mapping of labels to records or a sequence of records")
+	}
+	return records, nil
+}
+
+func parseValues(node *yaml.Node) (map[string]any, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, errors.New("expected a mapping of column names to values")
+	}
+	values := make(map[string]any)
+	for i := 0; i < len(node.Content); i += 2 {
+		columnName, valueNode := node.Content[i].Value, node.Content[i+1]
+		var value any
+		if err := valueNode.Decode(&value); err != nil {
+			return nil, fmt.Errorf("column %s: %w", columnName, err)
+		}
+		// keep unquoted dates as written, the type of the column decides what they are
+		if valueNode.Kind == yaml.ScalarNode && valueNode.ShortTag() == "!!timestamp" {
+			value = valueNode.Value
+		}
+		if s, ok := value.(string); ok && strings.HasPrefix(s, ReferencePrefix) {
+			if literal, ok := strings.CutPrefix(s, ReferencePrefix+ReferencePrefix); ok {
+				value = ReferencePrefix + literal
+			} else {
+				value = Reference{Label: strings.TrimPrefix(s, ReferencePrefix)}
+			}
+		}
+		values[columnName] = value
+	}
+	return values, nil
+}
+
+func (f *Fixtures) merge(other *Fixtures) error {
+	for tableName, records := range other.Tables {
+		for _, record := range records {
+			if record.Label != "" && slices.ContainsFunc(f.Tables[tableName], func(r Record) bool { return r.Label == record.Label }) {
+				return fmt.Errorf("table %s: label %q is used more than once", tableName, record.Label)
+			}
+			f.Tables[tableName] = append(f.Tables[tableName], record)
+		}
+	}
+	return nil
+}
+
+// Validate checks the fixtures against a schema: that the tables and columns exist, and that references are made from
+// foreign key columns to labels of the referenced table
+func (f *Fixtures) Validate(schema *nodes.PostgreSQLSchema) error {
+	var errs []error
+	for _, tableName := range slices.Sorted(maps.Keys(f.Tables)) {
+		table, ok := schema.Tables[tableName]
+		if !ok {
+			errs = append(errs, fmt.Errorf("unknown table %q", tableName))
+			continue
+		}
+		for i, record := range f.Tables[tableName] {
+			name := recordName(tableName, i, record)
+			for _, columnName := range slices.Sorted(maps.Keys(record.Values)) {
+				if !slices.ContainsFunc(table.Columns, func(c nodes.Column) bool { return c.Name == columnName }) {
+					errs = append(errs, fmt.Errorf("%s: table has no column %q", name, columnName))
+					continue
+				}
+				ref, ok := record.Values[columnName].(Reference)
+				if !ok {
+					continue
+				}
+				info, ok := ForeignKey(table, columnName)
+				if !ok {
+					errs = append(errs, fmt.Errorf("%s: column %s references %q but isn't a foreign key", name, columnName, ref.Label))
+					continue
+				}
+				if _, ok := f.Find(info.ForeignKeyTableName, ref.Label); !ok {
+					errs = append(errs, fmt.Errorf("%s: column %s references unknown %s record %q", name, columnName, info.ForeignKeyTableName, ref.Label))
+					continue
+				}
+				// the records of a table are inserted in order, so a record referencing its own table can only
+				// reference the records before it
+				if info.ForeignKeyTableName == tableName && slices.IndexFunc(f.Tables[tableName], func(r Record) bool { return r.Label == ref.Label }) >= i {
+					errs = append(errs, fmt.Errorf("%s: column %s references %s record %q, which isn't before it", name, columnName, tableName, ref.Label))
+				}
+			}
+			errs = append(errs, validateCompositeReferences(table, name, record)...)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateCompositeReferences checks that the columns of a composite foreign key reference the same record when one of
+// them references a record, as each column is resolved to the value of its referenced column on its own
+func validateCompositeReferences(table nodes.Table, name string, record Record) []error {
+	var errs []error
+	for _, foreignKey := range table.ForeignKeys() {
+		if len(foreignKey.Columns) < 2 {
+			continue
+		}
+		labels := make([]string, 0, len(foreignKey.Columns))
+		for _, columnName := range foreignKey.Columns {
+			if ref, ok := record.Values[columnName].(Reference); ok {
+				labels = append(labels, ref.Label)
+			}
+		}
+		if len(labels) > 0 && (len(labels) < len(foreignKey.Columns) || len(slices.Compact(labels)) > 1) {
+			errs = append(errs, fmt.Errorf("%s: columns %s of foreign key %s must all reference the same record", name, strings.Join(foreignKey.Columns, ", "), foreignKey.Name))
+		}
+	}
+	return errs
+}
+
+// Find looks up the record of a table by label
+func (f *Fixtures) Find(tableName string, label string) (Record, bool) {
+	for _, record := range f.Tables[tableName] {
+		if record.Label == label {
+			return record, true
+		}
+	}
+	return Record{}, false
+}
+
+// ForeignKey returns the foreign key entry of a column, if it has one. The entries of a composite foreign key are
+// resolved one column at a time, so every column of the key references the same record, which Validate checks.
+func ForeignKey(table nodes.Table, columnName string) (*nodes.ForeignKeyConstraintInfo, bool) {
+	for _, constraint := range table.Constraints {
+		if info, ok := constraint.Constraint.(*nodes.ForeignKeyConstraintInfo); ok && info.TableColumnName == columnName {
+			return info, true
+		}
+	}
+	return nil, false
+}
+
+func recordName(tableName string, index int, record Record) string {
+	if record.Label != "" {
+		return fmt.Sprintf("%s record %q", tableName, record.Label)
+	}
+	return fmt.Sprintf("%s record %d", tableName, index)
+}
@@ -0,0 +1,116 @@
+package fixtures
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tab58/go-integral/internal/parse/nodes"
+)
+
+func TestValidate(t *testing.T) {
+	schema, err := nodes.NewPostgreSQLSchema(`
+CREATE TABLE accounts (org int, num int, PRIMARY KEY (org, num));
+CREATE TABLE users (
+  id int PRIMARY KEY,
+  manager_id int REFERENCES users (id),
+  account_org int,
+  account_num int,
+  FOREIGN KEY (account_org, account_num) REFERENCES accounts (org, num)
+);`)
+	if err != nil {
+		t.Fatalf("NewPostgreSQLSchema() error = %v", err)
+	}
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{
+			name: "reference to an earlier record of the same table",
+			yaml: `
+users:
+  boss: {id: 1}
+  alice: {id: 2, manager_id: $boss}
+`,
+		},
+		{
+			name: "reference to a later record of the same table",
+			yaml: `
+users:
+  alice: {id: 2, manager_id: $boss}
+  boss: {id: 1}
+`,
+			wantErr: `users record "alice": column manager_id references users record "boss", which isn't before it`,
+		},
+		{
+			name: "reference of a record to itself",
+			yaml: `
+users:
+  boss: {id: 1, manager_id: $boss}
+`,
+			wantErr: `users record "boss": column manager_id references users record "boss", which isn't before it`,
+		},
+		{
+			name: "composite foreign key referencing a record",
+			yaml: `
+accounts:
+  acme: {org: 1, num: 1}
+users:
+  alice: {id: 1, account_org: $acme, account_num: $acme}
+`,
+		},
+		{
+			name: "composite foreign key with values",
+			yaml: `
+accounts:
+  acme: {org: 1, num: 1}
+users:
+  alice: {id: 1, account_org: 1, account_num: 1}
+`,
+		},
+		{
+			name: "composite foreign key referencing a record in one column",
+			yaml: `
+accounts:
+  acme: {org: 1, num: 1}
+users:
+  alice: {id: 1, account_org: $acme, account_num: 1}
+`,
+			wantErr: `users record "alice": columns account_org, account_num of foreign key users_account_org_account_num_fkey must all reference the same record`,
+		},
+		{
+			name: "composite foreign key referencing different records",
+			yaml: `
+accounts:
+  acme: {org: 1, num: 1}
+  globex: {org: 1, num: 2}
+users:
+  alice: {id: 1, account_org: $acme, account_num: $globex}
+`,
+			wantErr: `users record "alice": columns account_org, account_num of foreign key users_account_org_account_num_fkey must all reference the same record`,
+		},
+		{
+			name: "reference from a column that isn't a foreign key",
+			yaml: `
+accounts:
+  acme: {org: 1, num: $acme}
+`,
+			wantErr: `accounts record "acme": column num references "acme" but isn't a foreign key`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse([]byte(tt.yaml))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			err = f.Validate(schema)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package fixtures
+
+import (
+	"context"
+	"fmt"
+	"github.com/tab58/go-integral/internal/seeder"
+)
+
+// Seed validates the fixtures and inserts them with the runtime seeder, tables in order of dependency, replacing each
+// reference with the value the referenced record was stored with. It returns the stored rows by table and label.
+func (f *Fixtures) Seed(ctx context.Context, s *seeder.Seeder, db seeder.Querier) (map[string]map[string]seeder.Row, error) {
+	if err := f.Validate(s.Schema()); err != nil {
+		return nil, err
+	}
+
+	stored := make(map[string]map[string]seeder.Row)
+	for _, table := range s.Tables() {
+		for i, record := range f.Tables[table.Name] {
+			row := make(seeder.Row, len(record.Values))
+			for columnName, value := range record.Values {
+				ref, ok := value.(Reference)
+				if !ok {
+					row[columnName] = value
+					continue
+				}
+				// validated above, and the referenced table is inserted first
+				info, _ := ForeignKey(table, columnName)
+				row[columnName] = stored[info.ForeignKeyTableName][ref.Label][info.ForeignKeyColumnName]
+			}
+
+			inserted, err := s.Insert(ctx, db, table.Name, row)
+			if err != nil {
+				return nil, fmt.Errorf("unable to insert %s: %w", recordName(table.Name, i, record), err)
+			}
+			if record.Label != "" {
+				if stored[table.Name] == nil {
+					stored[table.Name] = make(map[string]seeder.Row)
+				}
+				stored[table.Name][record.Label] = inserted
+			}
+		}
+	}
+	return stored, nil
+}
@@ -0,0 +1,224 @@
+package graph
+
+import (
+	"container/heap"
+	"errors"
+	"math"
+)
+
+// ErrCycle is returned by the algorithms that require the graph to be acyclic
+var ErrCycle = errors.New("graph has a cycle")
+
+// TopologicalSort orders the node values so that every edge goes from an earlier node to a later one. Nodes without
+// an ordering constraint between them keep their insertion order.
+func (g *DirectedGraph[T, E]) TopologicalSort() ([]T, error) {
+	sorted, err := g.TopologicalSortNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	resultValues := make([]T, 0, len(sorted))
+	for _, n := range sorted {
+		resultValues = append(resultValues, n.Value)
+	}
+	return resultValues, nil
+}
+
+// TopologicalSortNodes is TopologicalSort returning the nodes instead of their values
+func (g *DirectedGraph[T, E]) TopologicalSortNodes() ([]*Node[T], error) {
+	g.initIndexes()
+
+	// calculate in-degree nodes
+	inDegrees := make(map[string]int)
+	for _, edge := range g.Edges {
+		inDegrees[edge.To.ID]++
+	}
+
+	// enqueue nodes with in-degree 0, in insertion order so that the sort is deterministic
+	q := Queue[*Node[T]]{}
+	for _, n := range g.Nodes {
+		if inDegrees[n.ID] == 0 {
+			q.Enqueue(n)
+		}
+	}
+
+	// iterate through nodes
+	result := make([]*Node[T], 0, len(g.Nodes))
+	for !q.IsEmpty() {
+		n := *q.Dequeue()
+		result = append(result, n)
+
+		for _, edge := range g.outEdges[n.ID] {
+			inDegrees[edge.To.ID]--
+			if inDegrees[edge.To.ID] == 0 {
+				q.Enqueue(edge.To)
+			}
+		}
+	}
+
+	if len(result) != len(g.Nodes) {
+		return nil, ErrCycle
+	}
+	return result, nil
+}
+
+// StronglyConnectedComponents returns the strongly connected components of the graph with Tarjan's algorithm. The
+// components are returned in reverse topological order, and a component of more than one node, or of a node with an
+// edge to itself, is a cycle.
+func (g *DirectedGraph[T, E]) StronglyConnectedComponents() [][]*Node[T] {
+	g.initIndexes()
+
+	index := 0
+	indexes := make(map[string]int)
+	lowLinks := make(map[string]int)
+	onStack := make(map[string]bool)
+	stack := make([]*Node[T], 0)
+	components := make([][]*Node[T], 0)
+
+	var strongConnect func(n *Node[T])
+	strongConnect = func(n *Node[T]) {
+		indexes[n.ID] = index
+		lowLinks[n.ID] = index
+		index++
+		stack = append(stack, n)
+		onStack[n.ID] = true
+
+		for _, edge := range g.outEdges[n.ID] {
+			next := edge.To
+			if _, visited := indexes[next.ID]; !visited {
+				strongConnect(next)
+				lowLinks[n.ID] = min(lowLinks[n.ID], lowLinks[next.ID])
+			} else if onStack[next.ID] {
+				lowLinks[n.ID] = min(lowLinks[n.ID], indexes[next.ID])
+			}
+		}
+
+		// n is the root of a component, which is everything above it on the stack
+		if lowLinks[n.ID] == indexes[n.ID] {
+			component := make([]*Node[T], 0)
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top.ID] = false
+				component = append(component, top)
+				if top.ID == n.ID {
+					break
+				}
+			}
+			components = append(components, component)
+		}
+	}
+
+	for _, n := range g.Nodes {
+		if _, visited := indexes[n.ID]; !visited {
+			strongConnect(n)
+		}
+	}
+	return components
+}
+
+// TransitiveReduction returns the graph with the same nodes and reachability but without the edges that are implied
+// by a longer path, e.g. a -> c when there are a -> b and b -> c. Parallel edges between two nodes are either all kept
+// or all removed. The graph must be acyclic.
+func (g *DirectedGraph[T, E]) TransitiveReduction() (*DirectedGraph[T, E], error) {
+	if _, err := g.TopologicalSortNodes(); err != nil {
+		return nil, err
+	}
+
+	reduced := NewDirectedGraph[T, E]()
+	for _, n := range g.Nodes {
+		reduced.Nodes = append(reduced.Nodes, n)
+		reduced.nodesByID[n.ID] = n
+	}
+	for _, edge := range g.Edges {
+		if !g.reachableAvoidingEdge(edge) {
+			reduced.Edges = append(reduced.Edges, edge)
+			reduced.outEdges[edge.From.ID] = append(reduced.outEdges[edge.From.ID], edge)
+			reduced.inEdges[edge.To.ID] = append(reduced.inEdges[edge.To.ID], edge)
+		}
+	}
+	return reduced, nil
+}
+
+// reachableAvoidingEdge returns whether the end of an edge can be reached from its start through another node
+func (g *DirectedGraph[T, E]) reachableAvoidingEdge(edge *DirectedEdge[T, E]) bool {
+	for _, first := range g.outEdges[edge.From.ID] {
+		if first.To.ID != edge.To.ID && g.Reachable(first.To, edge.To) {
+			return true
+		}
+	}
+	return false
+}
+
+// ShortestPath returns the edges of the shortest path between two nodes with Dijkstra's algorithm, where weight gives
+// the non-negative length of each edge or every edge has a length of 1 if weight is nil. It returns false if there is
+// no path.
+func (g *DirectedGraph[T, E]) ShortestPath(from *Node[T], to *Node[T], weight func(edge *DirectedEdge[T, E]) float64) ([]*DirectedEdge[T, E], bool) {
+	g.initIndexes()
+	if weight == nil {
+		weight = func(*DirectedEdge[T, E]) float64 { return 1 }
+	}
+
+	distances := map[string]float64{from.ID: 0}
+	previous := make(map[string]*DirectedEdge[T, E])
+	done := make(map[string]bool)
+	pq := &pathQueue[T]{{node: from, distance: 0}}
+	for pq.Len() > 0 {
+		item := heap.Pop(pq).(pathItem[T])
+		n := item.node
+		if done[n.ID] {
+			continue
+		}
+		done[n.ID] = true
+		if n.ID == to.ID {
+			break
+		}
+
+		for _, edge := range g.outEdges[n.ID] {
+			distance := item.distance + weight(edge)
+			current, ok := distances[edge.To.ID]
+			if !ok {
+				current = math.Inf(1)
+			}
+			if distance < current {
+				distances[edge.To.ID] = distance
+				previous[edge.To.ID] = edge
+				heap.Push(pq, pathItem[T]{node: edge.To, distance: distance})
+			}
+		}
+	}
+
+	if !done[to.ID] {
+		return nil, false
+	}
+	path := make([]*DirectedEdge[T, E], 0)
+	for id := to.ID; id != from.ID; {
+		edge := previous[id]
+		path = append([]*DirectedEdge[T, E]{edge}, path...)
+		id = edge.From.ID
+	}
+	return path, true
+}
+
+type pathItem[T any] struct {
+	node     *Node[T]
+	distance float64
+}
+
+// pathQueue is the priority queue of Dijkstra's algorithm, ordered by distance
+type pathQueue[T any] []pathItem[T]
+
+func (q pathQueue[T]) Len() int           { return len(q) }
+func (q pathQueue[T]) Less(i, j int) bool { return q[i].distance < q[j].distance }
+func (q pathQueue[T]) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *pathQueue[T]) Push(x any) {
+	*q = append(*q, x.(pathItem[T]))
+}
+
+func (q *pathQueue[T]) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,308 @@
+package graph
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestTopologicalSortNodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		nodes   []string
+		edges   []string
+		want    []string
+		wantErr error
+	}{
+		{
+			name:  "empty graph",
+			nodes: nil,
+			edges: nil,
+			want:  []string{},
+		},
+		{
+			name:  "nodes without edges keep their insertion order",
+			nodes: []string{"c", "a", "b"},
+			edges: nil,
+			want:  []string{"c", "a", "b"},
+		},
+		{
+			name:  "chain inserted in reverse",
+			nodes: []string{"c", "b", "a"},
+			edges: []string{"a->b", "b->c"},
+			want:  []string{"a", "b", "c"},
+		},
+		{
+			name:  "node moved after the node it depends on",
+			nodes: []string{"a", "b", "c", "d"},
+			edges: []string{"d->a"},
+			want:  []string{"b", "c", "d", "a"},
+		},
+		{
+			name:  "diamond",
+			nodes: []string{"a", "b", "c", "d"},
+			edges: []string{"a->b", "a->c", "b->d", "c->d"},
+			want:  []string{"a", "b", "c", "d"},
+		},
+		{
+			name:  "parallel edges",
+			nodes: []string{"b", "a"},
+			edges: []string{"a->b=1", "a->b=2"},
+			want:  []string{"a", "b"},
+		},
+		{
+			name:    "cycle",
+			nodes:   []string{"a", "b", "c", "d"},
+			edges:   []string{"a->b", "b->c", "c->b", "c->d"},
+			wantErr: ErrCycle,
+		},
+		{
+			name:    "self-loop",
+			nodes:   []string{"a", "b"},
+			edges:   []string{"a->b", "b->b"},
+			wantErr: ErrCycle,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGraph(t, tt.nodes, tt.edges)
+			// the order only depends on the insertion order, so sorting again gives the same nodes
+			for range 5 {
+				sorted, err := g.TopologicalSortNodes()
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("TopologicalSortNodes() error = %v, want %v", err, tt.wantErr)
+				}
+				if got := nodeIDs(sorted); err == nil && !slices.Equal(got, tt.want) {
+					t.Fatalf("TopologicalSortNodes() = %v, want %v", got, tt.want)
+				}
+			}
+
+			values, err := g.TopologicalSort()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("TopologicalSort() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && !slices.Equal(values, tt.want) {
+				t.Errorf("TopologicalSort() = %v, want %v", values, tt.want)
+			}
+		})
+	}
+}
+
+func TestStronglyConnectedComponents(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes []string
+		edges []string
+		// want are the components in the order they are returned, with the nodes of each sorted
+		want [][]string
+	}{
+		{
+			name:  "acyclic graph in reverse topological order",
+			nodes: []string{"a", "b", "c"},
+			edges: []string{"a->b", "b->c"},
+			want:  [][]string{{"c"}, {"b"}, {"a"}},
+		},
+		{
+			name:  "cycle reaching another cycle",
+			nodes: []string{"a", "b", "c", "d", "e"},
+			edges: []string{"a->b", "b->c", "c->a", "c->d", "d->e", "e->d"},
+			want:  [][]string{{"d", "e"}, {"a", "b", "c"}},
+		},
+		{
+			name:  "self-loop",
+			nodes: []string{"a", "b"},
+			edges: []string{"a->a", "a->b"},
+			want:  [][]string{{"b"}, {"a"}},
+		},
+		{
+			name:  "disconnected nodes",
+			nodes: []string{"a", "b", "c"},
+			edges: []string{"b->c", "c->b"},
+			want:  [][]string{{"a"}, {"b", "c"}},
+		},
+		{
+			name:  "parallel edges",
+			nodes: []string{"a", "b"},
+			edges: []string{"a->b=1", "a->b=2", "b->a"},
+			want:  [][]string{{"a", "b"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGraph(t, tt.nodes, tt.edges)
+			components := g.StronglyConnectedComponents()
+
+			got := make([][]string, 0, len(components))
+			for _, component := range components {
+				got = append(got, slices.Sorted(slices.Values(nodeIDs(component))))
+			}
+			if !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("StronglyConnectedComponents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransitiveReduction(t *testing.T) {
+	tests := []struct {
+		name    string
+		nodes   []string
+		edges   []string
+		want    []string
+		wantErr error
+	}{
+		{
+			name:  "edge implied by a longer path",
+			nodes: []string{"a", "b", "c"},
+			edges: []string{"a->b", "b->c", "a->c"},
+			want:  []string{"a->b=1", "b->c=1"},
+		},
+		{
+			name:  "edge implied by a path of several edges",
+			nodes: []string{"a", "b", "c", "d"},
+			edges: []string{"a->d", "a->b", "b->c", "c->d"},
+			want:  []string{"a->b=1", "b->c=1", "c->d=1"},
+		},
+		{
+			name:  "diamond keeps every edge",
+			nodes: []string{"a", "b", "c", "d"},
+			edges: []string{"a->b", "a->c", "b->d", "c->d"},
+			want:  []string{"a->b=1", "a->c=1", "b->d=1", "c->d=1"},
+		},
+		{
+			name:  "parallel edges are kept together",
+			nodes: []string{"a", "b", "c"},
+			edges: []string{"a->b=1", "a->b=2", "b->c"},
+			want:  []string{"a->b=1", "a->b=2", "b->c=1"},
+		},
+		{
+			name:  "parallel edges are removed together",
+			nodes: []string{"a", "b", "c"},
+			edges: []string{"a->c=1", "a->b", "a->c=2", "b->c"},
+			want:  []string{"a->b=1", "b->c=1"},
+		},
+		{
+			name:    "cycle",
+			nodes:   []string{"a", "b"},
+			edges:   []string{"a->b", "b->a"},
+			wantErr: ErrCycle,
+		},
+		{
+			name:    "self-loop",
+			nodes:   []string{"a"},
+			edges:   []string{"a->a"},
+			wantErr: ErrCycle,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGraph(t, tt.nodes, tt.edges)
+			reduced, err := g.TransitiveReduction()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("TransitiveReduction() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if got := edgeNames(reduced.Edges); !slices.Equal(got, tt.want) {
+				t.Errorf("TransitiveReduction() edges = %v, want %v", got, tt.want)
+			}
+			if got := nodeIDs(reduced.Nodes); !slices.Equal(got, tt.nodes) {
+				t.Errorf("TransitiveReduction() nodes = %v, want %v", got, tt.nodes)
+			}
+			// the reduced graph has the same reachability, and the original graph is left as it is
+			for _, from := range g.Nodes {
+				for _, to := range g.Nodes {
+					if g.Reachable(from, to) != reduced.Reachable(from, to) {
+						t.Errorf("Reachable(%s, %s) differs in the reduced graph", from.ID, to.ID)
+					}
+				}
+			}
+			if got := len(g.Edges); got != len(tt.edges) {
+				t.Errorf("original has %d edges, want %d", got, len(tt.edges))
+			}
+		})
+	}
+}
+
+func TestShortestPath(t *testing.T) {
+	byWeight := func(edge *DirectedEdge[string, int]) float64 { return float64(edge.Value) }
+	tests := []struct {
+		name     string
+		edges    []string
+		from, to string
+		weight   func(edge *DirectedEdge[string, int]) float64
+		want     []string
+		wantOK   bool
+	}{
+		{
+			name:   "fewest edges without weights",
+			edges:  []string{"a->b=1", "b->c=1", "a->c=5"},
+			from:   "a",
+			to:     "c",
+			want:   []string{"a->c=5"},
+			wantOK: true,
+		},
+		{
+			name:   "lightest path with weights",
+			edges:  []string{"a->b=1", "b->c=1", "a->c=5"},
+			from:   "a",
+			to:     "c",
+			weight: byWeight,
+			want:   []string{"a->b=1", "b->c=1"},
+			wantOK: true,
+		},
+		{
+			name:   "lightest of parallel edges",
+			edges:  []string{"a->b=3", "a->b=1", "a->b=2"},
+			from:   "a",
+			to:     "b",
+			weight: byWeight,
+			want:   []string{"a->b=1"},
+			wantOK: true,
+		},
+		{
+			name:   "path through a cycle",
+			edges:  []string{"a->b", "b->a", "b->c", "c->d"},
+			from:   "a",
+			to:     "d",
+			want:   []string{"a->b=1", "b->c=1", "c->d=1"},
+			wantOK: true,
+		},
+		{
+			name:   "same node",
+			edges:  []string{"a->b"},
+			from:   "a",
+			to:     "a",
+			want:   []string{},
+			wantOK: true,
+		},
+		{
+			name:   "against the direction of the edges",
+			edges:  []string{"a->b", "b->c"},
+			from:   "c",
+			to:     "a",
+			wantOK: false,
+		},
+		{
+			name:   "unconnected nodes",
+			edges:  []string{"a->b"},
+			from:   "a",
+			to:     "d",
+			wantOK: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGraph(t, []string{"a", "b", "c", "d"}, tt.edges)
+			path, ok := g.ShortestPath(mustNode(t, g, tt.from), mustNode(t, g, tt.to), tt.weight)
+			if ok != tt.wantOK {
+				t.Fatalf("ShortestPath() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if got := edgeNames(path); ok && !slices.Equal(got, tt.want) {
+				t.Errorf("ShortestPath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
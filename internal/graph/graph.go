@@ -1,7 +1,8 @@
 package graph
 
 import (
-	"errors"
+	"fmt"
+	"slices"
 
 	"github.com/segmentio/ksuid"
 )
@@ -17,94 +18,160 @@ type DirectedEdge[T any, E any] struct {
 	Value E
 }
 
+// DirectedGraph is a directed multigraph. Nodes and Edges are kept in insertion order, which every algorithm follows
+// so that results are deterministic; they must only be changed through the methods of the graph so that the
+// adjacency indexes stay in sync.
 type DirectedGraph[T any, E any] struct {
 	Nodes []*Node[T]
 	Edges []*DirectedEdge[T, E]
+
+	nodesByID map[string]*Node[T]
+	outEdges  map[string][]*DirectedEdge[T, E]
+	inEdges   map[string][]*DirectedEdge[T, E]
 }
 
 func NewDirectedGraph[T any, E any]() *DirectedGraph[T, E] {
 	return &DirectedGraph[T, E]{
-		Nodes: make([]*Node[T], 0),
-		Edges: make([]*DirectedEdge[T, E], 0),
+		Nodes:     make([]*Node[T], 0),
+		Edges:     make([]*DirectedEdge[T, E], 0),
+		nodesByID: make(map[string]*Node[T]),
+		outEdges:  make(map[string][]*DirectedEdge[T, E]),
+		inEdges:   make(map[string][]*DirectedEdge[T, E]),
 	}
 }
 
+// AddNode adds a node with a generated unique ID
 func (g *DirectedGraph[T, E]) AddNode(nodeValue T) *Node[T] {
+	node, _ := g.AddNodeWithID(ksuid.New().String(), nodeValue)
+	return node
+}
+
+// AddNodeWithID adds a node with a caller chosen ID, like a table name, that it can be looked up by
+func (g *DirectedGraph[T, E]) AddNodeWithID(id string, nodeValue T) (*Node[T], error) {
+	g.initIndexes()
+	if _, ok := g.nodesByID[id]; ok {
+		return nil, fmt.Errorf("node %q already exists", id)
+	}
 	node := &Node[T]{
-		ID:    ksuid.New().String(),
+		ID:    id,
 		Value: nodeValue,
 	}
 	g.Nodes = append(g.Nodes, node)
-	return node
+	g.nodesByID[id] = node
+	return node, nil
+}
+
+// Node looks up a node by its ID
+func (g *DirectedGraph[T, E]) Node(id string) (*Node[T], bool) {
+	g.initIndexes()
+	node, ok := g.nodesByID[id]
+	return node, ok
 }
 
-func (g *DirectedGraph[T, E]) AddEdge(from *Node[T], to *Node[T], edgeValue E) {
-	g.Edges = append(g.Edges, &DirectedEdge[T, E]{
+func (g *DirectedGraph[T, E]) AddEdge(from *Node[T], to *Node[T], edgeValue E) *DirectedEdge[T, E] {
+	g.initIndexes()
+	edge := &DirectedEdge[T, E]{
 		From:  from,
 		To:    to,
 		Value: edgeValue,
-	})
+	}
+	g.Edges = append(g.Edges, edge)
+	g.outEdges[from.ID] = append(g.outEdges[from.ID], edge)
+	g.inEdges[to.ID] = append(g.inEdges[to.ID], edge)
+	return edge
 }
 
-type topoNode[T any] struct {
-	Node           *Node[T]
-	InDegree       int
-	ConnectedNodes []*topoNode[T]
+// RemoveNode removes a node along with the edges from and to it
+func (g *DirectedGraph[T, E]) RemoveNode(node *Node[T]) {
+	g.initIndexes()
+	for _, edge := range slices.Concat(g.outEdges[node.ID], g.inEdges[node.ID]) {
+		g.RemoveEdge(edge)
+	}
+	g.Nodes = slices.DeleteFunc(g.Nodes, func(n *Node[T]) bool { return n == node })
+	delete(g.nodesByID, node.ID)
+	delete(g.outEdges, node.ID)
+	delete(g.inEdges, node.ID)
 }
 
-func (g *DirectedGraph[T, E]) TopologicalSort() ([]T, error) {
-	// build topoNodes
-	topoNodes := make(map[string]*topoNode[T])
-	for _, n := range g.Nodes {
-		topoNodes[n.ID] = &topoNode[T]{
-			Node:           n,
-			InDegree:       0,
-			ConnectedNodes: make([]*topoNode[T], 0),
-		}
-	}
+// RemoveEdge removes a single edge, keeping any parallel edge between the same nodes
+func (g *DirectedGraph[T, E]) RemoveEdge(edge *DirectedEdge[T, E]) {
+	g.initIndexes()
+	isEdge := func(e *DirectedEdge[T, E]) bool { return e == edge }
+	g.Edges = slices.DeleteFunc(g.Edges, isEdge)
+	g.outEdges[edge.From.ID] = slices.DeleteFunc(g.outEdges[edge.From.ID], isEdge)
+	g.inEdges[edge.To.ID] = slices.DeleteFunc(g.inEdges[edge.To.ID], isEdge)
+}
 
-	// calculate in-degree nodes
-	for _, edge := range g.Edges {
-		fromNode := topoNodes[edge.From.ID]
-		toNode := topoNodes[edge.To.ID]
+// OutEdges returns the edges from a node, in insertion order
+func (g *DirectedGraph[T, E]) OutEdges(node *Node[T]) []*DirectedEdge[T, E] {
+	g.initIndexes()
+	return slices.Clone(g.outEdges[node.ID])
+}
 
-		connNodes := fromNode.ConnectedNodes
-		connNodes = append(connNodes, toNode)
-		fromNode.ConnectedNodes = connNodes
-		toNode.InDegree++
-	}
+// InEdges returns the edges to a node, in insertion order
+func (g *DirectedGraph[T, E]) InEdges(node *Node[T]) []*DirectedEdge[T, E] {
+	g.initIndexes()
+	return slices.Clone(g.inEdges[node.ID])
+}
+
+// OutNeighbors returns the distinct nodes that a node has an edge to
+func (g *DirectedGraph[T, E]) OutNeighbors(node *Node[T]) []*Node[T] {
+	g.initIndexes()
+	return distinctNodes(g.outEdges[node.ID], func(edge *DirectedEdge[T, E]) *Node[T] { return edge.To })
+}
+
+// InNeighbors returns the distinct nodes that have an edge to a node
+func (g *DirectedGraph[T, E]) InNeighbors(node *Node[T]) []*Node[T] {
+	g.initIndexes()
+	return distinctNodes(g.inEdges[node.ID], func(edge *DirectedEdge[T, E]) *Node[T] { return edge.From })
+}
 
-	// enqueue nodes with in-degree 0
-	q := Queue[topoNode[T]]{}
-	for _, node := range topoNodes {
-		if node.InDegree == 0 {
-			q.Enqueue(*node)
+// Subgraph returns the graph of the nodes for which keep returns true and of the edges between them. The nodes and
+// edges are shared with the original graph.
+func (g *DirectedGraph[T, E]) Subgraph(keep func(node *Node[T]) bool) *DirectedGraph[T, E] {
+	subgraph := NewDirectedGraph[T, E]()
+	for _, n := range g.Nodes {
+		if keep(n) {
+			subgraph.Nodes = append(subgraph.Nodes, n)
+			subgraph.nodesByID[n.ID] = n
 		}
 	}
-
-	// iterate through nodes
-	result := make([]*Node[T], 0)
-	for !q.IsEmpty() {
-		n := q.Dequeue()
-		result = append(result, n.Node)
-
-		if len(n.ConnectedNodes) != 0 {
-			for _, connectedNode := range n.ConnectedNodes {
-				connectedNode.InDegree--
-				if connectedNode.InDegree == 0 {
-					q.Enqueue(*connectedNode)
-				}
-			}
+	for _, edge := range g.Edges {
+		if subgraph.nodesByID[edge.From.ID] != nil && subgraph.nodesByID[edge.To.ID] != nil {
+			subgraph.Edges = append(subgraph.Edges, edge)
+			subgraph.outEdges[edge.From.ID] = append(subgraph.outEdges[edge.From.ID], edge)
+			subgraph.inEdges[edge.To.ID] = append(subgraph.inEdges[edge.To.ID], edge)
 		}
 	}
+	return subgraph
+}
 
-	if len(result) != len(topoNodes) {
-		return nil, errors.New("graph has a cycle")
+// initIndexes creates the adjacency indexes of graphs that weren't created with NewDirectedGraph
+func (g *DirectedGraph[T, E]) initIndexes() {
+	if g.nodesByID != nil {
+		return
+	}
+	g.nodesByID = make(map[string]*Node[T])
+	g.outEdges = make(map[string][]*DirectedEdge[T, E])
+	g.inEdges = make(map[string][]*DirectedEdge[T, E])
+	for _, n := range g.Nodes {
+		g.nodesByID[n.ID] = n
+	}
+	for _, edge := range g.Edges {
+		g.outEdges[edge.From.ID] = append(g.outEdges[edge.From.ID], edge)
+		g.inEdges[edge.To.ID] = append(g.inEdges[edge.To.ID], edge)
 	}
+}
 
-	resultValues := make([]T, 0)
-	for _, n := range result {
-		resultValues = append(resultValues, n.Value)
+func distinctNodes[T any, E any](edges []*DirectedEdge[T, E], endpoint func(edge *DirectedEdge[T, E]) *Node[T]) []*Node[T] {
+	seen := make(map[string]bool)
+	result := make([]*Node[T], 0)
+	for _, edge := range edges {
+		n := endpoint(edge)
+		if !seen[n.ID] {
+			seen[n.ID] = true
+			result = append(result, n)
+		}
 	}
-	return resultValues, nil
+	return result
 }
@@ -0,0 +1,292 @@
+package graph
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// newTestGraph builds a graph of nodes named by their IDs and of edges written "from->to" or "from->to=weight", with
+// the weight, 1 by default, as their value
+func newTestGraph(t *testing.T, nodes []string, edges []string) *DirectedGraph[string, int] {
+	t.Helper()
+	g := NewDirectedGraph[string, int]()
+	for _, id := range nodes {
+		if _, err := g.AddNodeWithID(id, id); err != nil {
+			t.Fatalf("AddNodeWithID(%q) error = %v", id, err)
+		}
+	}
+	for _, edge := range edges {
+		from, to, ok := strings.Cut(edge, "->")
+		if !ok {
+			t.Fatalf("invalid edge %q", edge)
+		}
+		weight := 1
+		if name, value, ok := strings.Cut(to, "="); ok {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				t.Fatalf("invalid edge %q", edge)
+			}
+			to, weight = name, n
+		}
+		g.AddEdge(mustNode(t, g, from), mustNode(t, g, to), weight)
+	}
+	return g
+}
+
+func mustNode(t *testing.T, g *DirectedGraph[string, int], id string) *Node[string] {
+	t.Helper()
+	n, ok := g.Node(id)
+	if !ok {
+		t.Fatalf("node %q not found", id)
+	}
+	return n
+}
+
+// nodeIDs returns the IDs of nodes, in order
+func nodeIDs(nodes []*Node[string]) []string {
+	ids := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		ids = append(ids, n.ID)
+	}
+	return ids
+}
+
+// edgeNames returns edges written like newTestGraph reads them, weight included, in order
+func edgeNames(edges []*DirectedEdge[string, int]) []string {
+	names := make([]string, 0, len(edges))
+	for _, edge := range edges {
+		names = append(names, fmt.Sprintf("%s->%s=%d", edge.From.ID, edge.To.ID, edge.Value))
+	}
+	return names
+}
+
+func TestAddNodeWithID(t *testing.T) {
+	g := newTestGraph(t, []string{"a"}, nil)
+	if _, err := g.AddNodeWithID("a", "again"); err == nil {
+		t.Error("AddNodeWithID() of an existing ID error = nil")
+	}
+	if n := g.AddNode("generated"); n.ID == "" || n.ID == "a" {
+		t.Errorf("AddNode() ID = %q, want a new generated ID", n.ID)
+	}
+	if got := nodeIDs(g.Nodes); len(got) != 2 || got[0] != "a" {
+		t.Errorf("Nodes = %v, want a and the generated node", got)
+	}
+}
+
+func TestRemoveNode(t *testing.T) {
+	tests := []struct {
+		name      string
+		nodes     []string
+		edges     []string
+		remove    string
+		wantNodes []string
+		wantEdges []string
+	}{
+		{
+			name:      "node with edges from and to it",
+			nodes:     []string{"a", "b", "c"},
+			edges:     []string{"a->b", "b->c", "a->c"},
+			remove:    "b",
+			wantNodes: []string{"a", "c"},
+			wantEdges: []string{"a->c=1"},
+		},
+		{
+			name:      "node with a self-loop",
+			nodes:     []string{"a", "b"},
+			edges:     []string{"a->a", "a->b", "b->b"},
+			remove:    "a",
+			wantNodes: []string{"b"},
+			wantEdges: []string{"b->b=1"},
+		},
+		{
+			name:      "node with parallel edges",
+			nodes:     []string{"a", "b", "c"},
+			edges:     []string{"a->b=1", "a->b=2", "b->c"},
+			remove:    "a",
+			wantNodes: []string{"b", "c"},
+			wantEdges: []string{"b->c=1"},
+		},
+		{
+			name:      "isolated node",
+			nodes:     []string{"a", "b"},
+			edges:     []string{"a->a"},
+			remove:    "b",
+			wantNodes: []string{"a"},
+			wantEdges: []string{"a->a=1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGraph(t, tt.nodes, tt.edges)
+			removed := mustNode(t, g, tt.remove)
+			g.RemoveNode(removed)
+
+			if got := nodeIDs(g.Nodes); !slices.Equal(got, tt.wantNodes) {
+				t.Errorf("Nodes = %v, want %v", got, tt.wantNodes)
+			}
+			if got := edgeNames(g.Edges); !slices.Equal(got, tt.wantEdges) {
+				t.Errorf("Edges = %v, want %v", got, tt.wantEdges)
+			}
+			if _, ok := g.Node(tt.remove); ok {
+				t.Errorf("Node(%q) found the removed node", tt.remove)
+			}
+			if edges := slices.Concat(g.OutEdges(removed), g.InEdges(removed)); len(edges) != 0 {
+				t.Errorf("edges of the removed node = %v, want none", edgeNames(edges))
+			}
+			for _, n := range g.Nodes {
+				for _, edge := range slices.Concat(g.OutEdges(n), g.InEdges(n)) {
+					if edge.From == removed || edge.To == removed {
+						t.Errorf("edge %v of %s still has the removed node", edgeNames([]*DirectedEdge[string, int]{edge}), n.ID)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRemoveEdge(t *testing.T) {
+	tests := []struct {
+		name         string
+		edges        []string
+		remove       int
+		wantEdges    []string
+		wantOutEdges map[string][]string
+		wantInEdges  map[string][]string
+	}{
+		{
+			name:         "single edge",
+			edges:        []string{"a->b", "b->c"},
+			remove:       0,
+			wantEdges:    []string{"b->c=1"},
+			wantOutEdges: map[string][]string{"a": {}, "b": {"b->c=1"}},
+			wantInEdges:  map[string][]string{"b": {}, "c": {"b->c=1"}},
+		},
+		{
+			name:         "parallel edge is kept",
+			edges:        []string{"a->b=1", "a->b=2"},
+			remove:       1,
+			wantEdges:    []string{"a->b=1"},
+			wantOutEdges: map[string][]string{"a": {"a->b=1"}},
+			wantInEdges:  map[string][]string{"b": {"a->b=1"}},
+		},
+		{
+			name:         "self-loop",
+			edges:        []string{"a->a", "a->b"},
+			remove:       0,
+			wantEdges:    []string{"a->b=1"},
+			wantOutEdges: map[string][]string{"a": {"a->b=1"}},
+			wantInEdges:  map[string][]string{"a": {}, "b": {"a->b=1"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGraph(t, []string{"a", "b", "c"}, tt.edges)
+			g.RemoveEdge(g.Edges[tt.remove])
+
+			if got := edgeNames(g.Edges); !slices.Equal(got, tt.wantEdges) {
+				t.Errorf("Edges = %v, want %v", got, tt.wantEdges)
+			}
+			for id, want := range tt.wantOutEdges {
+				if got := edgeNames(g.OutEdges(mustNode(t, g, id))); !slices.Equal(got, want) {
+					t.Errorf("OutEdges(%s) = %v, want %v", id, got, want)
+				}
+			}
+			for id, want := range tt.wantInEdges {
+				if got := edgeNames(g.InEdges(mustNode(t, g, id))); !slices.Equal(got, want) {
+					t.Errorf("InEdges(%s) = %v, want %v", id, got, want)
+				}
+			}
+			if got := nodeIDs(g.Nodes); !slices.Equal(got, []string{"a", "b", "c"}) {
+				t.Errorf("Nodes = %v, want every node", got)
+			}
+		})
+	}
+}
+
+func TestNeighbors(t *testing.T) {
+	g := newTestGraph(t, []string{"a", "b", "c"}, []string{"a->c", "a->b=1", "a->b=2", "c->b"})
+	if got, want := nodeIDs(g.OutNeighbors(mustNode(t, g, "a"))), []string{"c", "b"}; !slices.Equal(got, want) {
+		t.Errorf("OutNeighbors(a) = %v, want %v", got, want)
+	}
+	if got, want := nodeIDs(g.InNeighbors(mustNode(t, g, "b"))), []string{"a", "c"}; !slices.Equal(got, want) {
+		t.Errorf("InNeighbors(b) = %v, want %v", got, want)
+	}
+}
+
+func TestSubgraph(t *testing.T) {
+	tests := []struct {
+		name      string
+		edges     []string
+		keep      []string
+		wantEdges []string
+	}{
+		{
+			name:      "edges between kept nodes",
+			edges:     []string{"a->b", "b->c", "c->d", "a->d"},
+			keep:      []string{"a", "b", "d"},
+			wantEdges: []string{"a->b=1", "a->d=1"},
+		},
+		{
+			name:      "parallel edges and self-loops",
+			edges:     []string{"a->b=1", "a->b=2", "b->b", "b->c"},
+			keep:      []string{"a", "b"},
+			wantEdges: []string{"a->b=1", "a->b=2", "b->b=1"},
+		},
+		{
+			name:      "no nodes",
+			edges:     []string{"a->b"},
+			keep:      nil,
+			wantEdges: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes := []string{"a", "b", "c", "d"}
+			g := newTestGraph(t, nodes, tt.edges)
+			subgraph := g.Subgraph(func(n *Node[string]) bool { return slices.Contains(tt.keep, n.ID) })
+
+			if got := nodeIDs(subgraph.Nodes); !slices.Equal(got, tt.keep) {
+				t.Errorf("Nodes = %v, want %v", got, tt.keep)
+			}
+			if got := edgeNames(subgraph.Edges); !slices.Equal(got, tt.wantEdges) {
+				t.Errorf("Edges = %v, want %v", got, tt.wantEdges)
+			}
+			for _, id := range tt.keep {
+				n, ok := subgraph.Node(id)
+				if !ok || n != mustNode(t, g, id) {
+					t.Errorf("Node(%q) isn't the node of the original graph", id)
+				}
+				for _, edge := range subgraph.OutEdges(n) {
+					if !slices.Contains(tt.keep, edge.To.ID) {
+						t.Errorf("OutEdges(%s) has an edge to %s, which isn't kept", id, edge.To.ID)
+					}
+				}
+			}
+
+			// the original graph is left as it is
+			if got := nodeIDs(g.Nodes); !slices.Equal(got, nodes) {
+				t.Errorf("original Nodes = %v, want %v", got, nodes)
+			}
+			if got := len(g.Edges); got != len(tt.edges) {
+				t.Errorf("original has %d edges, want %d", got, len(tt.edges))
+			}
+		})
+	}
+}
+
+func TestGraphWithoutConstructor(t *testing.T) {
+	a, b := &Node[string]{ID: "a", Value: "a"}, &Node[string]{ID: "b", Value: "b"}
+	g := &DirectedGraph[string, int]{
+		Nodes: []*Node[string]{a, b},
+		Edges: []*DirectedEdge[string, int]{{From: a, To: b, Value: 1}},
+	}
+	if n, ok := g.Node("b"); !ok || n != b {
+		t.Errorf("Node(b) = %v, %v, want b", n, ok)
+	}
+	if got, want := edgeNames(g.OutEdges(a)), []string{"a->b=1"}; !slices.Equal(got, want) {
+		t.Errorf("OutEdges(a) = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,105 @@
+package graph
+
+import "iter"
+
+// BFS iterates over the nodes reachable from start in breadth-first order, start included. Neighbors are visited in
+// edge insertion order.
+func (g *DirectedGraph[T, E]) BFS(start *Node[T]) iter.Seq[*Node[T]] {
+	return func(yield func(*Node[T]) bool) {
+		g.walkBFS(start, nil, true, yield)
+	}
+}
+
+// DFS iterates over the nodes reachable from start in depth-first pre-order, start included. Neighbors are visited in
+// edge insertion order.
+func (g *DirectedGraph[T, E]) DFS(start *Node[T]) iter.Seq[*Node[T]] {
+	return func(yield func(*Node[T]) bool) {
+		g.initIndexes()
+		visited := make(map[string]bool)
+		var visit func(n *Node[T]) bool
+		visit = func(n *Node[T]) bool {
+			visited[n.ID] = true
+			if !yield(n) {
+				return false
+			}
+			for _, edge := range g.outEdges[n.ID] {
+				if !visited[edge.To.ID] && !visit(edge.To) {
+					return false
+				}
+			}
+			return true
+		}
+		visit(start)
+	}
+}
+
+// Reachable returns whether there is a path from one node to another. A node is always reachable from itself.
+func (g *DirectedGraph[T, E]) Reachable(from *Node[T], to *Node[T]) bool {
+	for n := range g.BFS(from) {
+		if n.ID == to.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// Ancestors returns the nodes from which node can be reached, following only the edges for which follow returns true
+// or every edge if follow is nil. The nodes are returned in insertion order.
+func (g *DirectedGraph[T, E]) Ancestors(node *Node[T], follow func(edge *DirectedEdge[T, E]) bool) []*Node[T] {
+	return g.reachable(node, follow, false)
+}
+
+// Descendants returns the nodes that can be reached from node, following only the edges for which follow returns
+// true or every edge if follow is nil. The nodes are returned in insertion order.
+func (g *DirectedGraph[T, E]) Descendants(node *Node[T], follow func(edge *DirectedEdge[T, E]) bool) []*Node[T] {
+	return g.reachable(node, follow, true)
+}
+
+func (g *DirectedGraph[T, E]) reachable(start *Node[T], follow func(edge *DirectedEdge[T, E]) bool, forward bool) []*Node[T] {
+	visited := make(map[string]bool)
+	g.walkBFS(start, follow, forward, func(n *Node[T]) bool {
+		visited[n.ID] = true
+		return true
+	})
+
+	result := make([]*Node[T], 0)
+	for _, n := range g.Nodes {
+		if n.ID != start.ID && visited[n.ID] {
+			result = append(result, n)
+		}
+	}
+	return result
+}
+
+// walkBFS visits the nodes reachable from start, along or against the direction of the edges, until visit returns
+// false
+func (g *DirectedGraph[T, E]) walkBFS(start *Node[T], follow func(edge *DirectedEdge[T, E]) bool, forward bool, visit func(n *Node[T]) bool) {
+	g.initIndexes()
+	visited := map[string]bool{start.ID: true}
+	q := Queue[*Node[T]]{}
+	q.Enqueue(start)
+	for !q.IsEmpty() {
+		n := *q.Dequeue()
+		if !visit(n) {
+			return
+		}
+
+		edges := g.outEdges[n.ID]
+		if !forward {
+			edges = g.inEdges[n.ID]
+		}
+		for _, edge := range edges {
+			if follow != nil && !follow(edge) {
+				continue
+			}
+			next := edge.To
+			if !forward {
+				next = edge.From
+			}
+			if !visited[next.ID] {
+				visited[next.ID] = true
+				q.Enqueue(next)
+			}
+		}
+	}
+}
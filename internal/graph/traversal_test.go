@@ -0,0 +1,160 @@
+package graph
+
+import (
+	"iter"
+	"slices"
+	"testing"
+)
+
+func TestTraversals(t *testing.T) {
+	// a -> b -> d, a -> c -> d -> e, with parallel edges, a self-loop and a cycle back to a
+	edges := []string{"a->b", "a->c", "a->b", "b->d", "c->d", "d->e", "d->d", "e->a"}
+	tests := []struct {
+		name  string
+		start string
+		walk  func(g *DirectedGraph[string, int], start *Node[string]) iter.Seq[*Node[string]]
+		// limit stops the iteration after that many nodes when it isn't 0
+		limit int
+		want  []string
+	}{
+		{
+			name:  "breadth-first",
+			start: "a",
+			walk:  (*DirectedGraph[string, int]).BFS,
+			want:  []string{"a", "b", "c", "d", "e"},
+		},
+		{
+			name:  "depth-first",
+			start: "a",
+			walk:  (*DirectedGraph[string, int]).DFS,
+			want:  []string{"a", "b", "d", "e", "c"},
+		},
+		{
+			name:  "breadth-first from a leaf of the cycle",
+			start: "e",
+			walk:  (*DirectedGraph[string, int]).BFS,
+			want:  []string{"e", "a", "b", "c", "d"},
+		},
+		{
+			name:  "breadth-first without edges",
+			start: "f",
+			walk:  (*DirectedGraph[string, int]).BFS,
+			want:  []string{"f"},
+		},
+		{
+			name:  "breadth-first stopped early",
+			start: "a",
+			walk:  (*DirectedGraph[string, int]).BFS,
+			limit: 2,
+			want:  []string{"a", "b"},
+		},
+		{
+			name:  "depth-first stopped early",
+			start: "a",
+			walk:  (*DirectedGraph[string, int]).DFS,
+			limit: 3,
+			want:  []string{"a", "b", "d"},
+		},
+		{
+			name:  "depth-first stopped at the start",
+			start: "a",
+			walk:  (*DirectedGraph[string, int]).DFS,
+			limit: 1,
+			want:  []string{"a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGraph(t, []string{"a", "b", "c", "d", "e", "f"}, edges)
+			// iterators panic when they keep yielding after the loop is broken out of
+			got := make([]string, 0)
+			for n := range tt.walk(g, mustNode(t, g, tt.start)) {
+				got = append(got, n.ID)
+				if len(got) == tt.limit {
+					break
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("visited %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReachable(t *testing.T) {
+	g := newTestGraph(t, []string{"a", "b", "c"}, []string{"a->b"})
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{from: "a", to: "b", want: true},
+		{from: "b", to: "a", want: false},
+		{from: "a", to: "c", want: false},
+		{from: "c", to: "c", want: true},
+	}
+	for _, tt := range tests {
+		if got := g.Reachable(mustNode(t, g, tt.from), mustNode(t, g, tt.to)); got != tt.want {
+			t.Errorf("Reachable(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestAncestorsDescendants(t *testing.T) {
+	// edges of weight 2 are not followed by the filter
+	nodes := []string{"e", "d", "c", "b", "a"}
+	edges := []string{"a->b", "b->c", "c->d=2", "b->d", "d->e", "e->e", "a->c=2"}
+	onlyWeightOne := func(edge *DirectedEdge[string, int]) bool { return edge.Value == 1 }
+	tests := []struct {
+		name            string
+		node            string
+		follow          func(edge *DirectedEdge[string, int]) bool
+		wantAncestors   []string
+		wantDescendants []string
+	}{
+		{
+			name:            "every edge, in insertion order",
+			node:            "c",
+			wantAncestors:   []string{"b", "a"},
+			wantDescendants: []string{"e", "d"},
+		},
+		{
+			name:            "filtered edges",
+			node:            "c",
+			follow:          onlyWeightOne,
+			wantAncestors:   []string{"b", "a"},
+			wantDescendants: []string{},
+		},
+		{
+			name:            "node reached through another path",
+			node:            "d",
+			follow:          onlyWeightOne,
+			wantAncestors:   []string{"b", "a"},
+			wantDescendants: []string{"e"},
+		},
+		{
+			name:            "node with a self-loop isn't its own ancestor",
+			node:            "e",
+			wantAncestors:   []string{"d", "c", "b", "a"},
+			wantDescendants: []string{},
+		},
+		{
+			name:            "filter rejecting every edge",
+			node:            "b",
+			follow:          func(*DirectedEdge[string, int]) bool { return false },
+			wantAncestors:   []string{},
+			wantDescendants: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGraph(t, nodes, edges)
+			n := mustNode(t, g, tt.node)
+			if got := nodeIDs(g.Ancestors(n, tt.follow)); !slices.Equal(got, tt.wantAncestors) {
+				t.Errorf("Ancestors(%s) = %v, want %v", tt.node, got, tt.wantAncestors)
+			}
+			if got := nodeIDs(g.Descendants(n, tt.follow)); !slices.Equal(got, tt.wantDescendants) {
+				t.Errorf("Descendants(%s) = %v, want %v", tt.node, got, tt.wantDescendants)
+			}
+		})
+	}
+}
@@ -0,0 +1,451 @@
+package introspect
+
+import (
+	"context"
+	"fmt"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"regexp"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const DefaultSchemaName = "public"
+
+// Options controls which part of the database is introspected
+type Options struct {
+	// Schemas lists the PostgreSQL schemas (namespaces) whose tables are loaded, public by default
+	Schemas []string
+}
+
+// IsDSN reports whether a schema input is a PostgreSQL connection URL rather than a path
+func IsDSN(input string) bool {
+	return strings.HasPrefix(input, "postgres://") || strings.HasPrefix(input, "postgresql://")
+}
+
+// LoadSchemaFromDSN connects to a PostgreSQL database and builds the schema of its tables
+func LoadSchemaFromDSN(ctx context.Context, dsn string, opts Options) (*nodes.PostgreSQLSchema, error) {
+	db, err := sqlx.ConnectContext(ctx, "postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	return LoadSchema(ctx, db, opts)
+}
+
+// LoadSchema builds the schema of the tables in a PostgreSQL database from pg_catalog, so that it is the same as the
+// one parsed from the SQL that created the tables
+func LoadSchema(ctx context.Context, db sqlx.QueryerContext, opts Options) (*nodes.PostgreSQLSchema, error) {
+	schemaNames := opts.Schemas
+	if len(schemaNames) == 0 {
+		schemaNames = []string{DefaultSchemaName}
+	}
+
+	tables, err := loadTables(ctx, db, schemaNames)
+	if err != nil {
+		return nil, err
+	}
+	if err := loadColumns(ctx, db, schemaNames, tables); err != nil {
+		return nil, err
+	}
+	if err := loadConstraints(ctx, db, schemaNames, tables); err != nil {
+		return nil, err
+	}
+	if err := loadUniqueIndexes(ctx, db, schemaNames, tables); err != nil {
+		return nil, err
+	}
+	enums, err := loadEnums(ctx, db, schemaNames)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := &nodes.PostgreSQLSchema{
+		Tables: make(map[string]nodes.Table),
+		Enums:  enums,
+	}
+	for _, table := range tables {
+		schema.Tables[table.Name] = *table
+	}
+	return schema, nil
+}
+
+const tablesQuery = `
+	SELECT n.nspname AS schema_name, c.relname AS table_name
+	FROM pg_catalog.pg_class c
+	JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+	WHERE c.relkind IN ('r', 'p') AND NOT c.relispartition AND n.nspname = ANY($1)
+	ORDER BY n.nspname, c.relname
+`
+
+type tableRow struct {
+	SchemaName string `db:"schema_name"`
+	TableName  string `db:"table_name"`
+}
+
+func loadTables(ctx context.Context, db sqlx.QueryerContext, schemaNames []string) (map[string]*nodes.Table, error) {
+	var rows []tableRow
+	if err := sqlx.SelectContext(ctx, db, &rows, tablesQuery, pq.Array(schemaNames)); err != nil {
+		return nil, fmt.Errorf("unable to load tables: %w", err)
+	}
+
+	tables := make(map[string]*nodes.Table)
+	for _, row := range rows {
+		if existing, ok := tables[row.TableName]; ok {
+			return nil, fmt.Errorf("table %s exists in more than one schema, including %s", existing.Name, row.SchemaName)
+		}
+		tables[row.TableName] = &nodes.Table{
+			Name:        row.TableName,
+			Schema:      row.SchemaName,
+			PrimaryKey:  make([]string, 0),
+			Columns:     make([]nodes.Column, 0),
+			Constraints: make([]nodes.TableConstraint, 0),
+		}
+	}
+	return tables, nil
+}
+
+const columnsQuery = `
+	SELECT
+		c.relname AS table_name,
+		a.attname AS column_name,
+		CASE WHEN t.typcategory = 'A' THEN et.typname || '[]' ELSE t.typname END AS data_type,
+		a.atttypmod AS type_modifier,
+		a.attnotnull AS not_null,
+		a.attidentity <> '' AS is_identity,
+		a.attgenerated <> '' AS is_generated,
+		pg_catalog.pg_get_expr(d.adbin, d.adrelid) AS default_expr
+	FROM pg_catalog.pg_attribute a
+	JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+	JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+	JOIN pg_catalog.pg_type t ON t.oid = a.atttypid
+	LEFT JOIN pg_catalog.pg_type et ON et.oid = t.typelem
+	LEFT JOIN pg_catalog.pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+	WHERE c.relkind IN ('r', 'p') AND n.nspname = ANY($1) AND a.attnum > 0 AND NOT a.attisdropped
+	ORDER BY c.relname, a.attnum
+`
+
+type columnRow struct {
+	TableName    string  `db:"table_name"`
+	ColumnName   string  `db:"column_name"`
+	DataType     string  `db:"data_type"`
+	TypeModifier int32   `db:"type_modifier"`
+	NotNull      bool    `db:"not_null"`
+	IsIdentity   bool    `db:"is_identity"`
+	IsGenerated  bool    `db:"is_generated"`
+	DefaultExpr  *string `db:"default_expr"`
+}
+
+var serialTypes = map[string]string{
+	"int2": "smallserial",
+	"int4": "serial",
+	"int8": "bigserial",
+}
+
+func loadColumns(ctx context.Context, db sqlx.QueryerContext, schemaNames []string, tables map[string]*nodes.Table) error {
+	var rows []columnRow
+	if err := sqlx.SelectContext(ctx, db, &rows, columnsQuery, pq.Array(schemaNames)); err != nil {
+		return fmt.Errorf("unable to load columns: %w", err)
+	}
+
+	for _, row := range rows {
+		table, ok := tables[row.TableName]
+		if !ok {
+			continue
+		}
+
+		column := nodes.Column{
+			Name:          row.ColumnName,
+			DataType:      row.DataType,
+			TypeModifiers: typeModifiers(strings.TrimSuffix(row.DataType, "[]"), row.TypeModifier),
+			Constraints:   make([]nodes.ColumnConstraint, 0),
+		}
+		switch {
+		case row.DefaultExpr != nil && row.IsGenerated:
+			// the expression of a generated column is stored as its default
+			column.Constraints = append(column.Constraints, nodes.ColumnConstraint{
+				Type:            nodes.ConstraintInfoTypeGenerated,
+				ExpressionValue: stripParentheses(*row.DefaultExpr),
+			})
+		case row.DefaultExpr != nil:
+			// serial columns are integers with a sequence default, which the SQL parser knows by their serial type
+			if serialType, ok := serialTypes[row.DataType]; ok && strings.HasPrefix(*row.DefaultExpr, "nextval(") {
+				column.DataType = serialType
+			} else {
+				column.Constraints = append(column.Constraints, nodes.ColumnConstraint{
+					Type:            nodes.ConstraintInfoTypeDefault,
+					ExpressionValue: normalizeDefault(*row.DefaultExpr),
+				})
+			}
+		}
+		if row.IsIdentity {
+			column.Constraints = append(column.Constraints, nodes.ColumnConstraint{Type: nodes.ConstraintInfoTypeIdentity})
+		}
+		if row.NotNull {
+			column.Constraints = append(column.Constraints, nodes.ColumnConstraint{Type: nodes.ConstraintInfoTypeNotNull})
+		}
+		table.Columns = append(table.Columns, column)
+	}
+	return nil
+}
+
+// typeModifiers decodes the atttypmod of a column to the parameters written in its type, e.g. 259 to [255] for a
+// varchar(255), so that they are the same as the ones parsed from SQL
+func typeModifiers(dataType string, typmod int32) []int32 {
+	if typmod < 0 {
+		return nil
+	}
+	switch dataType {
+	case "varchar", "bpchar":
+		return []int32{typmod - 4}
+	case "bit", "varbit":
+		return []int32{typmod}
+	case "numeric":
+		return []int32{((typmod - 4) >> 16) & 0xffff, (typmod - 4) & 0xffff}
+	case "time", "timetz", "timestamp", "timestamptz":
+		return []int32{typmod & 0xffff}
+	}
+	return nil
+}
+
+var (
+	castLiteralRegexp = regexp.MustCompile(`^'((?:[^']|'')*)'::[\w\s."\[\]]+$`)
+	castNullRegexp    = regexp.MustCompile(`^NULL::[\w\s."\[\]]+$`)
+)
+
+// normalizeDefault converts a default expression as printed by PostgreSQL to the value the SQL parser reads from a
+// DEFAULT clause, e.g. 'abc'::text becomes abc
+func normalizeDefault(expr string) string {
+	if expr == "NULL" || castNullRegexp.MatchString(expr) {
+		return "NULL"
+	}
+	if matches := castLiteralRegexp.FindStringSubmatch(expr); matches != nil {
+		return strings.ReplaceAll(matches[1], "''", "'")
+	}
+	return expr
+}
+
+const constraintsQuery = `
+	SELECT
+		c.relname AS table_name,
+		con.conname AS constraint_name,
+		con.contype AS constraint_type,
+		ARRAY(
+			SELECT a.attname FROM unnest(con.conkey) WITH ORDINALITY AS k(attnum, ord)
+			JOIN pg_catalog.pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+			ORDER BY k.ord
+		)::text[] AS column_names,
+		COALESCE(ref.relname, '') AS foreign_table_name,
+		ARRAY(
+			SELECT a.attname FROM unnest(con.confkey) WITH ORDINALITY AS k(attnum, ord)
+			JOIN pg_catalog.pg_attribute a ON a.attrelid = con.confrelid AND a.attnum = k.attnum
+			ORDER BY k.ord
+		)::text[] AS foreign_column_names,
+		pg_catalog.pg_get_constraintdef(con.oid) AS definition
+	FROM pg_catalog.pg_constraint con
+	JOIN pg_catalog.pg_class c ON c.oid = con.conrelid
+	JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+	LEFT JOIN pg_catalog.pg_class ref ON ref.oid = con.confrelid
+	WHERE con.contype IN ('p', 'f', 'u', 'c') AND n.nspname = ANY($1)
+	ORDER BY c.relname, con.conname
+`
+
+type constraintRow struct {
+	TableName          string         `db:"table_name"`
+	ConstraintName     string         `db:"constraint_name"`
+	ConstraintType     string         `db:"constraint_type"`
+	ColumnNames        pq.StringArray `db:"column_names"`
+	ForeignTableName   string         `db:"foreign_table_name"`
+	ForeignColumnNames pq.StringArray `db:"foreign_column_names"`
+	Definition         string         `db:"definition"`
+}
+
+func loadConstraints(ctx context.Context, db sqlx.QueryerContext, schemaNames []string, tables map[string]*nodes.Table) error {
+	var rows []constraintRow
+	if err := sqlx.SelectContext(ctx, db, &rows, constraintsQuery, pq.Array(schemaNames)); err != nil {
+		return fmt.Errorf("unable to load constraints: %w", err)
+	}
+
+	for _, row := range rows {
+		table, ok := tables[row.TableName]
+		if !ok {
+			continue
+		}
+
+		switch row.ConstraintType {
+		case "p":
+			table.PrimaryKey = append(table.PrimaryKey, row.ColumnNames...)
+			table.Constraints = append(table.Constraints, nodes.TableConstraint{
+				Name:       row.ConstraintName,
+				Type:       nodes.ConstraintInfoTypePrimaryKey,
+				Constraint: &nodes.PrimaryKeyConstraintInfo{ColumnNames: row.ColumnNames},
+			})
+		case "f":
+			if len(row.ColumnNames) != len(row.ForeignColumnNames) {
+				return fmt.Errorf("foreign key %s has mismatched columns", row.ConstraintName)
+			}
+			for i, columnName := range row.ColumnNames {
+				table.Constraints = append(table.Constraints, nodes.TableConstraint{
+					Name: row.ConstraintName,
+					Type: nodes.ConstraintInfoTypeForeignKey,
+					Constraint: &nodes.ForeignKeyConstraintInfo{
+						TableColumnName:      columnName,
+						ForeignKeyTableName:  row.ForeignTableName,
+						ForeignKeyColumnName: row.ForeignColumnNames[i],
+					},
+				})
+			}
+		case "u":
+			// single column unique constraints are column constraints, like when they are declared inline
+			if len(row.ColumnNames) == 1 {
+				addColumnConstraint(table, row.ColumnNames[0], nodes.ColumnConstraint{Type: nodes.ConstraintInfoTypeUnique})
+				continue
+			}
+			table.Constraints = append(table.Constraints, nodes.TableConstraint{
+				Name:       row.ConstraintName,
+				Type:       nodes.ConstraintInfoTypeUnique,
+				Constraint: &nodes.UniqueConstraintInfo{ColumnNames: row.ColumnNames},
+			})
+		case "c":
+			expression := checkExpression(row.Definition)
+			if len(row.ColumnNames) == 1 {
+				addColumnConstraint(table, row.ColumnNames[0], nodes.ColumnConstraint{
+					Type:            nodes.ConstraintInfoTypeCheck,
+					ExpressionValue: expression,
+				})
+				continue
+			}
+			table.Constraints = append(table.Constraints, nodes.TableConstraint{
+				Name:       row.ConstraintName,
+				Type:       nodes.ConstraintInfoTypeCheck,
+				Constraint: &nodes.CheckConstraintInfo{Expression: expression},
+			})
+		}
+	}
+	return nil
+}
+
+// uniqueIndexesQuery reads the unique indexes on plain columns that don't back a constraint, which the SQL parser
+// reads from CREATE UNIQUE INDEX statements
+const uniqueIndexesQuery = `
+	SELECT
+		c.relname AS table_name,
+		ic.relname AS index_name,
+		ARRAY(
+			SELECT a.attname FROM unnest(i.indkey::int2[]) WITH ORDINALITY AS k(attnum, ord)
+			JOIN pg_catalog.pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = k.attnum
+			WHERE k.ord <= i.indnkeyatts
+			ORDER BY k.ord
+		)::text[] AS column_names
+	FROM pg_catalog.pg_index i
+	JOIN pg_catalog.pg_class c ON c.oid = i.indrelid
+	JOIN pg_catalog.pg_class ic ON ic.oid = i.indexrelid
+	JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+	WHERE i.indisunique AND NOT i.indisprimary AND i.indpred IS NULL AND i.indexprs IS NULL
+		AND NOT EXISTS (SELECT 1 FROM pg_catalog.pg_constraint con WHERE con.conindid = i.indexrelid)
+		AND n.nspname = ANY($1)
+	ORDER BY c.relname, ic.relname
+`
+
+type uniqueIndexRow struct {
+	TableName   string         `db:"table_name"`
+	IndexName   string         `db:"index_name"`
+	ColumnNames pq.StringArray `db:"column_names"`
+}
+
+func loadUniqueIndexes(ctx context.Context, db sqlx.QueryerContext, schemaNames []string, tables map[string]*nodes.Table) error {
+	var rows []uniqueIndexRow
+	if err := sqlx.SelectContext(ctx, db, &rows, uniqueIndexesQuery, pq.Array(schemaNames)); err != nil {
+		return fmt.Errorf("unable to load unique indexes: %w", err)
+	}
+
+	for _, row := range rows {
+		table, ok := tables[row.TableName]
+		if !ok {
+			continue
+		}
+		table.Constraints = append(table.Constraints, nodes.TableConstraint{
+			Name:       row.IndexName,
+			Type:       nodes.ConstraintInfoTypeUnique,
+			Constraint: &nodes.UniqueConstraintInfo{ColumnNames: row.ColumnNames},
+		})
+	}
+	return nil
+}
+
+func addColumnConstraint(table *nodes.Table, columnName string, constraint nodes.ColumnConstraint) {
+	for i, column := range table.Columns {
+		if column.Name == columnName {
+			table.Columns[i].Constraints = append(table.Columns[i].Constraints, constraint)
+		}
+	}
+}
+
+// checkExpression extracts the expression from a CHECK constraint definition, e.g. price > 0 from CHECK ((price > 0)),
+// without the parentheses PostgreSQL wraps around the whole expression, like the SQL parser reads it
+func checkExpression(definition string) string {
+	expression := strings.TrimPrefix(definition, "CHECK ")
+	expression = strings.TrimSuffix(expression, " NOT VALID")
+	return stripParentheses(expression)
+}
+
+// stripParentheses removes the parentheses PostgreSQL wraps around a whole expression when it prints it
+func stripParentheses(expression string) string {
+	for enclosedInParentheses(expression) {
+		expression = expression[1 : len(expression)-1]
+	}
+	return expression
+}
+
+// enclosedInParentheses returns whether the first parenthesis of an expression is closed by its last character, as
+// in (a > 0) but not in (a > 0) AND (b > 0)
+func enclosedInParentheses(expression string) bool {
+	if !strings.HasPrefix(expression, "(") || !strings.HasSuffix(expression, ")") {
+		return false
+	}
+	depth := 0
+	quoted := false
+	for i, r := range expression {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+		case quoted:
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+			if depth == 0 {
+				return i == len(expression)-1
+			}
+		}
+	}
+	return false
+}
+
+const enumsQuery = `
+	SELECT t.typname AS type_name, e.enumlabel AS label
+	FROM pg_catalog.pg_type t
+	JOIN pg_catalog.pg_enum e ON e.enumtypid = t.oid
+	JOIN pg_catalog.pg_namespace n ON n.oid = t.typnamespace
+	WHERE n.nspname = ANY($1)
+	ORDER BY t.typname, e.enumsortorder
+`
+
+type enumRow struct {
+	TypeName string `db:"type_name"`
+	Label    string `db:"label"`
+}
+
+func loadEnums(ctx context.Context, db sqlx.QueryerContext, schemaNames []string) (map[string][]string, error) {
+	var rows []enumRow
+	if err := sqlx.SelectContext(ctx, db, &rows, enumsQuery, pq.Array(schemaNames)); err != nil {
+		return nil, fmt.Errorf("unable to load enums: %w", err)
+	}
+
+	enums := make(map[string][]string)
+	for _, row := range rows {
+		enums[row.TypeName] = append(enums[row.TypeName], row.Label)
+	}
+	return enums, nil
+}
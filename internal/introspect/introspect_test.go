@@ -0,0 +1,215 @@
+package introspect
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/jmoiron/sqlx"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+
+	_ "github.com/lib/pq"
+)
+
+func TestCheckExpression(t *testing.T) {
+	tests := []struct {
+		definition string
+		want       string
+	}{
+		{definition: "CHECK ((quantity > 0))", want: "quantity > 0"},
+		{definition: "CHECK (((a > 0) AND (b > 0)))", want: "(a > 0) AND (b > 0)"},
+		{definition: "CHECK (is_valid(code))", want: "is_valid(code)"},
+		{definition: "CHECK ((char_length(name) > 0)) NOT VALID", want: "char_length(name) > 0"},
+		{definition: "CHECK ((code <> ')('::text))", want: "code <> ')('::text"},
+	}
+	for _, tt := range tests {
+		if got := checkExpression(tt.definition); got != tt.want {
+			t.Errorf("checkExpression(%q) = %q, want %q", tt.definition, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeDefault(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{expr: "'pending'::order_status", want: "pending"},
+		{expr: "'it''s'::text", want: "it's"},
+		{expr: "NULL::character varying", want: "NULL"},
+		{expr: "now()", want: "now()"},
+		{expr: "0", want: "0"},
+	}
+	for _, tt := range tests {
+		if got := normalizeDefault(tt.expr); got != tt.want {
+			t.Errorf("normalizeDefault(%q) = %q, want %q", tt.expr, got, tt.want)
+		}
+	}
+}
+
+// integrationDDL creates tables covering what the schema of a database is read from: types and their modifiers,
+// serial columns, defaults, checks, enums, primary, unique and foreign keys, named and unnamed, and unique indexes
+const integrationDDL = `
+CREATE TYPE order_status AS ENUM ('pending', 'paid', 'shipped');
+
+CREATE TABLE users (
+  id serial PRIMARY KEY,
+  email varchar(255) NOT NULL UNIQUE,
+  name text,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE accounts (
+  tenant_id integer NOT NULL,
+  id integer NOT NULL,
+  PRIMARY KEY (tenant_id, id)
+);
+
+CREATE TABLE orders (
+  id bigserial PRIMARY KEY,
+  tenant_id integer NOT NULL,
+  account_id integer NOT NULL,
+  user_id integer NOT NULL REFERENCES users (id),
+  reviewer_id integer REFERENCES users (id),
+  status order_status NOT NULL DEFAULT 'pending',
+  total numeric(10, 2) NOT NULL,
+  quantity integer NOT NULL CHECK (quantity > 0),
+  tags text[],
+  FOREIGN KEY (tenant_id, account_id) REFERENCES accounts (tenant_id, id),
+  UNIQUE (tenant_id, account_id, id)
+);
+
+CREATE UNIQUE INDEX orders_user_id_total_key ON orders (user_id, total);
+
+CREATE TABLE line_items (
+  order_id bigint NOT NULL,
+  position smallint NOT NULL,
+  sku varchar(32) NOT NULL,
+  PRIMARY KEY (order_id, position),
+  CONSTRAINT line_items_order FOREIGN KEY (order_id) REFERENCES orders (id)
+);
+`
+
+// TestLoadSchema introspects a database holding the tables created by integrationDDL and compares its schema with
+// the one parsed from integrationDDL. It runs against the database at DATABASE_URL, in a schema of its own that it
+// drops at the end, and is skipped when DATABASE_URL isn't set.
+func TestLoadSchema(t *testing.T) {
+	parsed, err := nodes.NewPostgreSQLSchema(integrationDDL)
+	if err != nil {
+		t.Fatalf("NewPostgreSQLSchema() error = %v", err)
+	}
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		t.Skip("DATABASE_URL is not set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	db, err := sqlx.ConnectContext(ctx, "postgres", dsn)
+	if err != nil {
+		t.Fatalf("unable to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	schemaName := fmt.Sprintf("integral_introspect_%d", time.Now().UnixNano())
+	if _, err := db.ExecContext(ctx, "CREATE SCHEMA "+schemaName); err != nil {
+		t.Fatalf("unable to create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := db.ExecContext(context.Background(), "DROP SCHEMA "+schemaName+" CASCADE"); err != nil {
+			t.Errorf("unable to drop schema: %v", err)
+		}
+	})
+
+	// the search path is a setting of the connection, so the tables are created and introspected on a single one
+	conn, err := db.Connx(ctx)
+	if err != nil {
+		t.Fatalf("unable to get a connection: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "SET search_path TO "+schemaName); err != nil {
+		t.Fatalf("unable to set search path: %v", err)
+	}
+	if _, err := conn.ExecContext(ctx, integrationDDL); err != nil {
+		t.Fatalf("unable to create tables: %v", err)
+	}
+
+	introspected, err := LoadSchema(ctx, conn, Options{Schemas: []string{schemaName}})
+	if err != nil {
+		t.Fatalf("LoadSchema() error = %v", err)
+	}
+
+	if diff := cmp.Diff(comparableSchema(parsed), comparableSchema(introspected), cmpopts.EquateEmpty()); diff != "" {
+		t.Errorf("introspected schema differs from the parsed one (-parsed +introspected):\n%s", diff)
+	}
+	if diff := cmp.Diff(parsed.Enums, introspected.Enums, cmpopts.EquateEmpty()); diff != "" {
+		t.Errorf("introspected enums differ from the parsed ones (-parsed +introspected):\n%s", diff)
+	}
+}
+
+// comparableTable is what the rest of the module reads from a table, which the introspected and the parsed schemas
+// must agree on even where they store it differently, like a primary key declared inline being a column constraint
+// of the parsed schema only
+type comparableTable struct {
+	Columns     []comparableColumn
+	PrimaryKey  []string
+	UniqueKeys  [][]string
+	ForeignKeys []nodes.ForeignKey
+}
+
+type comparableColumn struct {
+	Name          string
+	DataType      string
+	TypeModifiers []int32
+	NotNull       bool
+	Required      bool
+	Unique        bool
+	Defaults      []string
+	Checks        []string
+}
+
+func comparableSchema(schema *nodes.PostgreSQLSchema) map[string]comparableTable {
+	tables := make(map[string]comparableTable)
+	for name, table := range schema.Tables {
+		result := comparableTable{
+			PrimaryKey:  table.PrimaryKey,
+			UniqueKeys:  table.UniqueKeys(),
+			ForeignKeys: table.ForeignKeys(),
+		}
+		// constraints are listed by name in the database and in order of declaration in the SQL
+		slices.SortFunc(result.UniqueKeys, func(a, b []string) int {
+			return strings.Compare(strings.Join(a, ","), strings.Join(b, ","))
+		})
+		slices.SortFunc(result.ForeignKeys, func(a, b nodes.ForeignKey) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+		for _, column := range table.Columns {
+			c := comparableColumn{
+				Name:          column.Name,
+				DataType:      column.DataType,
+				TypeModifiers: column.TypeModifiers,
+				NotNull:       table.IsNotNullColumn(column.Name),
+				Required:      table.IsRequiredColumn(column.Name),
+			}
+			for _, constraint := range column.Constraints {
+				switch constraint.Type {
+				case nodes.ConstraintInfoTypeUnique:
+					c.Unique = true
+				case nodes.ConstraintInfoTypeDefault:
+					c.Defaults = append(c.Defaults, constraint.ExpressionValue)
+				case nodes.ConstraintInfoTypeCheck:
+					c.Checks = append(c.Checks, constraint.ExpressionValue)
+				}
+			}
+			result.Columns = append(result.Columns, c)
+		}
+		tables[name] = result
+	}
+	return tables
+}
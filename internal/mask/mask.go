@@ -0,0 +1,344 @@
+package mask
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/tab58/go-integral/internal/fake"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"github.com/tab58/go-integral/internal/seeder"
+	"math/rand/v2"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Strategy is how a rule masks the values of the columns it applies to
+type Strategy string
+
+const (
+	// StrategyHash replaces values with a keyed hash: hex text, a UUID, a non-negative integer or bytes, depending on
+	// the column type
+	StrategyHash Strategy = "hash"
+	// StrategyFake replaces values with fake values of the kind of the column, like the dataset generator's
+	StrategyFake Strategy = "fake"
+	// StrategyNull replaces values with null
+	StrategyNull Strategy = "null"
+	// StrategyKeepFormat replaces the letters and digits of values with other letters and digits, keeping their case,
+	// the other characters and the length. It doesn't apply to key columns, whose distinct values it could make equal.
+	StrategyKeepFormat Strategy = "keep_format"
+)
+
+// Strategies are the known strategies
+var Strategies = []Strategy{StrategyHash, StrategyFake, StrategyNull, StrategyKeepFormat}
+
+// Rule masks the values of the columns matching Column, a "table.column" name or glob like "*.email", or of the
+// columns of type Type, like "inet"
+type Rule struct {
+	Column   string
+	Type     string
+	Strategy Strategy
+	// Kind is the kind of the fake values of StrategyFake, guessed from the column name when empty
+	Kind fake.Kind
+}
+
+// Masker masks the values of rows with the first rule matching each column. The masked values only depend on the
+// source value and the secret, so that a value masked in several tables stays the same and joins are preserved, and
+// the foreign keys without rules of their own are masked with the rule of the column they reference.
+type Masker struct {
+	rules  []Rule
+	secret []byte
+}
+
+// New validates the rules and creates a masker. The secret keys the hashes the masked values are derived from, so
+// that they can't be reversed by hashing guessed values.
+func New(rules []Rule, secret string) (*Masker, error) {
+	var errs []error
+	for i, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("rule %d: %w", i, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return &Masker{
+		rules:  rules,
+		secret: []byte(secret),
+	}, nil
+}
+
+// Validate checks that a rule matches columns by name or type and has a known strategy
+func (r Rule) Validate() error {
+	var errs []error
+	if (r.Column == "") == (r.Type == "") {
+		errs = append(errs, errors.New("exactly one of column or type must be set"))
+	}
+	if _, err := path.Match(r.Column, ""); err != nil {
+		errs = append(errs, fmt.Errorf("%q is not a valid glob", r.Column))
+	}
+	if !slices.Contains(Strategies, r.Strategy) {
+		errs = append(errs, fmt.Errorf("unknown strategy %q", r.Strategy))
+	}
+	if r.Kind != "" && (r.Strategy != StrategyFake || !fake.IsKind(string(r.Kind))) {
+		errs = append(errs, fmt.Errorf("kind %q requires the fake strategy and a kind of fake values", r.Kind))
+	}
+	return errors.Join(errs...)
+}
+
+// Mask returns the rows with the values of the columns matching a rule masked, leaving the given rows unchanged
+func (m *Masker) Mask(schema *nodes.PostgreSQLSchema, data seeder.Dataset) (seeder.Dataset, error) {
+	masked := make(seeder.Dataset, len(data))
+	for tableName, rows := range data {
+		table, ok := schema.Tables[tableName]
+		if !ok {
+			return nil, fmt.Errorf("unknown table %q", tableName)
+		}
+		masked[tableName] = make([]seeder.Row, len(rows))
+		for i, row := range rows {
+			maskedRow := make(seeder.Row, len(row))
+			for columnName, value := range row {
+				maskedValue, err := m.maskColumn(schema, table, columnName, value)
+				if err != nil {
+					return nil, fmt.Errorf("%s.%s: %w", tableName, columnName, err)
+				}
+				maskedRow[columnName] = maskedValue
+			}
+			masked[tableName][i] = maskedRow
+		}
+	}
+	return masked, nil
+}
+
+func (m *Masker) maskColumn(schema *nodes.PostgreSQLSchema, table nodes.Table, columnName string, value any) (any, error) {
+	rule, source, column, ok := m.ruleOf(schema, table, columnName, nil)
+	if !ok {
+		return value, nil
+	}
+	if rule.Strategy == StrategyKeepFormat && isKeyColumn(source, column.Name) {
+		// a few digits or letters have few replacements, which distinct values of a key would share
+		return nil, errors.New("the keep_format strategy doesn't apply to primary and unique key columns, mask them with hash or fake")
+	}
+	if value == nil {
+		return nil, nil
+	}
+	if rule.Strategy == StrategyNull {
+		if table.IsNotNullColumn(columnName) {
+			return nil, errors.New("a NOT NULL column can't be nulled out")
+		}
+		return nil, nil
+	}
+	if strings.HasSuffix(column.DataType, "[]") {
+		return nil, fmt.Errorf("the %s strategy doesn't apply to arrays", rule.Strategy)
+	}
+
+	digest := m.digest(value)
+	switch rule.Strategy {
+	case StrategyHash:
+		return hashValue(column, digest)
+	case StrategyFake:
+		return m.fakeValue(source, column, rule.Kind, schema.Enums, digest)
+	}
+	return keepFormat(column, value, digest)
+}
+
+// ruleOf returns the rule of a column, with the table and column the masked values are made for: the column itself,
+// or the column a foreign key without a rule of its own references, so that both are masked alike
+func (m *Masker) ruleOf(schema *nodes.PostgreSQLSchema, table nodes.Table, columnName string, visited []string) (Rule, nodes.Table, nodes.Column, bool) {
+	column, ok := findColumn(table, columnName)
+	if !ok {
+		return Rule{}, table, column, false
+	}
+	for _, rule := range m.rules {
+		matched := strings.EqualFold(rule.Type, column.DataType)
+		if rule.Column != "" {
+			matched, _ = path.Match(rule.Column, table.Name+"."+columnName)
+		}
+		if matched {
+			return rule, table, column, true
+		}
+	}
+
+	key := table.Name + "." + columnName
+	if slices.Contains(visited, key) {
+		return Rule{}, table, column, false
+	}
+	for _, foreignKey := range table.ForeignKeys() {
+		i := slices.Index(foreignKey.Columns, columnName)
+		if i < 0 {
+			continue
+		}
+		if parent, ok := schema.Tables[foreignKey.ReferencedTable]; ok {
+			return m.ruleOf(schema, parent, foreignKey.ReferencedColumns[i], append(visited, key))
+		}
+	}
+	return Rule{}, table, column, false
+}
+
+// digest is the keyed hash of the text of a value, the same for the same value whatever its column
+func (m *Masker) digest(value any) []byte {
+	var text string
+	switch v := value.(type) {
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	case time.Time:
+		text = v.UTC().Format(time.RFC3339Nano)
+	default:
+		text = fmt.Sprint(v)
+	}
+	mac := hmac.New(sha256.New, m.secret)
+	mac.Write([]byte(text))
+	return mac.Sum(nil)
+}
+
+func hashValue(column nodes.Column, digest []byte) (any, error) {
+	switch dataType := strings.ToLower(column.DataType); {
+	case isText(dataType):
+		return truncate(hex.EncodeToString(digest[:16]), column), nil
+	case dataType == "uuid":
+		return uuid(digest), nil
+	case dataType == "bytea":
+		return digest, nil
+	case bits(dataType) > 0:
+		return int64(binary.BigEndian.Uint64(digest) & (1<<(bits(dataType)-1) - 1)), nil
+	}
+	return nil, fmt.Errorf("the hash strategy doesn't apply to %s values", column.DataType)
+}
+
+func (m *Masker) fakeValue(table nodes.Table, column nodes.Column, kind fake.Kind, enums map[string][]string, digest []byte) (any, error) {
+	dataType := strings.ToLower(column.DataType)
+	if labels, ok := enums[dataType]; ok && len(labels) > 0 {
+		return labels[binary.BigEndian.Uint64(digest)%uint64(len(labels))], nil
+	}
+	faker := fake.New(binary.BigEndian.Uint64(digest))
+	if kind == "" {
+		kind = fake.KindOf(table.Name, column, nil)
+	}
+
+	switch {
+	case isText(dataType):
+		maxLength := 0
+		if len(column.TypeModifiers) > 0 {
+			maxLength = int(column.TypeModifiers[0])
+		}
+		if fake.IsUniqueColumn(table, column.Name) {
+			// fake values repeat, a number drawn from the digest keeps them distinct
+			seq := int64(binary.BigEndian.Uint64(digest[8:]) % 1_000_000_000)
+			return faker.UniqueText(kind, maxLength, seq), nil
+		}
+		return faker.Text(kind, maxLength), nil
+	case dataType == "date":
+		return faker.Time(kind).Truncate(24 * time.Hour), nil
+	case dataType == "timestamp" || dataType == "timestamptz":
+		return faker.Time(kind), nil
+	case dataType == "uuid":
+		return uuid(digest), nil
+	case dataType == "inet" || dataType == "cidr":
+		return faker.Text(fake.KindIPAddress, 0), nil
+	}
+	return nil, fmt.Errorf("the fake strategy doesn't apply to %s values", column.DataType)
+}
+
+// keepFormat replaces the letters and digits of the text of a value with random ones drawn from the digest
+func keepFormat(column nodes.Column, value any, digest []byte) (any, error) {
+	random := rand.New(rand.NewPCG(binary.BigEndian.Uint64(digest), binary.BigEndian.Uint64(digest[8:])))
+	dataType := strings.ToLower(column.DataType)
+	replace := func(text string, hexDigits bool) string {
+		runes := []rune(text)
+		for i, r := range runes {
+			switch {
+			case hexDigits && (unicode.IsDigit(r) || strings.ContainsRune("abcdefABCDEF", r)):
+				runes[i] = rune("0123456789abcdef"[random.IntN(16)])
+			case unicode.IsDigit(r):
+				// a leading digit stays non-zero, so that numbers keep their number of digits
+				if r != '0' && (i == 0 || !unicode.IsDigit(runes[i-1])) {
+					runes[i] = rune('1' + random.IntN(9))
+				} else {
+					runes[i] = rune('0' + random.IntN(10))
+				}
+			case unicode.IsUpper(r):
+				runes[i] = rune('A' + random.IntN(26))
+			case unicode.IsLower(r):
+				runes[i] = rune('a' + random.IntN(26))
+			}
+		}
+		return string(runes)
+	}
+
+	switch {
+	case isText(dataType), dataType == "uuid":
+		text, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("cannot mask %T as text", value)
+		}
+		return replace(text, dataType == "uuid"), nil
+	case dataType == "numeric" || dataType == "decimal":
+		return replace(fmt.Sprint(value), false), nil
+	case bits(dataType) > 0:
+		n, ok := value.(int64)
+		if !ok {
+			return nil, fmt.Errorf("cannot mask %T as an integer", value)
+		}
+		masked, err := strconv.ParseInt(replace(strconv.FormatInt(n, 10), false), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		// numbers with as many digits as the largest value of the type can exceed it
+		return masked % (1<<(bits(dataType)-1) - 1), nil
+	}
+	return nil, fmt.Errorf("the keep_format strategy doesn't apply to %s values", column.DataType)
+}
+
+// isKeyColumn returns whether a column is part of the primary key or a unique key of a table
+func isKeyColumn(table nodes.Table, columnName string) bool {
+	return slices.ContainsFunc(table.UniqueKeys(), func(key []string) bool { return slices.Contains(key, columnName) })
+}
+
+func uuid(digest []byte) string {
+	b := slices.Clone(digest[:16])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+func isText(dataType string) bool {
+	return slices.Contains([]string{"text", "varchar", "char", "bpchar", "citext", "name"}, dataType)
+}
+
+// bits returns the size of integer types, 0 for the other types
+func bits(dataType string) int {
+	switch dataType {
+	case "smallint", "int2", "smallserial":
+		return 16
+	case "integer", "int", "int4", "serial":
+		return 32
+	case "bigint", "int8", "bigserial":
+		return 64
+	}
+	return 0
+}
+
+// truncate cuts hex text to the length of a varchar(n) or char(n) column
+func truncate(s string, column nodes.Column) string {
+	if len(column.TypeModifiers) > 0 && int(column.TypeModifiers[0]) < len(s) {
+		return s[:column.TypeModifiers[0]]
+	}
+	return s
+}
+
+func findColumn(table nodes.Table, columnName string) (nodes.Column, bool) {
+	for _, column := range table.Columns {
+		if column.Name == columnName {
+			return column, true
+		}
+	}
+	return nodes.Column{}, false
+}
@@ -0,0 +1,305 @@
+package mask
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"github.com/tab58/go-integral/internal/seeder"
+)
+
+const maskSchema = `
+CREATE TYPE mood AS ENUM ('happy', 'sad', 'calm');
+CREATE TABLE users (
+  id int PRIMARY KEY,
+  email varchar(40) NOT NULL UNIQUE,
+  login varchar(12) UNIQUE,
+  phone text,
+  ssn char(11),
+  age smallint,
+  visits bigint,
+  balance numeric(10, 2),
+  note text NOT NULL,
+  ip inet,
+  mood mood,
+  tags text[],
+  token uuid,
+  avatar bytea,
+  born date,
+  seen_at timestamptz,
+  score float8
+);
+CREATE TABLE orders (id int PRIMARY KEY, user_id int REFERENCES users (id), user_email varchar(40) REFERENCES users (email));
+`
+
+func newTestSchema(t *testing.T) *nodes.PostgreSQLSchema {
+	t.Helper()
+	schema, err := nodes.NewPostgreSQLSchema(maskSchema)
+	if err != nil {
+		t.Fatalf("NewPostgreSQLSchema() error = %v", err)
+	}
+	return schema
+}
+
+func TestRuleValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    Rule
+		wantErr string
+	}{
+		{name: "column rule", rule: Rule{Column: "*.email", Strategy: StrategyHash}},
+		{name: "type rule", rule: Rule{Type: "inet", Strategy: StrategyNull}},
+		{name: "fake kind", rule: Rule{Column: "users.note", Strategy: StrategyFake, Kind: "paragraph"}},
+		{name: "neither column nor type", rule: Rule{Strategy: StrategyHash}, wantErr: "exactly one of column or type must be set"},
+		{name: "column and type", rule: Rule{Column: "users.ip", Type: "inet", Strategy: StrategyHash}, wantErr: "exactly one of column or type must be set"},
+		{name: "invalid glob", rule: Rule{Column: "users.[", Strategy: StrategyHash}, wantErr: `"users.[" is not a valid glob`},
+		{name: "unknown strategy", rule: Rule{Column: "users.ip", Strategy: "shuffle"}, wantErr: `unknown strategy "shuffle"`},
+		{name: "kind of another strategy", rule: Rule{Column: "users.note", Strategy: StrategyHash, Kind: "paragraph"}, wantErr: `kind "paragraph" requires the fake strategy`},
+		{name: "unknown kind", rule: Rule{Column: "users.note", Strategy: StrategyFake, Kind: "poem"}, wantErr: `kind "poem" requires the fake strategy and a kind of fake values`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rule.Validate()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Validate() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	_, err := New([]Rule{{Column: "users.ip", Strategy: StrategyNull}, {Strategy: "shuffle"}}, "secret")
+	if err == nil || !strings.Contains(err.Error(), "rule 1: exactly one of column or type must be set") {
+		t.Fatalf("New() error = %v, want the errors of rule 1", err)
+	}
+}
+
+func TestMaskStrategies(t *testing.T) {
+	schema := newTestSchema(t)
+	row := seeder.Row{
+		"id":      int64(7),
+		"email":   "ann@example.com",
+		"login":   "ann",
+		"phone":   "+1 (555) 010-9999",
+		"ssn":     "123-45-6789",
+		"age":     int64(42),
+		"visits":  int64(9000),
+		"balance": "1234.50",
+		"note":    "Call back",
+		"ip":      "10.0.0.1",
+		"mood":    "happy",
+		"tags":    []any{"a"},
+		"token":   "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+		"avatar":  []byte("png"),
+		"born":    time.Date(1990, 5, 17, 0, 0, 0, 0, time.UTC),
+		"seen_at": time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		"score":   1.5,
+	}
+	tests := []struct {
+		name   string
+		rule   Rule
+		column string
+		want   string
+		// mayKeep is set when the masked value can be the source value, like an enum label
+		mayKeep bool
+		wantErr string
+	}{
+		{name: "hash of text", rule: Rule{Column: "users.note", Strategy: StrategyHash}, column: "note", want: `^[0-9a-f]{32}$`},
+		{name: "hash of a varchar(n)", rule: Rule{Column: "users.login", Strategy: StrategyHash}, column: "login", want: `^[0-9a-f]{12}$`},
+		{name: "hash of a uuid", rule: Rule{Column: "users.token", Strategy: StrategyHash}, column: "token", want: `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{name: "hash of a smallint", rule: Rule{Column: "users.age", Strategy: StrategyHash}, column: "age", want: `^\d{1,5}$`},
+		{name: "hash of an integer key", rule: Rule{Column: "users.id", Strategy: StrategyHash}, column: "id", want: `^\d{1,10}$`},
+		{name: "hash of bytes", rule: Rule{Column: "users.avatar", Strategy: StrategyHash}, column: "avatar", want: `^\[(\d+ ){31}\d+\]$`},
+		{name: "hash of a date", rule: Rule{Column: "users.born", Strategy: StrategyHash}, column: "born", wantErr: "the hash strategy doesn't apply to date values"},
+		{name: "fake enum label", rule: Rule{Column: "users.mood", Strategy: StrategyFake}, column: "mood", want: `^(happy|sad|calm)$`, mayKeep: true},
+		{name: "fake unique email", rule: Rule{Column: "users.email", Strategy: StrategyFake}, column: "email", want: `^[^@\s]+\d+@[^@\s]+$`},
+		{name: "fake date", rule: Rule{Column: "users.born", Strategy: StrategyFake}, column: "born", want: `^\d{4}-\d{2}-\d{2} 00:00:00 \+0000 UTC$`},
+		{name: "fake address", rule: Rule{Type: "inet", Strategy: StrategyFake}, column: "ip", want: `^\d+\.\d+\.\d+\.\d+$`},
+		{name: "fake number", rule: Rule{Column: "users.score", Strategy: StrategyFake}, column: "score", wantErr: "the fake strategy doesn't apply to float8 values"},
+		{name: "null", rule: Rule{Column: "users.phone", Strategy: StrategyNull}, column: "phone", want: `^<nil>$`},
+		{name: "null of a NOT NULL column", rule: Rule{Column: "users.note", Strategy: StrategyNull}, column: "note", wantErr: "a NOT NULL column can't be nulled out"},
+		{name: "keep the format of a phone", rule: Rule{Column: "users.phone", Strategy: StrategyKeepFormat}, column: "phone", want: `^\+[1-9] \([1-9]\d\d\) \d{3}-[1-9]\d{3}$`},
+		{name: "keep the format of text", rule: Rule{Column: "users.note", Strategy: StrategyKeepFormat}, column: "note", want: `^[A-Z][a-z]{3} [a-z]{4}$`},
+		{name: "keep the format of a uuid", rule: Rule{Column: "users.token", Strategy: StrategyKeepFormat}, column: "token", want: `^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`},
+		{name: "keep the format of a numeric", rule: Rule{Column: "users.balance", Strategy: StrategyKeepFormat}, column: "balance", want: `^[1-9]\d{3}\.[1-9]\d$`},
+		{name: "keep the digits of a bigint", rule: Rule{Column: "users.visits", Strategy: StrategyKeepFormat}, column: "visits", want: `^[1-9]\d{3}$`},
+		{name: "keep the format of a date", rule: Rule{Column: "users.born", Strategy: StrategyKeepFormat}, column: "born", wantErr: "the keep_format strategy doesn't apply to date values"},
+		{name: "keep the format of a primary key", rule: Rule{Column: "users.id", Strategy: StrategyKeepFormat}, column: "id", wantErr: "the keep_format strategy doesn't apply to primary and unique key columns"},
+		{name: "keep the format of a unique key", rule: Rule{Column: "users.login", Strategy: StrategyKeepFormat}, column: "login", wantErr: "the keep_format strategy doesn't apply to primary and unique key columns"},
+		{name: "array", rule: Rule{Column: "users.tags", Strategy: StrategyHash}, column: "tags", wantErr: "the hash strategy doesn't apply to arrays"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			masker, err := New([]Rule{tt.rule}, "secret")
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			masked, err := masker.Mask(schema, seeder.Dataset{"users": {row}})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), "users."+tt.column+": "+tt.wantErr) {
+					t.Fatalf("Mask() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Mask() error = %v", err)
+			}
+			got := masked["users"][0]
+			if text := fmt.Sprint(got[tt.column]); !regexp.MustCompile(tt.want).MatchString(text) {
+				t.Errorf("Mask() %s = %s, want it to match %s", tt.column, text, tt.want)
+			}
+			if !tt.mayKeep && cmp.Equal(got[tt.column], row[tt.column]) {
+				t.Errorf("Mask() left %s = %v unchanged", tt.column, got[tt.column])
+			}
+			// the other columns are left as they are
+			for columnName, value := range row {
+				if columnName != tt.column && !cmp.Equal(value, got[columnName]) {
+					t.Errorf("Mask() %s = %v, want %v", columnName, got[columnName], value)
+				}
+			}
+		})
+	}
+}
+
+func TestMaskConsistency(t *testing.T) {
+	schema := newTestSchema(t)
+	rules := []Rule{
+		{Column: "users.email", Strategy: StrategyFake},
+		{Column: "*.id", Strategy: StrategyHash},
+		{Column: "users.*", Strategy: StrategyNull},
+	}
+	data := seeder.Dataset{
+		"users": {
+			{"id": int64(1), "email": "ann@example.com", "phone": "555"},
+			{"id": int64(2), "email": "bob@example.com", "phone": nil},
+		},
+		"orders": {
+			{"id": int64(1), "user_id": int64(2), "user_email": "bob@example.com"},
+			{"id": int64(2), "user_id": nil, "user_email": "ann@example.com"},
+		},
+	}
+	masker, err := New(rules, "secret")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	masked, err := masker.Mask(schema, data)
+	if err != nil {
+		t.Fatalf("Mask() error = %v", err)
+	}
+	again, err := masker.Mask(schema, data)
+	if err != nil {
+		t.Fatalf("Mask() error = %v", err)
+	}
+	if diff := cmp.Diff(masked, again); diff != "" {
+		t.Errorf("Mask() isn't deterministic (-first +second):\n%s", diff)
+	}
+
+	users, orders := masked["users"], masked["orders"]
+	// the first matching rule wins, and the rules of the referenced columns apply to the foreign keys
+	if users[0]["phone"] != nil {
+		t.Errorf("users.phone = %v, want it nulled out", users[0]["phone"])
+	}
+	if users[0]["id"] == int64(1) || users[0]["email"] == "ann@example.com" {
+		t.Errorf("users row %v isn't masked", users[0])
+	}
+	if orders[0]["user_id"] != users[1]["id"] || orders[0]["user_email"] != users[1]["email"] {
+		t.Errorf("orders row %v doesn't reference the masked users row %v", orders[0], users[1])
+	}
+	if orders[1]["user_id"] != nil || orders[1]["user_email"] != users[0]["email"] {
+		t.Errorf("orders row %v doesn't reference the masked users row %v", orders[1], users[0])
+	}
+	// the same value is masked alike in every table, whatever the column
+	if orders[0]["id"] != users[0]["id"] {
+		t.Errorf("orders.id = %v, want the masked users.id %v of the same value", orders[0]["id"], users[0]["id"])
+	}
+	// the given rows are left unchanged
+	if data["users"][0]["email"] != "ann@example.com" {
+		t.Errorf("Mask() changed the given rows")
+	}
+
+	otherMasker, err := New(rules, "other secret")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	other, err := otherMasker.Mask(schema, data)
+	if err != nil {
+		t.Fatalf("Mask() error = %v", err)
+	}
+	if other["users"][0]["id"] == users[0]["id"] {
+		t.Errorf("users.id masked to %v with both secrets", users[0]["id"])
+	}
+}
+
+func TestMaskKeyColumns(t *testing.T) {
+	schema := newTestSchema(t)
+	tests := []struct {
+		name    string
+		rule    Rule
+		table   string
+		column  string
+		wantErr string
+	}{
+		{name: "hash of an integer key", rule: Rule{Column: "users.id", Strategy: StrategyHash}, table: "users", column: "id"},
+		{name: "fake unique text", rule: Rule{Column: "users.login", Strategy: StrategyFake}, table: "users", column: "login"},
+		{
+			name:    "keep the format of a foreign key to a key",
+			rule:    Rule{Column: "users.id", Strategy: StrategyKeepFormat},
+			table:   "orders",
+			column:  "user_id",
+			wantErr: "orders.user_id: the keep_format strategy doesn't apply to primary and unique key columns",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			masker, err := New([]Rule{tt.rule}, "secret")
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			rows := make([]seeder.Row, 2000)
+			for i := range rows {
+				rows[i] = seeder.Row{tt.column: int64(i + 1)}
+				if tt.column == "login" {
+					rows[i][tt.column] = fmt.Sprintf("user%d", i)
+				}
+			}
+			masked, err := masker.Mask(schema, seeder.Dataset{tt.table: rows})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Mask() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Mask() error = %v", err)
+			}
+			// distinct keys stay distinct
+			seen := make(map[any]bool)
+			for _, row := range masked[tt.table] {
+				if seen[row[tt.column]] {
+					t.Fatalf("Mask() gave two %s values %v", tt.column, row[tt.column])
+				}
+				seen[row[tt.column]] = true
+			}
+		})
+	}
+}
+
+func TestMaskUnknownTable(t *testing.T) {
+	masker, err := New(nil, "secret")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := masker.Mask(newTestSchema(t), seeder.Dataset{"carts": {{}}}); err == nil || !strings.Contains(err.Error(), `unknown table "carts"`) {
+		t.Errorf("Mask() error = %v, want an unknown table error", err)
+	}
+}
@@ -0,0 +1,237 @@
+package migrations
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Migration is a single schema migration, with only the SQL that migrates the schema up
+type Migration struct {
+	Path    string
+	Version string
+	Name    string
+	Up      string
+	// Repeatable is set for Flyway repeatable migrations, which are applied after all the versioned ones
+	Repeatable bool
+}
+
+var (
+	// golang-migrate: 1_create_users.up.sql / 1_create_users.down.sql
+	migrateFileRegexp = regexp.MustCompile(`^(\d+)_(.*)\.(up|down)\.sql$`)
+	// Flyway: V1.2__create_users.sql, R__views.sql and U1.2__create_users.sql
+	flywayVersionedRegexp  = regexp.MustCompile(`^V(\d+(?:[._]\d+)*)__(.*)\.sql$`)
+	flywayRepeatableRegexp = regexp.MustCompile(`^R__(.*)\.sql$`)
+	flywayUndoRegexp       = regexp.MustCompile(`^U(\d+(?:[._]\d+)*)__(.*)\.sql$`)
+	// goose and dbmate: 20240101120000_create_users.sql, with the up and down sections marked in the file
+	numberedFileRegexp = regexp.MustCompile(`^(\d+)_(.*)\.sql$`)
+
+	versionSeparatorRegexp = regexp.MustCompile(`[._]`)
+)
+
+// IsPattern reports whether a schema input names a set of migrations, i.e. it is a directory or a glob
+func IsPattern(path string) bool {
+	if strings.ContainsAny(path, "*?[") {
+		return true
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// Load reads the migrations in a directory, or the ones matching a glob, ordered by version. Down migrations are
+// skipped, and only the up sections of goose and dbmate migrations are kept.
+func Load(pathOrGlob string) ([]Migration, error) {
+	paths, err := migrationPaths(pathOrGlob)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(paths))
+	for _, path := range paths {
+		migration, ok, err := readMigration(path)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			migrations = append(migrations, migration)
+		}
+	}
+	if len(migrations) == 0 {
+		return nil, fmt.Errorf("no migrations found in %s", pathOrGlob)
+	}
+
+	slices.SortStableFunc(migrations, compareMigrations)
+	for i := 1; i < len(migrations); i++ {
+		prev, cur := migrations[i-1], migrations[i]
+		if !cur.Repeatable && !prev.Repeatable && compareVersions(prev.Version, cur.Version) == 0 {
+			return nil, fmt.Errorf("migrations %s and %s have the same version %s", prev.Path, cur.Path, cur.Version)
+		}
+	}
+	return migrations, nil
+}
+
+// Apply applies the migrations to the schema in order
+func Apply(schema *nodes.PostgreSQLSchema, migrations []Migration) error {
+	for _, migration := range migrations {
+		if err := schema.Apply(migration.Up); err != nil {
+			return fmt.Errorf("unable to apply migration %s: %w", migration.Path, err)
+		}
+	}
+	return nil
+}
+
+// BuildSchema builds the schema that results from applying the migrations in a directory or matching a glob
+func BuildSchema(pathOrGlob string) (*nodes.PostgreSQLSchema, error) {
+	migrations, err := Load(pathOrGlob)
+	if err != nil {
+		return nil, err
+	}
+	schema := &nodes.PostgreSQLSchema{Tables: make(map[string]nodes.Table)}
+	if err := Apply(schema, migrations); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+func migrationPaths(pathOrGlob string) ([]string, error) {
+	info, err := os.Stat(pathOrGlob)
+	if err == nil && info.IsDir() {
+		entries, err := os.ReadDir(pathOrGlob)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read migrations directory: %w", err)
+		}
+		paths := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".sql") {
+				paths = append(paths, filepath.Join(pathOrGlob, entry.Name()))
+			}
+		}
+		return paths, nil
+	}
+
+	paths, err := filepath.Glob(pathOrGlob)
+	if err != nil {
+		return nil, fmt.Errorf("invalid migrations glob %s: %w", pathOrGlob, err)
+	}
+	return paths, nil
+}
+
+// readMigration reads a migration file, returning false if the file only migrates down
+func readMigration(path string) (Migration, bool, error) {
+	name := filepath.Base(path)
+	migration := Migration{Path: path}
+
+	switch {
+	case migrateFileRegexp.MatchString(name):
+		matches := migrateFileRegexp.FindStringSubmatch(name)
+		if matches[3] == "down" {
+			return Migration{}, false, nil
+		}
+		migration.Version, migration.Name = matches[1], matches[2]
+	case flywayUndoRegexp.MatchString(name):
+		return Migration{}, false, nil
+	case flywayVersionedRegexp.MatchString(name):
+		matches := flywayVersionedRegexp.FindStringSubmatch(name)
+		migration.Version, migration.Name = matches[1], matches[2]
+	case flywayRepeatableRegexp.MatchString(name):
+		migration.Name = flywayRepeatableRegexp.FindStringSubmatch(name)[1]
+		migration.Repeatable = true
+	case numberedFileRegexp.MatchString(name):
+		matches := numberedFileRegexp.FindStringSubmatch(name)
+		migration.Version, migration.Name = matches[1], matches[2]
+	default:
+		return Migration{}, false, fmt.Errorf("%s does not follow a known migration naming convention", path)
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return Migration{}, false, fmt.Errorf("unable to read migration: %w", err)
+	}
+	up, err := extractUpSection(string(contents))
+	if err != nil {
+		return Migration{}, false, fmt.Errorf("%s: %w", path, err)
+	}
+	migration.Up = up
+	return migration, true, nil
+}
+
+type sectionMarkers struct {
+	up   string
+	down string
+}
+
+var knownSectionMarkers = []sectionMarkers{
+	{up: "-- +goose Up", down: "-- +goose Down"},
+	{up: "-- migrate:up", down: "-- migrate:down"},
+}
+
+// extractUpSection keeps the lines between an up marker and the next down marker. Files without markers are
+// migrations that only go up.
+func extractUpSection(contents string) (string, error) {
+	lines := strings.Split(contents, "\n")
+	for _, markers := range knownSectionMarkers {
+		if !slices.ContainsFunc(lines, func(line string) bool { return isMarker(line, markers.up) || isMarker(line, markers.down) }) {
+			continue
+		}
+
+		up := make([]string, 0, len(lines))
+		inUp := false
+		foundUp := false
+		for _, line := range lines {
+			switch {
+			case isMarker(line, markers.up):
+				inUp, foundUp = true, true
+			case isMarker(line, markers.down):
+				inUp = false
+			case inUp:
+				up = append(up, line)
+			}
+		}
+		if !foundUp {
+			return "", errors.New("migration has a down section but no up section")
+		}
+		return strings.Join(up, "\n"), nil
+	}
+	return contents, nil
+}
+
+func isMarker(line string, marker string) bool {
+	line = strings.TrimSpace(line)
+	return line == marker || strings.HasPrefix(line, marker+" ")
+}
+
+func compareMigrations(a, b Migration) int {
+	if a.Repeatable != b.Repeatable {
+		if a.Repeatable {
+			return 1
+		}
+		return -1
+	}
+	if a.Repeatable {
+		return cmp.Compare(a.Name, b.Name)
+	}
+	return compareVersions(a.Version, b.Version)
+}
+
+// compareVersions compares dotted numeric versions part by part, without overflowing on long timestamps
+func compareVersions(a, b string) int {
+	aParts := versionSeparatorRegexp.Split(a, -1)
+	bParts := versionSeparatorRegexp.Split(b, -1)
+	for i := 0; i < min(len(aParts), len(bParts)); i++ {
+		aPart := strings.TrimLeft(aParts[i], "0")
+		bPart := strings.TrimLeft(bParts[i], "0")
+		if c := cmp.Compare(len(aPart), len(bPart)); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(aPart, bPart); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(aParts), len(bParts))
+}
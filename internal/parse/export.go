@@ -0,0 +1,216 @@
+package parse
+
+import (
+	"fmt"
+	"github.com/tab58/go-integral/internal/graph"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const defaultSchemaName = "public"
+
+// ExportOptions controls how the table graph is rendered
+type ExportOptions struct {
+	// ClusterBySchema groups the tables of each PostgreSQL schema together. Only DOT supports clusters.
+	ClusterBySchema bool
+}
+
+// FocusTableGraph restricts the table graph to the given tables and the tables within depth foreign keys of them, in
+// either direction. A negative depth keeps every connected table.
+func FocusTableGraph(schemaGraph *TableGraph, tableNames []string, depth int) (*TableGraph, error) {
+	distances := make(map[string]int)
+	queue := make([]*graph.Node[nodes.Table], 0, len(tableNames))
+	for _, tableName := range tableNames {
+		n, ok := schemaGraph.Node(tableName)
+		if !ok {
+			return nil, fmt.Errorf("unknown table %q", tableName)
+		}
+		if _, ok := distances[n.ID]; !ok {
+			distances[n.ID] = 0
+			queue = append(queue, n)
+		}
+	}
+
+	// breadth-first search over the foreign keys, ignoring their direction
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if depth >= 0 && distances[n.ID] >= depth {
+			continue
+		}
+		for _, neighbor := range slices.Concat(schemaGraph.OutNeighbors(n), schemaGraph.InNeighbors(n)) {
+			if _, ok := distances[neighbor.ID]; !ok {
+				distances[neighbor.ID] = distances[n.ID] + 1
+				queue = append(queue, neighbor)
+			}
+		}
+	}
+
+	return schemaGraph.Subgraph(func(n *graph.Node[nodes.Table]) bool {
+		_, ok := distances[n.ID]
+		return ok
+	}), nil
+}
+
+// WriteDOT renders the table graph as a Graphviz digraph, with an edge for each foreign key from the referencing table
+// to the table it references, labelled with its columns
+func WriteDOT(w io.Writer, schemaGraph *TableGraph, opts ExportOptions) error {
+	var b strings.Builder
+	b.WriteString("digraph schema {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+
+	if opts.ClusterBySchema {
+		for _, schemaName := range tableSchemaNames(schemaGraph) {
+			fmt.Fprintf(&b, "  subgraph %s {\n", strconv.Quote("cluster_"+schemaName))
+			fmt.Fprintf(&b, "    label=%s;\n", strconv.Quote(schemaName))
+			for _, n := range schemaGraph.Nodes {
+				if tableSchemaName(n.Value) == schemaName {
+					fmt.Fprintf(&b, "    %s;\n", strconv.Quote(n.Value.Name))
+				}
+			}
+			b.WriteString("  }\n")
+		}
+	} else {
+		for _, n := range schemaGraph.Nodes {
+			fmt.Fprintf(&b, "  %s;\n", strconv.Quote(n.Value.Name))
+		}
+	}
+
+	for _, edge := range foreignKeyEdges(schemaGraph) {
+		fmt.Fprintf(&b, "  %s -> %s [label=%s];\n", strconv.Quote(edge.table.Name), strconv.Quote(edge.foreignKey.ReferencedTable), strconv.Quote(edge.label()))
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMermaid renders the table graph as a Mermaid ER diagram, with the columns of each table and a relationship for
+// each foreign key, labelled with its columns
+func WriteMermaid(w io.Writer, schemaGraph *TableGraph, opts ExportOptions) error {
+	var b strings.Builder
+	b.WriteString("erDiagram\n")
+
+	for _, n := range schemaGraph.Nodes {
+		table := n.Value
+		fmt.Fprintf(&b, "  %s {\n", mermaidName(table.Name))
+		for _, column := range table.Columns {
+			fmt.Fprintf(&b, "    %s %s", mermaidWord(column.DataType), mermaidWord(column.Name))
+			if keys := columnKeys(table, column.Name); len(keys) > 0 {
+				fmt.Fprintf(&b, " %s", strings.Join(keys, ","))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("  }\n")
+	}
+
+	for _, edge := range foreignKeyEdges(schemaGraph) {
+		// a foreign key with a nullable column means the referencing row can exist without the referenced one
+		parentCardinality := "||"
+		if slices.ContainsFunc(edge.foreignKey.Columns, func(column string) bool { return !edge.table.IsNotNullColumn(column) }) {
+			parentCardinality = "|o"
+		}
+		fmt.Fprintf(&b, "  %s %s--o{ %s : %s\n", mermaidName(edge.foreignKey.ReferencedTable), parentCardinality, mermaidName(edge.table.Name), strconv.Quote(edge.label()))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// foreignKeyEdge is a foreign key of a table of the graph to another table of the graph
+type foreignKeyEdge struct {
+	table      nodes.Table
+	foreignKey nodes.ForeignKey
+}
+
+// label writes the columns of the foreign key and the ones it references, in parentheses when there are several
+func (e foreignKeyEdge) label() string {
+	columns := func(names []string) string {
+		if len(names) == 1 {
+			return names[0]
+		}
+		return "(" + strings.Join(names, ", ") + ")"
+	}
+	return fmt.Sprintf("%s -> %s", columns(e.foreignKey.Columns), columns(e.foreignKey.ReferencedColumns))
+}
+
+// foreignKeyEdges returns the foreign keys between the tables of the graph, once for each constraint rather than once
+// for each of its columns like the edges of the graph
+func foreignKeyEdges(schemaGraph *TableGraph) []foreignKeyEdge {
+	edges := make([]foreignKeyEdge, 0, len(schemaGraph.Edges))
+	for _, n := range schemaGraph.Nodes {
+		for _, foreignKey := range n.Value.ForeignKeys() {
+			if _, ok := schemaGraph.Node(foreignKey.ReferencedTable); ok {
+				edges = append(edges, foreignKeyEdge{table: n.Value, foreignKey: foreignKey})
+			}
+		}
+	}
+	return edges
+}
+
+func tableSchemaName(table nodes.Table) string {
+	if table.Schema == "" {
+		return defaultSchemaName
+	}
+	return table.Schema
+}
+
+func tableSchemaNames(schemaGraph *TableGraph) []string {
+	schemaNames := make([]string, 0)
+	for _, n := range schemaGraph.Nodes {
+		if schemaName := tableSchemaName(n.Value); !slices.Contains(schemaNames, schemaName) {
+			schemaNames = append(schemaNames, schemaName)
+		}
+	}
+	slices.Sort(schemaNames)
+	return schemaNames
+}
+
+// columnKeys returns the Mermaid key markers of a column
+func columnKeys(table nodes.Table, columnName string) []string {
+	keys := make([]string, 0)
+	if slices.Contains(table.PrimaryKey, columnName) {
+		keys = append(keys, "PK")
+	}
+	for _, constraint := range table.Constraints {
+		if info, ok := constraint.Constraint.(*nodes.ForeignKeyConstraintInfo); ok && info.TableColumnName == columnName {
+			keys = append(keys, "FK")
+			break
+		}
+	}
+	isUnique := slices.ContainsFunc(table.Constraints, func(constraint nodes.TableConstraint) bool {
+		info, ok := constraint.Constraint.(*nodes.UniqueConstraintInfo)
+		return ok && slices.Contains(info.ColumnNames, columnName)
+	})
+	for _, column := range table.Columns {
+		if column.Name == columnName && slices.ContainsFunc(column.Constraints, func(c nodes.ColumnConstraint) bool {
+			return c.Type == nodes.ConstraintInfoTypeUnique
+		}) {
+			isUnique = true
+		}
+	}
+	if isUnique {
+		keys = append(keys, "UK")
+	}
+	return keys
+}
+
+var mermaidInvalidChars = regexp.MustCompile(`[^A-Za-z0-9_\-\[\]()]`)
+
+// mermaidWord makes a data type like "timestamp with time zone", or a column name, usable in a Mermaid attribute
+func mermaidWord(word string) string {
+	return mermaidInvalidChars.ReplaceAllString(word, "_")
+}
+
+// mermaidName quotes entity names that Mermaid can't use as is
+func mermaidName(name string) string {
+	if mermaidInvalidChars.MatchString(name) || strings.ContainsAny(name, "[]()") {
+		return strconv.Quote(name)
+	}
+	return name
+}
@@ -0,0 +1,96 @@
+package parse
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of the tests")
+
+// exportSchema has a composite foreign key, a nullable one, two foreign keys to the same table and a table in another
+// PostgreSQL schema
+const exportSchema = `
+CREATE SCHEMA billing;
+CREATE TABLE users (id serial PRIMARY KEY, email text NOT NULL UNIQUE);
+CREATE TABLE accounts (org int NOT NULL, num int NOT NULL, name text, PRIMARY KEY (org, num));
+CREATE TABLE billing.invoices (
+  id serial PRIMARY KEY,
+  account_org int NOT NULL,
+  account_num int NOT NULL,
+  created_by int NOT NULL REFERENCES users (id),
+  reviewed_by int REFERENCES users (id),
+  FOREIGN KEY (account_org, account_num) REFERENCES accounts (org, num)
+);
+`
+
+func TestExport(t *testing.T) {
+	tests := []struct {
+		name   string
+		golden string
+		write  func(w *bytes.Buffer, schemaGraph *TableGraph) error
+	}{
+		{
+			name:   "dot",
+			golden: "export.dot",
+			write: func(w *bytes.Buffer, schemaGraph *TableGraph) error {
+				return WriteDOT(w, schemaGraph, ExportOptions{})
+			},
+		},
+		{
+			name:   "dot clustered by schema",
+			golden: "export_cluster.dot",
+			write: func(w *bytes.Buffer, schemaGraph *TableGraph) error {
+				return WriteDOT(w, schemaGraph, ExportOptions{ClusterBySchema: true})
+			},
+		},
+		{
+			name:   "mermaid",
+			golden: "export.mmd",
+			write: func(w *bytes.Buffer, schemaGraph *TableGraph) error {
+				return WriteMermaid(w, schemaGraph, ExportOptions{})
+			},
+		},
+		{
+			name:   "focused dot",
+			golden: "export_focus.dot",
+			write: func(w *bytes.Buffer, schemaGraph *TableGraph) error {
+				focused, err := FocusTableGraph(schemaGraph, []string{"accounts"}, 1)
+				if err != nil {
+					return err
+				}
+				return WriteDOT(w, focused, ExportOptions{})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schemaGraph, err := BuildSQLTableGraph(exportSchema)
+			if err != nil {
+				t.Fatalf("BuildSQLTableGraph() error = %v", err)
+			}
+			var got bytes.Buffer
+			if err := tt.write(&got, schemaGraph); err != nil {
+				t.Fatalf("export error = %v", err)
+			}
+
+			path := filepath.Join("testdata", tt.golden)
+			if *update {
+				if err := os.WriteFile(path, got.Bytes(), 0o644); err != nil {
+					t.Fatalf("unable to write golden file: %v", err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("unable to read golden file: %v", err)
+			}
+			if diff := cmp.Diff(string(want), got.String()); diff != "" {
+				t.Errorf("output differs from %s (-want +got):\n%s", path, diff)
+			}
+		})
+	}
+}
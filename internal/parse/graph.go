@@ -2,8 +2,10 @@ package parse
 
 import (
 	"fmt"
-	"go-integral/internal/graph"
-	"go-integral/internal/parse/nodes"
+	"github.com/tab58/go-integral/internal/graph"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"maps"
+	"slices"
 )
 
 type TableDependencyNode struct {
@@ -17,23 +19,34 @@ type TableDependency struct {
 	ToNode   TableDependencyNode
 }
 
+// TableGraph is the graph of tables, with the table names as node IDs
+type TableGraph = graph.DirectedGraph[nodes.Table, TableDependency]
+
 func BuildSQLTableGraph(sqlSchema string) (*graph.DirectedGraph[nodes.Table, TableDependency], error) {
 	schema, err := nodes.NewPostgreSQLSchema(sqlSchema)
 	if err != nil {
 		return nil, fmt.Errorf("unable to parse SQL schema: %w", err)
 	}
+	return BuildTableGraph(schema)
+}
 
+// BuildTableGraph builds the graph of tables of an already parsed schema, with an edge from each referenced table to
+// the tables referencing it
+func BuildTableGraph(schema *nodes.PostgreSQLSchema) (*graph.DirectedGraph[nodes.Table, TableDependency], error) {
 	schemaGraph := graph.NewDirectedGraph[nodes.Table, TableDependency]()
 
-	// add nodes in the graph
-	tableNodes := make(map[string]*graph.Node[nodes.Table])
-	for tableName, table := range schema.Tables {
-		tableNodes[tableName] = schemaGraph.AddNode(table)
+	// add nodes in the graph, keyed and sorted by table name so that the graph is built deterministically
+	tableNames := slices.Sorted(maps.Keys(schema.Tables))
+	for _, tableName := range tableNames {
+		if _, err := schemaGraph.AddNodeWithID(tableName, schema.Tables[tableName]); err != nil {
+			return nil, err
+		}
 	}
-	for tableName, table := range schema.Tables {
+	for _, tableName := range tableNames {
+		table := schema.Tables[tableName]
 		for _, constraint := range table.Constraints {
 			if constraint.Type == nodes.ConstraintInfoTypeForeignKey {
-				dependency, err := buildGraphTableEdge(constraint, tableName, tableNodes)
+				dependency, err := buildGraphTableEdge(constraint, tableName, schemaGraph)
 				if err != nil {
 					return nil, fmt.Errorf("could not build table graph edge: %w", err)
 				}
@@ -46,14 +59,17 @@ func BuildSQLTableGraph(sqlSchema string) (*graph.DirectedGraph[nodes.Table, Tab
 	return schemaGraph, nil
 }
 
-func buildGraphTableEdge(fk nodes.TableConstraint, tableName string, tableNodes map[string]*graph.Node[nodes.Table]) (TableDependency, error) {
+func buildGraphTableEdge(fk nodes.TableConstraint, tableName string, schemaGraph *TableGraph) (TableDependency, error) {
 	info, ok := fk.Constraint.(*nodes.ForeignKeyConstraintInfo)
 	if !ok {
 		return TableDependency{}, fmt.Errorf("table constraint cannot be converted to a foreign key constraint")
 	}
 
-	fromNode := tableNodes[info.ForeignKeyTableName]
-	toNode := tableNodes[tableName]
+	fromNode, ok := schemaGraph.Node(info.ForeignKeyTableName)
+	if !ok {
+		return TableDependency{}, fmt.Errorf("table %q references unknown table %q", tableName, info.ForeignKeyTableName)
+	}
+	toNode, _ := schemaGraph.Node(tableName)
 
 	fromTable := info.ForeignKeyTableName
 	fromColumn := info.ForeignKeyColumnName
@@ -0,0 +1,458 @@
+package nodes
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+)
+
+func (s *PostgreSQLSchema) applyAlterTable(stmt *pg_query.AlterTableStmt) error {
+	if stmt.Objtype != pg_query.ObjectType_OBJECT_TABLE {
+		slog.Info(fmt.Sprintf("skipping alter statement of type %s", stmt.Objtype))
+		return nil
+	}
+
+	tableName := stmt.Relation.Relname
+	table, ok := s.Tables[tableName]
+	if !ok {
+		if stmt.MissingOk {
+			return nil
+		}
+		return fmt.Errorf("cannot alter unknown table %s", tableName)
+	}
+
+	for _, cmdNode := range stmt.Cmds {
+		cmd, ok := cmdNode.Node.(*pg_query.Node_AlterTableCmd)
+		if !ok {
+			return fmt.Errorf("unknown alter table command: %+v", cmdNode.Node)
+		}
+		if err := applyAlterTableCmd(&table, cmd.AlterTableCmd); err != nil {
+			return fmt.Errorf("cannot alter table %s: %w", tableName, err)
+		}
+	}
+
+	if err := table.refreshPrimaryKey(); err != nil {
+		return err
+	}
+	s.Tables[tableName] = table
+	return nil
+}
+
+func applyAlterTableCmd(table *Table, cmd *pg_query.AlterTableCmd) error {
+	switch cmd.Subtype {
+	case pg_query.AlterTableType_AT_AddColumn:
+		colDef, ok := cmd.Def.Node.(*pg_query.Node_ColumnDef)
+		if !ok {
+			return fmt.Errorf("column definition expected")
+		}
+		if table.columnIndex(colDef.ColumnDef.Colname) >= 0 {
+			if cmd.MissingOk {
+				return nil
+			}
+			return fmt.Errorf("column %s already exists", colDef.ColumnDef.Colname)
+		}
+		col, err := ParsePGColumnDefinition(colDef)
+		if err != nil {
+			return err
+		}
+		fkConstraints, err := ParsePGColumnForeignKeyConstraints(colDef)
+		if err != nil {
+			return err
+		}
+		table.Columns = append(table.Columns, col)
+		for i := range fkConstraints {
+			nameForeignKey(table.Name, fkConstraints[i:i+1], table.Constraints)
+			table.Constraints = append(table.Constraints, fkConstraints[i])
+		}
+	case pg_query.AlterTableType_AT_DropColumn:
+		i := table.columnIndex(cmd.Name)
+		if i < 0 {
+			if cmd.MissingOk {
+				return nil
+			}
+			return fmt.Errorf("column %s does not exist", cmd.Name)
+		}
+		table.Columns = slices.Delete(table.Columns, i, i+1)
+		// a foreign key is dropped as a whole, along with the entries of its other columns
+		var foreignKeys []string
+		for _, constraint := range table.Constraints {
+			if _, ok := constraint.Constraint.(*ForeignKeyConstraintInfo); ok && constraint.Name != "" && constraintUsesColumn(constraint, cmd.Name) {
+				foreignKeys = append(foreignKeys, constraint.Name)
+			}
+		}
+		table.Constraints = slices.DeleteFunc(table.Constraints, func(constraint TableConstraint) bool {
+			_, isForeignKey := constraint.Constraint.(*ForeignKeyConstraintInfo)
+			return constraintUsesColumn(constraint, cmd.Name) || isForeignKey && slices.Contains(foreignKeys, constraint.Name)
+		})
+	case pg_query.AlterTableType_AT_AddConstraint:
+		constraintNode, ok := cmd.Def.Node.(*pg_query.Node_Constraint)
+		if !ok {
+			return fmt.Errorf("constraint definition expected")
+		}
+		constraints, err := ParsePGTableConstraints(constraintNode)
+		if err != nil {
+			return err
+		}
+		if constraintNode.Constraint.Contype == pg_query.ConstrType_CONSTR_FOREIGN {
+			nameForeignKey(table.Name, constraints, table.Constraints)
+		}
+		table.Constraints = append(table.Constraints, constraints...)
+	case pg_query.AlterTableType_AT_DropConstraint:
+		before := len(table.Constraints)
+		table.Constraints = slices.DeleteFunc(table.Constraints, func(constraint TableConstraint) bool {
+			return constraint.Name == cmd.Name
+		})
+		if len(table.Constraints) == before && !cmd.MissingOk {
+			slog.Info(fmt.Sprintf("constraint %s of table %s is not tracked, nothing to drop", cmd.Name, table.Name))
+		}
+	case pg_query.AlterTableType_AT_AlterColumnType:
+		i := table.columnIndex(cmd.Name)
+		if i < 0 {
+			return fmt.Errorf("column %s does not exist", cmd.Name)
+		}
+		colDef, ok := cmd.Def.Node.(*pg_query.Node_ColumnDef)
+		if !ok {
+			return fmt.Errorf("column definition expected")
+		}
+		dataType := ParsePGTypeName(colDef.ColumnDef.TypeName)
+		if len(colDef.ColumnDef.TypeName.ArrayBounds) > 0 {
+			dataType = dataType + "[]"
+		}
+		table.Columns[i].DataType = dataType
+		table.Columns[i].TypeModifiers = ParsePGTypeModifiers(colDef.ColumnDef.TypeName)
+	case pg_query.AlterTableType_AT_SetNotNull:
+		i := table.columnIndex(cmd.Name)
+		if i < 0 {
+			return fmt.Errorf("column %s does not exist", cmd.Name)
+		}
+		if !table.Columns[i].hasConstraint(ConstraintInfoTypeNotNull) {
+			table.Columns[i].Constraints = append(table.Columns[i].Constraints, ColumnConstraint{Type: ConstraintInfoTypeNotNull})
+		}
+		// a DEFAULT NULL only says the column is nullable, which it no longer is
+		table.Columns[i].Constraints = slices.DeleteFunc(table.Columns[i].Constraints, func(constraint ColumnConstraint) bool {
+			return constraint.Type == ConstraintInfoTypeDefault && constraint.ExpressionValue == "NULL"
+		})
+	case pg_query.AlterTableType_AT_DropNotNull:
+		i := table.columnIndex(cmd.Name)
+		if i < 0 {
+			return fmt.Errorf("column %s does not exist", cmd.Name)
+		}
+		table.Columns[i].removeConstraints(ConstraintInfoTypeNotNull)
+	case pg_query.AlterTableType_AT_ColumnDefault:
+		i := table.columnIndex(cmd.Name)
+		if i < 0 {
+			return fmt.Errorf("column %s does not exist", cmd.Name)
+		}
+		table.Columns[i].removeConstraints(ConstraintInfoTypeDefault)
+		if cmd.Def != nil {
+			value := deparseExpression(cmd.Def)
+			if e, ok := cmd.Def.Node.(*pg_query.Node_AConst); ok {
+				value = ParsePGAConst(e)
+			}
+			table.Columns[i].Constraints = append(table.Columns[i].Constraints, ColumnConstraint{
+				Type:            ConstraintInfoTypeDefault,
+				ExpressionValue: value,
+			})
+		}
+	default:
+		slog.Info(fmt.Sprintf("skipping alter table command %s", cmd.Subtype))
+	}
+	return nil
+}
+
+func (s *PostgreSQLSchema) applyRename(stmt *pg_query.RenameStmt) error {
+	switch stmt.RenameType {
+	case pg_query.ObjectType_OBJECT_TABLE:
+		oldName := stmt.Relation.Relname
+		table, ok := s.Tables[oldName]
+		if !ok {
+			if stmt.MissingOk {
+				return nil
+			}
+			return fmt.Errorf("cannot rename unknown table %s", oldName)
+		}
+		table.Name = stmt.Newname
+		delete(s.Tables, oldName)
+		s.Tables[stmt.Newname] = table
+		s.forEachForeignKey(func(_ string, info *ForeignKeyConstraintInfo) {
+			if info.ForeignKeyTableName == oldName {
+				info.ForeignKeyTableName = stmt.Newname
+			}
+		})
+	case pg_query.ObjectType_OBJECT_COLUMN:
+		tableName := stmt.Relation.Relname
+		table, ok := s.Tables[tableName]
+		if !ok {
+			if stmt.MissingOk {
+				return nil
+			}
+			return fmt.Errorf("cannot rename column of unknown table %s", tableName)
+		}
+		i := table.columnIndex(stmt.Subname)
+		if i < 0 {
+			return fmt.Errorf("column %s of table %s does not exist", stmt.Subname, tableName)
+		}
+		table.Columns[i].Name = stmt.Newname
+		for j, pkColumn := range table.PrimaryKey {
+			if pkColumn == stmt.Subname {
+				table.PrimaryKey[j] = stmt.Newname
+			}
+		}
+		for _, constraint := range table.Constraints {
+			var columnNames []string
+			switch info := constraint.Constraint.(type) {
+			case *PrimaryKeyConstraintInfo:
+				columnNames = info.ColumnNames
+			case *UniqueConstraintInfo:
+				columnNames = info.ColumnNames
+			}
+			for j, columnName := range columnNames {
+				if columnName == stmt.Subname {
+					columnNames[j] = stmt.Newname
+				}
+			}
+		}
+		s.Tables[tableName] = table
+		s.forEachForeignKey(func(fkTableName string, info *ForeignKeyConstraintInfo) {
+			if fkTableName == tableName && info.TableColumnName == stmt.Subname {
+				info.TableColumnName = stmt.Newname
+			}
+			if info.ForeignKeyTableName == tableName && info.ForeignKeyColumnName == stmt.Subname {
+				info.ForeignKeyColumnName = stmt.Newname
+			}
+		})
+	case pg_query.ObjectType_OBJECT_TABCONSTRAINT:
+		tableName := stmt.Relation.Relname
+		if table, ok := s.Tables[tableName]; ok {
+			for i, constraint := range table.Constraints {
+				if constraint.Name == stmt.Subname {
+					table.Constraints[i].Name = stmt.Newname
+				}
+			}
+		}
+	default:
+		slog.Info(fmt.Sprintf("skipping rename statement of type %s", stmt.RenameType))
+	}
+	return nil
+}
+
+func (s *PostgreSQLSchema) applyAlterEnum(stmt *pg_query.AlterEnumStmt) error {
+	name := lastName(stmt.TypeName)
+	labels, ok := s.Enums[name]
+	if !ok {
+		return fmt.Errorf("cannot alter unknown type %s", name)
+	}
+
+	// ALTER TYPE ... RENAME VALUE
+	if stmt.OldVal != "" {
+		i := slices.Index(labels, stmt.OldVal)
+		if i < 0 {
+			return fmt.Errorf("%s is not a value of type %s", stmt.OldVal, name)
+		}
+		labels[i] = stmt.NewVal
+		return nil
+	}
+
+	// ALTER TYPE ... ADD VALUE
+	if slices.Contains(labels, stmt.NewVal) {
+		if stmt.SkipIfNewValExists {
+			return nil
+		}
+		return fmt.Errorf("%s is already a value of type %s", stmt.NewVal, name)
+	}
+	i := len(labels)
+	if stmt.NewValNeighbor != "" {
+		i = slices.Index(labels, stmt.NewValNeighbor)
+		if i < 0 {
+			return fmt.Errorf("%s is not a value of type %s", stmt.NewValNeighbor, name)
+		}
+		if stmt.NewValIsAfter {
+			i++
+		}
+	}
+	s.Enums[name] = slices.Insert(labels, i, stmt.NewVal)
+	return nil
+}
+
+func (s *PostgreSQLSchema) applyDrop(stmt *pg_query.DropStmt) error {
+	if stmt.RemoveType == pg_query.ObjectType_OBJECT_TYPE {
+		for _, object := range stmt.Objects {
+			typeName, ok := object.Node.(*pg_query.Node_TypeName)
+			if !ok {
+				return fmt.Errorf("unknown drop type object: %+v", object.Node)
+			}
+			delete(s.Enums, ParsePGTypeName(typeName.TypeName))
+		}
+		return nil
+	}
+	if stmt.RemoveType == pg_query.ObjectType_OBJECT_INDEX {
+		for _, object := range stmt.Objects {
+			list, ok := object.Node.(*pg_query.Node_List)
+			if !ok || len(list.List.Items) == 0 {
+				return fmt.Errorf("unknown drop index object: %+v", object.Node)
+			}
+			nameNode, ok := list.List.Items[len(list.List.Items)-1].Node.(*pg_query.Node_String_)
+			if !ok {
+				return fmt.Errorf("unknown drop index object: %+v", object.Node)
+			}
+			// only unique indexes are kept, as the unique constraints of their table
+			for name, table := range s.Tables {
+				table.Constraints = slices.DeleteFunc(table.Constraints, func(constraint TableConstraint) bool {
+					_, ok := constraint.Constraint.(*UniqueConstraintInfo)
+					return ok && constraint.Name == nameNode.String_.Sval
+				})
+				s.Tables[name] = table
+			}
+		}
+		return nil
+	}
+	if stmt.RemoveType != pg_query.ObjectType_OBJECT_TABLE {
+		slog.Info(fmt.Sprintf("skipping drop statement of type %s", stmt.RemoveType))
+		return nil
+	}
+
+	dropped := make([]string, 0, len(stmt.Objects))
+	for _, object := range stmt.Objects {
+		list, ok := object.Node.(*pg_query.Node_List)
+		if !ok || len(list.List.Items) == 0 {
+			return fmt.Errorf("unknown drop table object: %+v", object.Node)
+		}
+		// the name may be schema-qualified, the table name is always the last item
+		nameNode, ok := list.List.Items[len(list.List.Items)-1].Node.(*pg_query.Node_String_)
+		if !ok {
+			return fmt.Errorf("unknown drop table object: %+v", object.Node)
+		}
+		tableName := nameNode.String_.Sval
+		if _, ok := s.Tables[tableName]; !ok {
+			if stmt.MissingOk {
+				continue
+			}
+			return fmt.Errorf("cannot drop unknown table %s", tableName)
+		}
+		dropped = append(dropped, tableName)
+	}
+
+	// the tables dropped together can reference each other, while the foreign keys of the other tables referencing
+	// them are only removed by DROP ... CASCADE
+	if stmt.Behavior != pg_query.DropBehavior_DROP_CASCADE {
+		for _, name := range slices.Sorted(maps.Keys(s.Tables)) {
+			if slices.Contains(dropped, name) {
+				continue
+			}
+			for _, foreignKey := range s.Tables[name].ForeignKeys() {
+				if slices.Contains(dropped, foreignKey.ReferencedTable) {
+					return fmt.Errorf("cannot drop table %s because foreign key %s of table %s references it, use DROP TABLE ... CASCADE", foreignKey.ReferencedTable, foreignKey.Name, name)
+				}
+			}
+		}
+	}
+	for _, tableName := range dropped {
+		delete(s.Tables, tableName)
+	}
+	for name, table := range s.Tables {
+		table.Constraints = slices.DeleteFunc(table.Constraints, func(constraint TableConstraint) bool {
+			info, ok := constraint.Constraint.(*ForeignKeyConstraintInfo)
+			return ok && slices.Contains(dropped, info.ForeignKeyTableName)
+		})
+		s.Tables[name] = table
+	}
+	return nil
+}
+
+// applyCreateIndex adds the unique indexes on plain columns to the unique constraints of their table. The other
+// indexes, and the partial and expression unique indexes, which don't make the values of columns unique, are skipped.
+func (s *PostgreSQLSchema) applyCreateIndex(stmt *pg_query.IndexStmt) error {
+	if !stmt.Unique || stmt.WhereClause != nil {
+		slog.Info("skipping index stmt")
+		return nil
+	}
+	columnNames := make([]string, 0, len(stmt.IndexParams))
+	for _, param := range stmt.IndexParams {
+		elem, ok := param.Node.(*pg_query.Node_IndexElem)
+		if !ok || elem.IndexElem.Name == "" {
+			slog.Info(fmt.Sprintf("skipping unique index %s on an expression", stmt.Idxname))
+			return nil
+		}
+		columnNames = append(columnNames, elem.IndexElem.Name)
+	}
+
+	tableName := stmt.Relation.Relname
+	table, ok := s.Tables[tableName]
+	if !ok {
+		return fmt.Errorf("cannot create index on unknown table %s", tableName)
+	}
+	for _, columnName := range columnNames {
+		if table.columnIndex(columnName) < 0 {
+			return fmt.Errorf("column %s of table %s does not exist", columnName, tableName)
+		}
+	}
+	if stmt.Idxname != "" && slices.ContainsFunc(table.Constraints, func(constraint TableConstraint) bool {
+		return constraint.Name == stmt.Idxname
+	}) {
+		if stmt.IfNotExists {
+			return nil
+		}
+		return fmt.Errorf("index %s already exists", stmt.Idxname)
+	}
+	table.Constraints = append(table.Constraints, TableConstraint{
+		Name:       stmt.Idxname,
+		Type:       ConstraintInfoTypeUnique,
+		Constraint: &UniqueConstraintInfo{ColumnNames: columnNames},
+	})
+	s.Tables[tableName] = table
+	return nil
+}
+
+func (s *PostgreSQLSchema) forEachForeignKey(fn func(tableName string, info *ForeignKeyConstraintInfo)) {
+	for tableName, table := range s.Tables {
+		for _, constraint := range table.Constraints {
+			if info, ok := constraint.Constraint.(*ForeignKeyConstraintInfo); ok {
+				fn(tableName, info)
+			}
+		}
+	}
+}
+
+func constraintUsesColumn(constraint TableConstraint, columnName string) bool {
+	switch info := constraint.Constraint.(type) {
+	case *ForeignKeyConstraintInfo:
+		return info.TableColumnName == columnName
+	case *PrimaryKeyConstraintInfo:
+		return slices.Contains(info.ColumnNames, columnName)
+	case *UniqueConstraintInfo:
+		return slices.Contains(info.ColumnNames, columnName)
+	}
+	return false
+}
+
+func (t *Table) columnIndex(columnName string) int {
+	return slices.IndexFunc(t.Columns, func(column Column) bool {
+		return column.Name == columnName
+	})
+}
+
+func (c *Column) hasConstraint(constraintType ConstraintInfoType) bool {
+	return slices.ContainsFunc(c.Constraints, func(constraint ColumnConstraint) bool {
+		return constraint.Type == constraintType
+	})
+}
+
+func (c *Column) removeConstraints(constraintType ConstraintInfoType) {
+	c.Constraints = slices.DeleteFunc(c.Constraints, func(constraint ColumnConstraint) bool {
+		return constraint.Type == constraintType
+	})
+}
+
+// lastName returns the last part of a possibly schema-qualified name
+func lastName(names []*pg_query.Node) string {
+	if len(names) == 0 {
+		return ""
+	}
+	if name, ok := names[len(names)-1].Node.(*pg_query.Node_String_); ok {
+		return name.String_.Sval
+	}
+	return ""
+}
@@ -0,0 +1,79 @@
+package nodes
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDropTable(t *testing.T) {
+	const tables = `CREATE TABLE users (id int PRIMARY KEY, manager_id int REFERENCES users (id));
+CREATE TABLE posts (id int PRIMARY KEY, author_id int REFERENCES users (id));
+CREATE TABLE tags (id int PRIMARY KEY);
+`
+	tests := []struct {
+		name string
+		sql  string
+		// want are the names of the foreign keys of each remaining table
+		want    map[string][]string
+		wantErr string
+	}{
+		{
+			name:    "referenced table",
+			sql:     "DROP TABLE users;",
+			wantErr: "cannot drop table users because foreign key posts_author_id_fkey of table posts references it",
+		},
+		{
+			name: "referenced table with cascade",
+			sql:  "DROP TABLE users CASCADE;",
+			want: map[string][]string{"posts": {}, "tags": {}},
+		},
+		{
+			name: "referenced table dropped with the tables referencing it",
+			sql:  "DROP TABLE users, posts;",
+			want: map[string][]string{"tags": {}},
+		},
+		{
+			name: "table referencing another",
+			sql:  "DROP TABLE posts;",
+			want: map[string][]string{"users": {"users_manager_id_fkey"}, "tags": {}},
+		},
+		{
+			name: "missing table",
+			sql:  "DROP TABLE IF EXISTS comments, tags;",
+			want: map[string][]string{"users": {"users_manager_id_fkey"}, "posts": {"posts_author_id_fkey"}},
+		},
+		{
+			name:    "unknown table",
+			sql:     "DROP TABLE comments;",
+			wantErr: "cannot drop unknown table comments",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := NewPostgreSQLSchema(tables + tt.sql)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("NewPostgreSQLSchema() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewPostgreSQLSchema() error = %v", err)
+			}
+			got := make(map[string][]string)
+			for _, name := range slices.Sorted(maps.Keys(schema.Tables)) {
+				got[name] = make([]string, 0)
+				for _, foreignKey := range schema.Tables[name].ForeignKeys() {
+					got[name] = append(got[name], foreignKey.Name)
+				}
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("foreign keys mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
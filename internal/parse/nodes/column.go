@@ -2,6 +2,7 @@ package nodes
 
 import (
 	"fmt"
+	"strings"
 
 	pg_query "github.com/pganalyze/pg_query_go/v6"
 )
@@ -12,9 +13,11 @@ type ColumnConstraint struct {
 }
 
 type Column struct {
-	Name        string             `json:"name"`
-	DataType    string             `json:"data_type"`
-	Constraints []ColumnConstraint `json:"constraints"`
+	Name     string `json:"name"`
+	DataType string `json:"data_type"`
+	// TypeModifiers are the parameters of the data type, e.g. [255] for varchar(255) or [10, 2] for numeric(10, 2)
+	TypeModifiers []int32            `json:"type_modifiers,omitempty"`
+	Constraints   []ColumnConstraint `json:"constraints"`
 }
 
 func ParsePGColumnDefinition(colDef *pg_query.Node_ColumnDef) (Column, error) {
@@ -25,28 +28,18 @@ func ParsePGColumnDefinition(colDef *pg_query.Node_ColumnDef) (Column, error) {
 		return Column{}, err
 	}
 	return Column{
-		Name:        colName,
-		DataType:    colTypeString,
-		Constraints: colConstraints,
+		Name:          colName,
+		DataType:      colTypeString,
+		TypeModifiers: ParsePGTypeModifiers(colDef.ColumnDef.TypeName),
+		Constraints:   colConstraints,
 	}, nil
 }
 
 func ParsePGColumnDataType(colDef *pg_query.Node_ColumnDef) string {
 	def := colDef.ColumnDef
-	colType := def.TypeName
-
-	var colTypeString string = "unknown"
-
-	colTypeNames := colType.Names
-	for _, colTypeName := range colTypeNames {
-		switch t := colTypeName.Node.(type) {
-		case *pg_query.Node_TypeName:
-			colTypeString = t.TypeName.String()
-		case *pg_query.Node_AConst:
-			colTypeString = t.AConst.String()
-		case *pg_query.Node_String_:
-			colTypeString = t.String_.Sval
-		}
+	colTypeString := ParsePGTypeName(def.TypeName)
+	if len(def.TypeName.ArrayBounds) > 0 {
+		colTypeString = colTypeString + "[]"
 	}
 
 	consts, err := getColumnConstraints(colDef)
@@ -58,7 +51,7 @@ func ParsePGColumnDataType(colDef *pg_query.Node_ColumnDef) string {
 		if cons.Type == ConstraintInfoTypeDefault {
 			// TODO: this is a HACK to detect arrays because pg_query_go doesn't recognize them
 			// This should be removed once pg_query_go recognizes arrays
-			if cons.ExpressionValue == "{}" {
+			if cons.ExpressionValue == "{}" && !strings.HasSuffix(colTypeString, "[]") {
 				colTypeString = colTypeString + "[]"
 			}
 		}
@@ -67,43 +60,46 @@ func ParsePGColumnDataType(colDef *pg_query.Node_ColumnDef) string {
 	return colTypeString
 }
 
-func getColumnConstraints(colDef *pg_query.Node_ColumnDef) ([]ColumnConstraint, error) {
-	var colConstraints []ColumnConstraint
+// ParsePGTypeName returns the unqualified name of a type, e.g. int4 for pg_catalog.int4
+func ParsePGTypeName(typeName *pg_query.TypeName) string {
+	var typeString string = "unknown"
 
-	colDefConstraints := colDef.ColumnDef.Constraints
-	for _, cons := range colDefConstraints {
-		if node := cons.Node.(*pg_query.Node_Constraint); node != nil {
-			constraint := node.Constraint
+	for _, name := range typeName.Names {
+		switch t := name.Node.(type) {
+		case *pg_query.Node_TypeName:
+			typeString = t.TypeName.String()
+		case *pg_query.Node_AConst:
+			typeString = t.AConst.String()
+		case *pg_query.Node_String_:
+			typeString = t.String_.Sval
+		}
+	}
+	return typeString
+}
 
-			constraintExprValue := ""
-			constraintType := ConstraintInfoTypeUnknown
-			switch typ := constraint.Contype.String(); typ {
-			case "CONSTR_PRIMARY":
-				constraintType = ConstraintInfoTypePrimaryKey
-			case "CONSTR_UNIQUE":
-				constraintType = ConstraintInfoTypeUnique
-			case "CONSTR_DEFAULT":
-				exprNode := constraint.RawExpr.Node
-				if e, ok := exprNode.(*pg_query.Node_AConst); ok {
-					if e.AConst.Isnull {
-						constraintExprValue = "NULL"
-					} else {
-						value := e.AConst.GetSval().GetSval()
-						constraintExprValue = value
-					}
-				}
-				constraintType = ConstraintInfoTypeDefault
-			default:
-				constraintType = ConstraintInfoType(typ)
+// ParsePGTypeModifiers returns the integer parameters of a type, e.g. [10, 2] for numeric(10, 2)
+func ParsePGTypeModifiers(typeName *pg_query.TypeName) []int32 {
+	var modifiers []int32
+	for _, typmod := range typeName.Typmods {
+		if c, ok := typmod.Node.(*pg_query.Node_AConst); ok {
+			if ival := c.AConst.GetIval(); ival != nil {
+				modifiers = append(modifiers, ival.Ival)
 			}
+		}
+	}
+	return modifiers
+}
 
-			colConstraints = append(colConstraints, ColumnConstraint{
-				Type:            constraintType,
-				ExpressionValue: constraintExprValue,
-			})
-		} else {
+func getColumnConstraints(colDef *pg_query.Node_ColumnDef) ([]ColumnConstraint, error) {
+	colConstraints := make([]ColumnConstraint, 0)
+	for _, cons := range colDef.ColumnDef.Constraints {
+		node, ok := cons.Node.(*pg_query.Node_Constraint)
+		if !ok {
 			return nil, fmt.Errorf("unknown constraint type: %+v", cons.Node)
 		}
+		if constraint, ok := parseColumnConstraint(node.Constraint); ok {
+			colConstraints = append(colConstraints, constraint)
+		}
 	}
 	return colConstraints, nil
 }
@@ -3,35 +3,49 @@ package nodes
 import (
 	"fmt"
 	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
 
 	pg_query "github.com/pganalyze/pg_query_go/v6"
 )
 
 func ParsePGColumnConstraint(constraintNode *pg_query.Node_Constraint) (ColumnConstraint, error) {
-	constraint := constraintNode.Constraint
+	constraint, ok := parseColumnConstraint(constraintNode.Constraint)
+	if !ok {
+		slog.Info(fmt.Sprintf("adding unknown constraint type %s", constraintNode.Constraint.Contype))
+		return ColumnConstraint{Type: ConstraintInfoTypeUnknown}, nil
+	}
+	return constraint, nil
+}
 
-	constraintExprValue := ""
-	constraintType := ConstraintInfoTypeUnknown
-	switch typ := constraint.Contype; typ {
+// parseColumnConstraint converts a constraint of a column definition, returning false for the ones that aren't kept
+// with the column: foreign keys, which are table constraints, explicit NULLs, which columns are by default, and the
+// DEFERRABLE attributes of other constraints
+func parseColumnConstraint(constraint *pg_query.Constraint) (ColumnConstraint, bool) {
+	switch constraint.Contype {
 	case pg_query.ConstrType_CONSTR_PRIMARY:
-		constraintType = ConstraintInfoTypePrimaryKey
+		return ColumnConstraint{Type: ConstraintInfoTypePrimaryKey}, true
 	case pg_query.ConstrType_CONSTR_UNIQUE:
-		constraintType = ConstraintInfoTypeUnique
+		return ColumnConstraint{Type: ConstraintInfoTypeUnique}, true
+	case pg_query.ConstrType_CONSTR_NOTNULL:
+		return ColumnConstraint{Type: ConstraintInfoTypeNotNull}, true
 	case pg_query.ConstrType_CONSTR_DEFAULT:
-		exprNode := constraint.RawExpr.Node
-		if e, ok := exprNode.(*pg_query.Node_AConst); ok {
-			constraintExprValue = ParsePGAConst(e)
+		expression := ""
+		if e, ok := constraint.RawExpr.Node.(*pg_query.Node_AConst); ok {
+			expression = ParsePGAConst(e)
+		} else {
+			expression = deparseExpression(constraint.RawExpr)
 		}
-		constraintType = ConstraintInfoTypeDefault
-	default:
-		slog.Info(fmt.Sprintf("adding unknown constraint type %s", typ))
-		constraintType = ConstraintInfoType(typ)
+		return ColumnConstraint{Type: ConstraintInfoTypeDefault, ExpressionValue: expression}, true
+	case pg_query.ConstrType_CONSTR_CHECK:
+		return ColumnConstraint{Type: ConstraintInfoTypeCheck, ExpressionValue: deparseExpression(constraint.RawExpr)}, true
+	case pg_query.ConstrType_CONSTR_IDENTITY:
+		return ColumnConstraint{Type: ConstraintInfoTypeIdentity}, true
+	case pg_query.ConstrType_CONSTR_GENERATED:
+		return ColumnConstraint{Type: ConstraintInfoTypeGenerated, ExpressionValue: deparseExpression(constraint.RawExpr)}, true
 	}
-
-	return ColumnConstraint{
-		Type:            constraintType,
-		ExpressionValue: constraintExprValue,
-	}, nil
+	return ColumnConstraint{}, false
 }
 
 func ParsePGTableConstraints(constraintNode *pg_query.Node_Constraint) ([]TableConstraint, error) {
@@ -42,6 +56,18 @@ func ParsePGTableConstraints(constraintNode *pg_query.Node_Constraint) ([]TableC
 		return ParsePGTableForeignKeyConstraints(constraint)
 	case pg_query.ConstrType_CONSTR_PRIMARY:
 		return ParsePGTablePrimaryKeyConstraints(constraint)
+	case pg_query.ConstrType_CONSTR_UNIQUE:
+		return ParsePGTableUniqueConstraints(constraint)
+	case pg_query.ConstrType_CONSTR_CHECK:
+		return []TableConstraint{
+			{
+				Name: constraint.Conname,
+				Type: ConstraintInfoTypeCheck,
+				Constraint: &CheckConstraintInfo{
+					Expression: deparseExpression(constraint.RawExpr),
+				},
+			},
+		}, nil
 	default:
 		slog.Info(fmt.Sprintf("unknown constraint type %s", constraint.Contype))
 	}
@@ -56,6 +82,7 @@ func ParsePGTablePrimaryKeyConstraints(constraint *pg_query.Constraint) ([]Table
 	}
 	return []TableConstraint{
 		{
+			Name: constraint.Conname,
 			Type: ConstraintInfoTypePrimaryKey,
 			Constraint: &PrimaryKeyConstraintInfo{
 				ColumnNames: columnNames,
@@ -64,19 +91,40 @@ func ParsePGTablePrimaryKeyConstraints(constraint *pg_query.Constraint) ([]Table
 	}, nil
 }
 
+func ParsePGTableUniqueConstraints(constraint *pg_query.Constraint) ([]TableConstraint, error) {
+	columnNames := make([]string, 0)
+	for _, key := range constraint.Keys {
+		columnNames = append(columnNames, key.Node.(*pg_query.Node_String_).String_.Sval)
+	}
+	return []TableConstraint{
+		{
+			Name: constraint.Conname,
+			Type: ConstraintInfoTypeUnique,
+			Constraint: &UniqueConstraintInfo{
+				ColumnNames: columnNames,
+			},
+		},
+	}, nil
+}
+
 func ParsePGTableForeignKeyConstraints(constraint *pg_query.Constraint) ([]TableConstraint, error) {
 	tableConstraints := make([]TableConstraint, 0)
 	fkAttrs := constraint.FkAttrs
 	pkAttrs := constraint.PkAttrs
-	if len(fkAttrs) != len(pkAttrs) {
+	if len(pkAttrs) != 0 && len(fkAttrs) != len(pkAttrs) {
 		return nil, fmt.Errorf("foreign key and primary key attributes must have the same length")
 	}
 	pkTableName := constraint.Pktable.Relname
 	for i, fkAttr := range constraint.FkAttrs {
-		pkAttr := constraint.PkAttrs[i]
 		fkColumnName := fkAttr.Node.(*pg_query.Node_String_).String_.Sval
-		pkColumnName := pkAttr.Node.(*pg_query.Node_String_).String_.Sval
+		// the referenced columns may be omitted to reference the primary key, which is resolved once the whole
+		// schema is known
+		pkColumnName := ""
+		if len(pkAttrs) != 0 {
+			pkColumnName = pkAttrs[i].Node.(*pg_query.Node_String_).String_.Sval
+		}
 		tableConstraints = append(tableConstraints, TableConstraint{
+			Name: constraint.Conname,
 			Type: ConstraintInfoTypeForeignKey,
 			Constraint: &ForeignKeyConstraintInfo{
 				TableColumnName:      fkColumnName,
@@ -87,3 +135,65 @@ func ParsePGTableForeignKeyConstraints(constraint *pg_query.Constraint) ([]Table
 	}
 	return tableConstraints, nil
 }
+
+// ParsePGColumnForeignKeyConstraints converts the REFERENCES clauses of a column definition to table foreign keys
+func ParsePGColumnForeignKeyConstraints(colDef *pg_query.Node_ColumnDef) ([]TableConstraint, error) {
+	tableConstraints := make([]TableConstraint, 0)
+	for _, cons := range colDef.ColumnDef.Constraints {
+		node, ok := cons.Node.(*pg_query.Node_Constraint)
+		if !ok || node.Constraint.Contype != pg_query.ConstrType_CONSTR_FOREIGN {
+			continue
+		}
+		constraint := node.Constraint
+		if len(constraint.PkAttrs) > 1 {
+			return nil, fmt.Errorf("column %s references more than one column", colDef.ColumnDef.Colname)
+		}
+		pkColumnName := ""
+		if len(constraint.PkAttrs) == 1 {
+			pkColumnName = constraint.PkAttrs[0].Node.(*pg_query.Node_String_).String_.Sval
+		}
+		tableConstraints = append(tableConstraints, TableConstraint{
+			Name: constraint.Conname,
+			Type: ConstraintInfoTypeForeignKey,
+			Constraint: &ForeignKeyConstraintInfo{
+				TableColumnName:      colDef.ColumnDef.Colname,
+				ForeignKeyTableName:  constraint.Pktable.Relname,
+				ForeignKeyColumnName: pkColumnName,
+			},
+		})
+	}
+	return tableConstraints, nil
+}
+
+// maxIdentifierLength is the length PostgreSQL truncates identifiers to
+const maxIdentifierLength = 63
+
+// nameForeignKey names the entries of an unnamed foreign key of a table like PostgreSQL does, <table>_<columns>_fkey
+// with a number appended when the table already has a constraint of that name, so that the columns of a composite
+// foreign key stay grouped by their name
+func nameForeignKey(tableName string, foreignKey []TableConstraint, existing []TableConstraint) {
+	if len(foreignKey) == 0 || foreignKey[0].Name != "" {
+		return
+	}
+	columnNames := make([]string, 0, len(foreignKey))
+	for _, constraint := range foreignKey {
+		if info, ok := constraint.Constraint.(*ForeignKeyConstraintInfo); ok {
+			columnNames = append(columnNames, info.TableColumnName)
+		}
+	}
+	base := tableName + "_" + strings.Join(columnNames, "_")
+	name := ""
+	for n := 0; ; n++ {
+		suffix := "_fkey"
+		if n > 0 {
+			suffix += strconv.Itoa(n)
+		}
+		name = base[:min(len(base), maxIdentifierLength-len(suffix))] + suffix
+		if !slices.ContainsFunc(existing, func(constraint TableConstraint) bool { return constraint.Name == name }) {
+			break
+		}
+	}
+	for i := range foreignKey {
+		foreignKey[i].Name = name
+	}
+}
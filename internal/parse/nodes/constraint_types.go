@@ -9,6 +9,9 @@ const (
 	ConstraintInfoTypeUnique     ConstraintInfoType = "unique"
 	ConstraintInfoTypeDefault    ConstraintInfoType = "default"
 	ConstraintInfoTypeNotNull    ConstraintInfoType = "not_null"
+	ConstraintInfoTypeCheck      ConstraintInfoType = "check"
+	ConstraintInfoTypeIdentity   ConstraintInfoType = "identity"
+	ConstraintInfoTypeGenerated  ConstraintInfoType = "generated"
 )
 
 type ConstraintInfo interface {
@@ -38,3 +41,23 @@ func (c *PrimaryKeyConstraintInfo) ConstraintType() ConstraintInfoType {
 }
 
 // ----------
+
+type UniqueConstraintInfo struct {
+	ColumnNames []string `json:"column_names"`
+}
+
+func (c *UniqueConstraintInfo) ConstraintType() ConstraintInfoType {
+	return ConstraintInfoTypeUnique
+}
+
+// ----------
+
+type CheckConstraintInfo struct {
+	Expression string `json:"expression"`
+}
+
+func (c *CheckConstraintInfo) ConstraintType() ConstraintInfoType {
+	return ConstraintInfoTypeCheck
+}
+
+// ----------
@@ -0,0 +1,149 @@
+package nodes
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaFormatVersion is the version of the serialized schema format. It's bumped whenever a change to the format
+// would break existing consumers.
+const SchemaFormatVersion = 1
+
+// schemaDocument is the serialized form of a schema, with the tables sorted by name so the output is stable
+type schemaDocument struct {
+	Version int                 `json:"version"`
+	Tables  []Table             `json:"tables"`
+	Enums   map[string][]string `json:"enums,omitempty"`
+}
+
+func (s *PostgreSQLSchema) MarshalJSON() ([]byte, error) {
+	doc := schemaDocument{
+		Version: SchemaFormatVersion,
+		Tables:  make([]Table, 0, len(s.Tables)),
+		Enums:   s.Enums,
+	}
+	for _, tableName := range slices.Sorted(maps.Keys(s.Tables)) {
+		doc.Tables = append(doc.Tables, withEmptyLists(s.Tables[tableName]))
+	}
+	return json.Marshal(doc)
+}
+
+// withEmptyLists returns a copy of a table whose nil lists are empty, so that they are written as [] rather than null
+func withEmptyLists(table Table) Table {
+	table.PrimaryKey = emptyIfNil(table.PrimaryKey)
+	table.Constraints = emptyIfNil(table.Constraints)
+	columns := make([]Column, 0, len(table.Columns))
+	for _, column := range table.Columns {
+		column.Constraints = emptyIfNil(column.Constraints)
+		columns = append(columns, column)
+	}
+	table.Columns = columns
+	return table
+}
+
+func emptyIfNil[T any](values []T) []T {
+	if values == nil {
+		return make([]T, 0)
+	}
+	return values
+}
+
+func (s *PostgreSQLSchema) UnmarshalJSON(data []byte) error {
+	var doc schemaDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if doc.Version != SchemaFormatVersion {
+		return fmt.Errorf("unsupported schema format version %d, expected %d", doc.Version, SchemaFormatVersion)
+	}
+
+	tables := make(map[string]Table, len(doc.Tables))
+	for _, table := range doc.Tables {
+		if _, ok := tables[table.Name]; ok {
+			return fmt.Errorf("table %s is defined more than once", table.Name)
+		}
+		tables[table.Name] = table
+	}
+	enums := doc.Enums
+	if enums == nil {
+		enums = make(map[string][]string)
+	}
+
+	s.Tables = tables
+	s.Enums = enums
+	return nil
+}
+
+// MarshalYAML serializes the schema in the same format as JSON, keeping the order of the fields
+func (s *PostgreSQLSchema) MarshalYAML() (any, error) {
+	data, err := s.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	// JSON is valid YAML, so decoding it to a node keeps the field order and only the style needs to change
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	resetYAMLStyle(&doc)
+	return doc.Content[0], nil
+}
+
+func (s *PostgreSQLSchema) UnmarshalYAML(value *yaml.Node) error {
+	var decoded any
+	if err := value.Decode(&decoded); err != nil {
+		return err
+	}
+	data, err := json.Marshal(decoded)
+	if err != nil {
+		return err
+	}
+	return s.UnmarshalJSON(data)
+}
+
+func resetYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetYAMLStyle(child)
+	}
+}
+
+// UnmarshalJSON decodes the constraint info into the concrete type named by the constraint type
+func (c *TableConstraint) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Name       string             `json:"name"`
+		Type       ConstraintInfoType `json:"type"`
+		Constraint json.RawMessage    `json:"constraint"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	var info ConstraintInfo
+	switch raw.Type {
+	case ConstraintInfoTypeForeignKey:
+		info = &ForeignKeyConstraintInfo{}
+	case ConstraintInfoTypePrimaryKey:
+		info = &PrimaryKeyConstraintInfo{}
+	case ConstraintInfoTypeUnique:
+		info = &UniqueConstraintInfo{}
+	case ConstraintInfoTypeCheck:
+		info = &CheckConstraintInfo{}
+	default:
+		return fmt.Errorf("unknown table constraint type %q", raw.Type)
+	}
+	if len(raw.Constraint) > 0 && string(raw.Constraint) != "null" {
+		if err := json.Unmarshal(raw.Constraint, info); err != nil {
+			return fmt.Errorf("invalid %s constraint: %w", raw.Type, err)
+		}
+	}
+
+	c.Name = raw.Name
+	c.Type = raw.Type
+	c.Constraint = info
+	return nil
+}
@@ -0,0 +1,88 @@
+package nodes
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSchemaJSON(t *testing.T) {
+	schema, err := NewPostgreSQLSchema(`
+CREATE TABLE users (id serial PRIMARY KEY);
+CREATE TABLE notes (body text NULL);
+CREATE TABLE products (
+  id int GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+  price numeric NOT NULL DEFAULT 0 CHECK (price >= 0),
+  quantity int,
+  total numeric GENERATED ALWAYS AS (price * quantity) STORED,
+  owner_id int UNIQUE REFERENCES users (id) DEFERRABLE INITIALLY DEFERRED
+);`)
+	if err != nil {
+		t.Fatalf("NewPostgreSQLSchema() error = %v", err)
+	}
+	data, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("MarshalJSON() error = %v", err)
+	}
+
+	var doc struct {
+		Tables []struct {
+			Name             string           `json:"name"`
+			PrimaryKey       []string         `json:"pk"`
+			TableConstraints []map[string]any `json:"table_constraints"`
+			Columns          []struct {
+				Name        string             `json:"name"`
+				Constraints []ColumnConstraint `json:"constraints"`
+			} `json:"columns"`
+		} `json:"tables"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	// every column constraint has one of the normalized types, and the lists are written as [] rather than null
+	known := []ConstraintInfoType{
+		ConstraintInfoTypePrimaryKey, ConstraintInfoTypeUnique, ConstraintInfoTypeDefault, ConstraintInfoTypeNotNull,
+		ConstraintInfoTypeCheck, ConstraintInfoTypeIdentity, ConstraintInfoTypeGenerated,
+	}
+	constraints := make(map[string][]ColumnConstraint)
+	for _, table := range doc.Tables {
+		if table.PrimaryKey == nil || table.TableConstraints == nil {
+			t.Errorf("table %s has a null list", table.Name)
+		}
+		for _, column := range table.Columns {
+			if column.Constraints == nil {
+				t.Errorf("column %s.%s has null constraints", table.Name, column.Name)
+			}
+			for _, constraint := range column.Constraints {
+				if !slices.Contains(known, constraint.Type) {
+					t.Errorf("column %s.%s has a constraint of type %q", table.Name, column.Name, constraint.Type)
+				}
+			}
+			constraints[table.Name+"."+column.Name] = column.Constraints
+		}
+	}
+
+	want := map[string][]ColumnConstraint{
+		"users.id":          {{Type: ConstraintInfoTypePrimaryKey}},
+		"notes.body":        {},
+		"products.id":       {{Type: ConstraintInfoTypeIdentity}, {Type: ConstraintInfoTypePrimaryKey}},
+		"products.price":    {{Type: ConstraintInfoTypeNotNull}, {Type: ConstraintInfoTypeDefault, ExpressionValue: "0"}, {Type: ConstraintInfoTypeCheck, ExpressionValue: "price >= 0"}},
+		"products.quantity": {},
+		"products.total":    {{Type: ConstraintInfoTypeGenerated, ExpressionValue: "price * quantity"}},
+		"products.owner_id": {{Type: ConstraintInfoTypeUnique}},
+	}
+	if diff := cmp.Diff(want, constraints); diff != "" {
+		t.Errorf("column constraints mismatch (-want +got):\n%s", diff)
+	}
+
+	var decoded PostgreSQLSchema
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("UnmarshalJSON() error = %v", err)
+	}
+	if got := decoded.Tables["products"].ForeignKeys(); len(got) != 1 || got[0].Name != "products_owner_id_fkey" {
+		t.Errorf("ForeignKeys() of the decoded schema = %v, want products_owner_id_fkey", got)
+	}
+}
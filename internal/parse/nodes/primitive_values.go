@@ -1,11 +1,41 @@
 package nodes
 
-import pg_query "github.com/pganalyze/pg_query_go/v6"
+import (
+	"strconv"
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+)
 
 func ParsePGAConst(e *pg_query.Node_AConst) string {
 	if e.AConst.Isnull {
 		return "NULL"
 	}
+	switch v := e.AConst.Val.(type) {
+	case *pg_query.A_Const_Ival:
+		return strconv.FormatInt(int64(v.Ival.Ival), 10)
+	case *pg_query.A_Const_Fval:
+		return v.Fval.Fval
+	case *pg_query.A_Const_Boolval:
+		return strconv.FormatBool(v.Boolval.Boolval)
+	case *pg_query.A_Const_Bsval:
+		return v.Bsval.Bsval
+	}
 	value := e.AConst.GetSval().GetSval()
 	return value
 }
+
+// deparseExpression converts an expression back to SQL text, e.g. for CHECK constraints and DEFAULT expressions
+func deparseExpression(expr *pg_query.Node) string {
+	if expr == nil {
+		return ""
+	}
+	stmt := &pg_query.Node{Node: &pg_query.Node_SelectStmt{SelectStmt: &pg_query.SelectStmt{
+		TargetList: []*pg_query.Node{pg_query.MakeResTargetNodeWithVal(expr, 0)},
+	}}}
+	sql, err := pg_query.Deparse(&pg_query.ParseResult{Stmts: []*pg_query.RawStmt{{Stmt: stmt}}})
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(sql, "SELECT ")
+}
@@ -2,39 +2,109 @@ package nodes
 
 import (
 	"fmt"
+	"log/slog"
 
 	pg_query "github.com/pganalyze/pg_query_go/v6"
 )
 
 type PostgreSQLSchema struct {
 	Tables map[string]Table
+	// Enums maps the name of each enum type to its labels, in sort order
+	Enums map[string][]string
 }
 
 func NewPostgreSQLSchema(sqlSchema string) (*PostgreSQLSchema, error) {
+	schema := &PostgreSQLSchema{Tables: make(map[string]Table), Enums: make(map[string][]string)}
+	if err := schema.Apply(sqlSchema); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// Apply parses SQL statements and applies them to the schema in order, so that a schema can be built from a sequence
+// of migrations. Statements that don't change the tables, like INSERT or CREATE INDEX of non-unique indexes, are
+// skipped.
+func (s *PostgreSQLSchema) Apply(sql string) error {
 	// parse the SQL schema text
-	pgResult, err := pg_query.Parse(sqlSchema)
+	pgResult, err := pg_query.Parse(sql)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if s.Tables == nil {
+		s.Tables = make(map[string]Table)
+	}
+	if s.Enums == nil {
+		s.Enums = make(map[string][]string)
 	}
 
 	// parse the result and get the table relationships
-	tables := make(map[string]Table)
 	for _, rawStmt := range pgResult.Stmts {
 		stmt := rawStmt.GetStmt()
 		switch n := stmt.Node.(type) {
 		case *pg_query.Node_CreateStmt:
 			table, err := ParsePGTableCreateStatement(n)
 			if err != nil {
-				return nil, err
+				return err
+			}
+			if _, ok := s.Tables[table.Name]; ok {
+				if n.CreateStmt.IfNotExists {
+					continue
+				}
+				return fmt.Errorf("table %s already exists", table.Name)
+			}
+			s.Tables[table.Name] = table
+		case *pg_query.Node_AlterTableStmt:
+			if err := s.applyAlterTable(n.AlterTableStmt); err != nil {
+				return err
+			}
+		case *pg_query.Node_RenameStmt:
+			if err := s.applyRename(n.RenameStmt); err != nil {
+				return err
+			}
+		case *pg_query.Node_DropStmt:
+			if err := s.applyDrop(n.DropStmt); err != nil {
+				return err
+			}
+		case *pg_query.Node_CreateEnumStmt:
+			name, labels := ParsePGCreateEnumStatement(n)
+			if _, ok := s.Enums[name]; ok {
+				return fmt.Errorf("type %s already exists", name)
+			}
+			s.Enums[name] = labels
+		case *pg_query.Node_AlterEnumStmt:
+			if err := s.applyAlterEnum(n.AlterEnumStmt); err != nil {
+				return err
 			}
-			tables[table.Name] = table
 		case *pg_query.Node_IndexStmt:
-			fmt.Printf("skipping index stmt...\n")
+			if err := s.applyCreateIndex(n.IndexStmt); err != nil {
+				return err
+			}
 		default:
-			return nil, fmt.Errorf("unknown node type: %+v", n)
-
+			slog.Info(fmt.Sprintf("skipping statement of type %T", n))
 		}
 	}
 
-	return &PostgreSQLSchema{Tables: tables}, nil
+	return s.resolveForeignKeys()
+}
+
+// resolveForeignKeys fills in the referenced column of foreign keys that implicitly reference a primary key
+func (s *PostgreSQLSchema) resolveForeignKeys() error {
+	for _, table := range s.Tables {
+		for _, constraint := range table.Constraints {
+			info, ok := constraint.Constraint.(*ForeignKeyConstraintInfo)
+			if !ok || info.ForeignKeyColumnName != "" {
+				continue
+			}
+			referenced, ok := s.Tables[info.ForeignKeyTableName]
+			if !ok {
+				// the referenced table may be created by a later statement
+				continue
+			}
+			if len(referenced.PrimaryKey) != 1 {
+				return fmt.Errorf("%s.%s references table %s, which doesn't have a single column primary key", table.Name, info.TableColumnName, referenced.Name)
+			}
+			info.ForeignKeyColumnName = referenced.PrimaryKey[0]
+		}
+	}
+	return nil
 }
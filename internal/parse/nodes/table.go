@@ -3,17 +3,22 @@ package nodes
 import (
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
 
 	pg_query "github.com/pganalyze/pg_query_go/v6"
 )
 
 type TableConstraint struct {
+	Name       string             `json:"name,omitempty"`
 	Type       ConstraintInfoType `json:"type"`
 	Constraint ConstraintInfo     `json:"constraint"`
 }
 
 type Table struct {
-	Name        string            `json:"name"`
+	Name string `json:"name"`
+	// Schema is the PostgreSQL schema the table belongs to, empty when the table name isn't qualified
+	Schema      string            `json:"schema,omitempty"`
 	PrimaryKey  []string          `json:"pk"`
 	Columns     []Column          `json:"columns"`
 	Constraints []TableConstraint `json:"table_constraints"`
@@ -35,24 +40,148 @@ func ParsePGTableCreateStatement(tableNode *pg_query.Node_CreateStmt) (Table, er
 				return Table{}, err
 			}
 			columns = append(columns, col)
+
+			// inline REFERENCES clauses are foreign keys of the table
+			fkConstraints, err := ParsePGColumnForeignKeyConstraints(t)
+			if err != nil {
+				return Table{}, err
+			}
+			for i := range fkConstraints {
+				nameForeignKey(tableName, fkConstraints[i:i+1], tableConstraints)
+				tableConstraints = append(tableConstraints, fkConstraints[i])
+			}
 		case *pg_query.Node_Constraint:
 			constraint, err := ParsePGTableConstraints(t)
 			if err != nil {
 				return Table{}, err
 			}
+			if t.Constraint.Contype == pg_query.ConstrType_CONSTR_FOREIGN {
+				nameForeignKey(tableName, constraint, tableConstraints)
+			}
 			tableConstraints = append(tableConstraints, constraint...)
 		default:
 			slog.Info("found unknown table element type")
 		}
 	}
 
-	// find out what the primary key is
+	pkColumns, err := primaryKeyColumns(columns, tableConstraints)
+	if err != nil {
+		return Table{}, err
+	}
+
+	return Table{
+		Name:        tableName,
+		Schema:      stmt.Relation.Schemaname,
+		PrimaryKey:  pkColumns,
+		Columns:     columns,
+		Constraints: tableConstraints,
+	}, nil
+}
+
+// IsNotNullColumn returns whether a column of the table can't be null, either because of a NOT NULL constraint or
+// because it's part of the primary key
+func (t Table) IsNotNullColumn(columnName string) bool {
+	if slices.Contains(t.PrimaryKey, columnName) {
+		return true
+	}
+	for _, column := range t.Columns {
+		if column.Name == columnName {
+			return column.hasConstraint(ConstraintInfoTypeNotNull) || column.hasConstraint(ConstraintInfoTypePrimaryKey)
+		}
+	}
+	return false
+}
+
+// IsRequiredColumn returns whether a value must be given for a column of the table when a row is inserted, that is
+// when it can't be null and the database has no value to fill it with
+func (t Table) IsRequiredColumn(columnName string) bool {
+	if !t.IsNotNullColumn(columnName) {
+		return false
+	}
+	for _, column := range t.Columns {
+		if column.Name != columnName {
+			continue
+		}
+		switch strings.ToLower(column.DataType) {
+		case "smallserial", "serial", "bigserial":
+			return false
+		}
+		return !column.hasConstraint(ConstraintInfoTypeDefault) && !column.hasConstraint(ConstraintInfoTypeIdentity) &&
+			!column.hasConstraint(ConstraintInfoTypeGenerated)
+	}
+	return false
+}
+
+// UniqueKeys returns the sets of columns identifying a row of the table: the primary key first, then the columns of
+// the unique constraints and unique indexes, with each set of columns once
+func (t Table) UniqueKeys() [][]string {
+	var keys [][]string
+	add := func(columns []string) {
+		if len(columns) == 0 || slices.ContainsFunc(keys, func(key []string) bool {
+			return slices.Equal(slices.Sorted(slices.Values(key)), slices.Sorted(slices.Values(columns)))
+		}) {
+			return
+		}
+		keys = append(keys, columns)
+	}
+	add(t.PrimaryKey)
+	for _, column := range t.Columns {
+		if column.hasConstraint(ConstraintInfoTypeUnique) {
+			add([]string{column.Name})
+		}
+	}
+	for _, constraint := range t.Constraints {
+		if info, ok := constraint.Constraint.(*UniqueConstraintInfo); ok {
+			add(info.ColumnNames)
+		}
+	}
+	return keys
+}
+
+// ForeignKey is a foreign key of a table, with the columns of a composite foreign key grouped together in order
+type ForeignKey struct {
+	Name              string
+	Columns           []string
+	ReferencedTable   string
+	ReferencedColumns []string
+}
+
+// ForeignKeys returns the foreign keys of the table, grouping the column constraints with the same name. The parser
+// names unnamed foreign keys like PostgreSQL does, so unnamed column constraints only come from schemas built by hand
+// and are each a foreign key of their own.
+func (t Table) ForeignKeys() []ForeignKey {
+	foreignKeys := make([]ForeignKey, 0)
+	named := make(map[string]int)
+	for _, constraint := range t.Constraints {
+		info, ok := constraint.Constraint.(*ForeignKeyConstraintInfo)
+		if !ok {
+			continue
+		}
+		groupKey := constraint.Name + "\x00" + info.ForeignKeyTableName
+		if i, ok := named[groupKey]; ok && constraint.Name != "" {
+			foreignKeys[i].Columns = append(foreignKeys[i].Columns, info.TableColumnName)
+			foreignKeys[i].ReferencedColumns = append(foreignKeys[i].ReferencedColumns, info.ForeignKeyColumnName)
+			continue
+		}
+		named[groupKey] = len(foreignKeys)
+		foreignKeys = append(foreignKeys, ForeignKey{
+			Name:              constraint.Name,
+			Columns:           []string{info.TableColumnName},
+			ReferencedTable:   info.ForeignKeyTableName,
+			ReferencedColumns: []string{info.ForeignKeyColumnName},
+		})
+	}
+	return foreignKeys
+}
+
+// primaryKeyColumns finds out what the primary key is, either from a table constraint or from column constraints
+func primaryKeyColumns(columns []Column, tableConstraints []TableConstraint) ([]string, error) {
 	pkColumns := make([]string, 0)
 	for _, tableCon := range tableConstraints {
 		if tableCon.Type == ConstraintInfoTypePrimaryKey {
 			pk, ok := tableCon.Constraint.(*PrimaryKeyConstraintInfo)
 			if !ok {
-				return Table{}, fmt.Errorf("constraint cannot be converted to a primary key constraint")
+				return nil, fmt.Errorf("constraint cannot be converted to a primary key constraint")
 			}
 			pkColumns = append(pkColumns, pk.ColumnNames...)
 		}
@@ -66,11 +195,27 @@ func ParsePGTableCreateStatement(tableNode *pg_query.Node_CreateStmt) (Table, er
 			}
 		}
 	}
+	return pkColumns, nil
+}
 
-	return Table{
-		Name:        tableName,
-		PrimaryKey:  pkColumns,
-		Columns:     columns,
-		Constraints: tableConstraints,
-	}, nil
+// refreshPrimaryKey recomputes the primary key after the columns or constraints of the table changed
+func (t *Table) refreshPrimaryKey() error {
+	pkColumns, err := primaryKeyColumns(t.Columns, t.Constraints)
+	if err != nil {
+		return err
+	}
+	t.PrimaryKey = pkColumns
+	return nil
+}
+
+// ParsePGCreateEnumStatement returns the name and the labels of an enum type
+func ParsePGCreateEnumStatement(enumNode *pg_query.Node_CreateEnumStmt) (string, []string) {
+	stmt := enumNode.CreateEnumStmt
+	labels := make([]string, 0, len(stmt.Vals))
+	for _, val := range stmt.Vals {
+		if label, ok := val.Node.(*pg_query.Node_String_); ok {
+			labels = append(labels, label.String_.Sval)
+		}
+	}
+	return lastName(stmt.TypeName), labels
 }
@@ -0,0 +1,141 @@
+package nodes
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTableForeignKeys(t *testing.T) {
+	tests := []struct {
+		name  string
+		sql   string
+		table string
+		want  []ForeignKey
+	}{
+		{
+			name: "unnamed composite foreign key",
+			sql: `CREATE TABLE accounts (tenant_id int, id int, PRIMARY KEY (tenant_id, id));
+CREATE TABLE invoices (
+  id int PRIMARY KEY,
+  tenant_id int NOT NULL,
+  account_id int NOT NULL,
+  FOREIGN KEY (tenant_id, account_id) REFERENCES accounts (tenant_id, id)
+);`,
+			table: "invoices",
+			want: []ForeignKey{
+				{Name: "invoices_tenant_id_account_id_fkey", Columns: []string{"tenant_id", "account_id"}, ReferencedTable: "accounts", ReferencedColumns: []string{"tenant_id", "id"}},
+			},
+		},
+		{
+			name: "named composite foreign key",
+			sql: `CREATE TABLE accounts (tenant_id int, id int, PRIMARY KEY (tenant_id, id));
+CREATE TABLE invoices (
+  tenant_id int NOT NULL,
+  account_id int NOT NULL,
+  CONSTRAINT invoices_account FOREIGN KEY (tenant_id, account_id) REFERENCES accounts (tenant_id, id)
+);`,
+			table: "invoices",
+			want: []ForeignKey{
+				{Name: "invoices_account", Columns: []string{"tenant_id", "account_id"}, ReferencedTable: "accounts", ReferencedColumns: []string{"tenant_id", "id"}},
+			},
+		},
+		{
+			name: "unnamed column foreign keys to the same table",
+			sql: `CREATE TABLE users (id int PRIMARY KEY);
+CREATE TABLE posts (
+  id int PRIMARY KEY,
+  created_by int REFERENCES users (id),
+  updated_by int REFERENCES users (id)
+);`,
+			table: "posts",
+			want: []ForeignKey{
+				{Name: "posts_created_by_fkey", Columns: []string{"created_by"}, ReferencedTable: "users", ReferencedColumns: []string{"id"}},
+				{Name: "posts_updated_by_fkey", Columns: []string{"updated_by"}, ReferencedTable: "users", ReferencedColumns: []string{"id"}},
+			},
+		},
+		{
+			name: "unnamed foreign keys on the same columns",
+			sql: `CREATE TABLE users (id int PRIMARY KEY);
+CREATE TABLE admins (id int PRIMARY KEY);
+CREATE TABLE posts (
+  author_id int REFERENCES users (id),
+  FOREIGN KEY (author_id) REFERENCES admins (id)
+);`,
+			table: "posts",
+			want: []ForeignKey{
+				{Name: "posts_author_id_fkey", Columns: []string{"author_id"}, ReferencedTable: "users", ReferencedColumns: []string{"id"}},
+				{Name: "posts_author_id_fkey1", Columns: []string{"author_id"}, ReferencedTable: "admins", ReferencedColumns: []string{"id"}},
+			},
+		},
+		{
+			name: "unnamed composite foreign key added by an alter table",
+			sql: `CREATE TABLE accounts (tenant_id int, id int, PRIMARY KEY (tenant_id, id));
+CREATE TABLE invoices (tenant_id int NOT NULL, account_id int NOT NULL);
+ALTER TABLE invoices ADD FOREIGN KEY (tenant_id, account_id) REFERENCES accounts (tenant_id, id);
+ALTER TABLE invoices ADD COLUMN owner_id int REFERENCES accounts (id);`,
+			table: "invoices",
+			want: []ForeignKey{
+				{Name: "invoices_tenant_id_account_id_fkey", Columns: []string{"tenant_id", "account_id"}, ReferencedTable: "accounts", ReferencedColumns: []string{"tenant_id", "id"}},
+				{Name: "invoices_owner_id_fkey", Columns: []string{"owner_id"}, ReferencedTable: "accounts", ReferencedColumns: []string{"id"}},
+			},
+		},
+		{
+			name: "dropped column of a composite foreign key",
+			sql: `CREATE TABLE users (id int PRIMARY KEY);
+CREATE TABLE accounts (tenant_id int, id int, PRIMARY KEY (tenant_id, id));
+CREATE TABLE invoices (
+  tenant_id int NOT NULL,
+  account_id int NOT NULL,
+  owner_id int REFERENCES users (id),
+  FOREIGN KEY (tenant_id, account_id) REFERENCES accounts (tenant_id, id)
+);
+ALTER TABLE invoices DROP COLUMN account_id;`,
+			table: "invoices",
+			want: []ForeignKey{
+				{Name: "invoices_owner_id_fkey", Columns: []string{"owner_id"}, ReferencedTable: "users", ReferencedColumns: []string{"id"}},
+			},
+		},
+		{
+			name: "dropped unnamed foreign key",
+			sql: `CREATE TABLE users (id int PRIMARY KEY);
+CREATE TABLE posts (id int PRIMARY KEY, author_id int REFERENCES users (id));
+ALTER TABLE posts DROP CONSTRAINT posts_author_id_fkey;`,
+			table: "posts",
+			want:  []ForeignKey{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := NewPostgreSQLSchema(tt.sql)
+			if err != nil {
+				t.Fatalf("NewPostgreSQLSchema() error = %v", err)
+			}
+			table, ok := schema.Tables[tt.table]
+			if !ok {
+				t.Fatalf("table %s not found", tt.table)
+			}
+			if diff := cmp.Diff(tt.want, table.ForeignKeys()); diff != "" {
+				t.Errorf("ForeignKeys() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTableForeignKeysUnnamed(t *testing.T) {
+	// unnamed column constraints of a schema built by hand are each a foreign key of their own
+	table := Table{
+		Name: "posts",
+		Constraints: []TableConstraint{
+			{Type: ConstraintInfoTypeForeignKey, Constraint: &ForeignKeyConstraintInfo{TableColumnName: "created_by", ForeignKeyTableName: "users", ForeignKeyColumnName: "id"}},
+			{Type: ConstraintInfoTypeForeignKey, Constraint: &ForeignKeyConstraintInfo{TableColumnName: "updated_by", ForeignKeyTableName: "users", ForeignKeyColumnName: "id"}},
+		},
+	}
+	want := []ForeignKey{
+		{Columns: []string{"created_by"}, ReferencedTable: "users", ReferencedColumns: []string{"id"}},
+		{Columns: []string{"updated_by"}, ReferencedTable: "users", ReferencedColumns: []string{"id"}},
+	}
+	if diff := cmp.Diff(want, table.ForeignKeys()); diff != "" {
+		t.Errorf("ForeignKeys() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,299 @@
+package seeder
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// timeLayouts are the layouts string values of date and time columns are parsed with, in order
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+	"15:04:05.999999999",
+}
+
+// Coerce converts a value, as decoded from JSON, YAML or CSV, to a value of the Go type the database driver expects for
+// a column. enums are the labels of the enum types of the schema.
+func Coerce(column nodes.Column, value any, enums map[string][]string) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	coerced, err := coerceType(strings.ToLower(column.DataType), value, enums)
+	if err != nil {
+		return nil, fmt.Errorf("column %s: %w", column.Name, err)
+	}
+	return coerced, nil
+}
+
+func coerceType(dataType string, value any, enums map[string][]string) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	if elementType, ok := strings.CutSuffix(dataType, "[]"); ok {
+		return coerceArray(elementType, value, enums)
+	}
+	if labels, ok := enums[dataType]; ok {
+		label, err := coerceString(value)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(labels, label) {
+			return nil, fmt.Errorf("%q is not a label of enum %s (%s)", label, dataType, strings.Join(labels, ", "))
+		}
+		return label, nil
+	}
+
+	switch dataType {
+	case "int2", "smallint", "smallserial":
+		return coerceInt(value, 16)
+	case "int4", "int", "integer", "serial":
+		return coerceInt(value, 32)
+	case "int8", "bigint", "bigserial":
+		return coerceInt(value, 64)
+	case "float4", "real", "float8", "double precision", "float":
+		return coerceFloat(value)
+	case "numeric", "decimal", "money":
+		return coerceNumeric(value)
+	case "bool", "boolean":
+		return coerceBool(value)
+	case "date", "time", "timetz", "timestamp", "timestamptz":
+		return coerceTime(value)
+	case "json", "jsonb":
+		return coerceJSON(value)
+	case "bytea":
+		switch v := value.(type) {
+		case []byte:
+			return v, nil
+		case string:
+			return []byte(v), nil
+		}
+		return nil, fmt.Errorf("cannot use %T as bytea", value)
+	case "text", "varchar", "char", "bpchar", "citext", "uuid", "name", "inet", "cidr", "macaddr":
+		return coerceString(value)
+	}
+	// leave the values of other types to the driver
+	return value, nil
+}
+
+func coerceInt(value any, bits int) (int64, error) {
+	var n int64
+	switch v := value.(type) {
+	case int:
+		n = int64(v)
+	case int8:
+		n = int64(v)
+	case int16:
+		n = int64(v)
+	case int32:
+		n = int64(v)
+	case int64:
+		n = v
+	case uint:
+		n = int64(v)
+	case uint8:
+		n = int64(v)
+	case uint16:
+		n = int64(v)
+	case uint32:
+		n = int64(v)
+	case uint64:
+		if v > math.MaxInt64 {
+			return 0, fmt.Errorf("%d is out of range", v)
+		}
+		n = int64(v)
+	case float32, float64:
+		f := reflect.ValueOf(v).Float()
+		if f != math.Trunc(f) {
+			return 0, fmt.Errorf("%v is not an integer", f)
+		}
+		n = int64(f)
+	case json.Number:
+		parsed, err := v.Int64()
+		if err != nil {
+			return 0, err
+		}
+		n = parsed
+	case string:
+		parsed, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not an integer", v)
+		}
+		n = parsed
+	default:
+		return 0, fmt.Errorf("cannot use %T as an integer", value)
+	}
+
+	if bits < 64 && (n < -(1<<(bits-1)) || n > 1<<(bits-1)-1) {
+		return 0, fmt.Errorf("%d is out of range for a %d-bit integer", n, bits)
+	}
+	return n, nil
+}
+
+func coerceFloat(value any) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case json.Number:
+		return v.Float64()
+	case string:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number", v)
+		}
+		return parsed, nil
+	}
+	n, err := coerceInt(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("cannot use %T as a number", value)
+	}
+	return float64(n), nil
+}
+
+// coerceNumeric keeps string values as they are, so that no precision is lost
+func coerceNumeric(value any) (any, error) {
+	switch v := value.(type) {
+	case string:
+		if _, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err != nil {
+			return nil, fmt.Errorf("%q is not a number", v)
+		}
+		return strings.TrimSpace(v), nil
+	case json.Number:
+		return v.String(), nil
+	}
+	return coerceFloat(value)
+}
+
+func coerceBool(value any) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true", "t", "yes", "y", "on", "1":
+			return true, nil
+		case "false", "f", "no", "n", "off", "0":
+			return false, nil
+		}
+		return false, fmt.Errorf("%q is not a boolean", v)
+	}
+	n, err := coerceInt(value, 64)
+	if err != nil || (n != 0 && n != 1) {
+		return false, fmt.Errorf("cannot use %v as a boolean", value)
+	}
+	return n == 1, nil
+}
+
+func coerceTime(value any) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		for _, layout := range timeLayouts {
+			if parsed, err := time.Parse(layout, strings.TrimSpace(v)); err == nil {
+				return parsed, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("%q is not a date or time", v)
+	}
+	return time.Time{}, fmt.Errorf("cannot use %T as a date or time", value)
+}
+
+// coerceJSON encodes values as JSON, except strings that already are JSON documents
+func coerceJSON(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		if json.Valid([]byte(v)) {
+			return v, nil
+		}
+	case []byte:
+		if json.Valid(v) {
+			return string(v), nil
+		}
+	case json.RawMessage:
+		return string(v), nil
+	}
+	data, err := json.Marshal(Normalize(value))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func coerceString(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case fmt.Stringer:
+		return v.String(), nil
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, json.Number:
+		return fmt.Sprint(v), nil
+	}
+	return "", fmt.Errorf("cannot use %T as a string", value)
+}
+
+func coerceArray(elementType string, value any, enums map[string][]string) (any, error) {
+	if s, ok := value.(string); ok {
+		// already a PostgreSQL array literal, like '{a,b}'
+		if strings.HasPrefix(s, "{") {
+			return s, nil
+		}
+		return nil, fmt.Errorf("%q is not an array", s)
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("cannot use %T as an array", value)
+	}
+	elements := make([]any, rv.Len())
+	for i := range elements {
+		element, err := coerceType(elementType, rv.Index(i).Interface(), enums)
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %w", i, err)
+		}
+		elements[i] = element
+	}
+	return pq.GenericArray{A: elements}, nil
+}
+
+// Normalize converts the map[any]any values some YAML decoders produce to map[string]any so they can be encoded as
+// JSON
+func Normalize(value any) any {
+	switch v := value.(type) {
+	case map[any]any:
+		normalized := make(map[string]any, len(v))
+		for key, item := range v {
+			normalized[fmt.Sprint(key)] = Normalize(item)
+		}
+		return normalized
+	case map[string]any:
+		normalized := make(map[string]any, len(v))
+		for key, item := range v {
+			normalized[key] = Normalize(item)
+		}
+		return normalized
+	case []any:
+		normalized := make([]any, len(v))
+		for i, item := range v {
+			normalized[i] = Normalize(item)
+		}
+		return normalized
+	}
+	return value
+}
@@ -0,0 +1,119 @@
+package seeder
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/lib/pq"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+)
+
+func TestCoerce(t *testing.T) {
+	enums := map[string][]string{"mood": {"happy", "sad"}}
+	tests := []struct {
+		name     string
+		dataType string
+		value    any
+		want     any
+		wantErr  string
+	}{
+		{name: "null", dataType: "int4", value: nil, want: nil},
+		{name: "integer from a JSON number", dataType: "int4", value: float64(42), want: int64(42)},
+		{name: "integer from a numeric string", dataType: "integer", value: " 17 ", want: int64(17)},
+		{name: "bigint from a json.Number", dataType: "int8", value: json.Number("9007199254740993"), want: int64(9007199254740993)},
+		{name: "integer with a fraction", dataType: "int4", value: 1.5, wantErr: "1.5 is not an integer"},
+		{name: "smallint out of range", dataType: "int2", value: 40000, wantErr: "40000 is out of range for a 16-bit integer"},
+		{name: "integer from text", dataType: "serial", value: "ten", wantErr: `"ten" is not an integer`},
+		{name: "real from a string", dataType: "float8", value: "2.5", want: 2.5},
+		{name: "numeric string kept as is", dataType: "numeric", value: " 19.90 ", want: "19.90"},
+		{name: "numeric from a json.Number", dataType: "decimal", value: json.Number("0.10"), want: "0.10"},
+		{name: "numeric from a number", dataType: "numeric", value: 3, want: float64(3)},
+		{name: "numeric from text", dataType: "numeric", value: "abc", wantErr: `"abc" is not a number`},
+		{name: "boolean from yes", dataType: "boolean", value: "yes", want: true},
+		{name: "boolean from 0", dataType: "bool", value: 0, want: false},
+		{name: "boolean from text", dataType: "boolean", value: "maybe", wantErr: `"maybe" is not a boolean`},
+		{name: "date", dataType: "date", value: "2024-01-02", want: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{
+			name:     "timestamptz",
+			dataType: "timestamptz",
+			value:    "2024-01-02T03:04:05+02:00",
+			want:     time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("", 2*60*60)),
+		},
+		{name: "timestamp with a space", dataType: "timestamp", value: "2024-01-02 03:04:05.5", want: time.Date(2024, 1, 2, 3, 4, 5, 5e8, time.UTC)},
+		{name: "time of day", dataType: "time", value: "13:14:15", want: time.Date(0, 1, 1, 13, 14, 15, 0, time.UTC)},
+		{name: "time as is", dataType: "timestamptz", value: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), want: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{name: "date from text", dataType: "date", value: "yesterday", wantErr: `"yesterday" is not a date or time`},
+		{name: "date from a number", dataType: "date", value: 20240102, wantErr: "cannot use int as a date or time"},
+		{name: "json from a map", dataType: "jsonb", value: map[string]any{"a": 1, "b": []any{true}}, want: `{"a":1,"b":[true]}`},
+		{name: "json from a YAML map", dataType: "json", value: map[any]any{"a": map[any]any{1: "b"}}, want: `{"a":{"1":"b"}}`},
+		{name: "json document kept as is", dataType: "jsonb", value: `{"b": 2}`, want: `{"b": 2}`},
+		{name: "json string that isn't a document", dataType: "jsonb", value: "hello", want: `"hello"`},
+		{name: "json from bytes", dataType: "jsonb", value: []byte(`[1, 2]`), want: `[1, 2]`},
+		{name: "bytea from a string", dataType: "bytea", value: "abc", want: []byte("abc")},
+		{name: "bytea from a number", dataType: "bytea", value: 1, wantErr: "cannot use int as bytea"},
+		{name: "text from a number", dataType: "varchar", value: 12, want: "12"},
+		{name: "text from a map", dataType: "text", value: map[string]any{}, wantErr: "cannot use map[string]interface {} as a string"},
+		{name: "uuid", dataType: "uuid", value: "6ba7b810-9dad-11d1-80b4-00c04fd430c8", want: "6ba7b810-9dad-11d1-80b4-00c04fd430c8"},
+		{name: "upper case type", dataType: "INT4", value: "5", want: int64(5)},
+		{name: "type left to the driver", dataType: "point", value: "(1,2)", want: "(1,2)"},
+		{name: "text array", dataType: "text[]", value: []any{"a", "b c"}, want: pq.GenericArray{A: []any{"a", "b c"}}},
+		{name: "integer array", dataType: "int4[]", value: []any{1.0, "2"}, want: pq.GenericArray{A: []any{int64(1), int64(2)}}},
+		{name: "typed slice", dataType: "bool[]", value: []string{"t", "no"}, want: pq.GenericArray{A: []any{true, false}}},
+		{name: "array with a null", dataType: "text[]", value: []any{"a", nil}, want: pq.GenericArray{A: []any{"a", nil}}},
+		{name: "array literal kept as is", dataType: "text[]", value: `{a,"b c"}`, want: `{a,"b c"}`},
+		{name: "array from text", dataType: "text[]", value: "a", wantErr: `"a" is not an array`},
+		{name: "array from a number", dataType: "int4[]", value: 1, wantErr: "cannot use int as an array"},
+		{name: "array with an invalid element", dataType: "int4[]", value: []any{1, "x"}, wantErr: `element 1: "x" is not an integer`},
+		{name: "enum label", dataType: "mood", value: "happy", want: "happy"},
+		{name: "unknown enum label", dataType: "mood", value: "angry", wantErr: `"angry" is not a label of enum mood (happy, sad)`},
+		{name: "enum array", dataType: "mood[]", value: []any{"sad", "happy"}, want: pq.GenericArray{A: []any{"sad", "happy"}}},
+		{name: "enum array with an unknown label", dataType: "mood[]", value: []any{"sad", "bored"}, wantErr: `element 1: "bored" is not a label of enum mood`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Coerce(nodes.Column{Name: "c", DataType: tt.dataType}, tt.value, enums)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Coerce() error = %v, want %q", err, tt.wantErr)
+				}
+				if !strings.HasPrefix(err.Error(), "column c: ") {
+					t.Errorf("Coerce() error = %q, want it to name the column", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Coerce() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Coerce() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  any
+	}{
+		{name: "scalar", value: 1, want: 1},
+		{name: "YAML map", value: map[any]any{"a": 1, 2: "b"}, want: map[string]any{"a": 1, "2": "b"}},
+		{
+			name:  "nested maps in a map and a slice",
+			value: map[string]any{"a": map[any]any{"b": []any{map[any]any{true: nil}}}},
+			want:  map[string]any{"a": map[string]any{"b": []any{map[string]any{"true": nil}}}},
+		},
+		{name: "typed slice left as is", value: []string{"a"}, want: []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, Normalize(tt.value)); diff != "" {
+				t.Errorf("Normalize() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
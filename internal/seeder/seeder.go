@@ -0,0 +1,357 @@
+package seeder
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/tab58/go-integral/internal/fake"
+	"github.com/tab58/go-integral/internal/parse"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Row is a record of a table, with the values keyed by column name. Columns that are left out get their default value.
+type Row map[string]any
+
+// Dataset holds the rows to insert in each table, keyed by table name
+type Dataset map[string][]Row
+
+// Querier is the database interface the rows are inserted with, satisfied by *sql.DB, *sql.Tx, *sql.Conn and their
+// sqlx counterparts
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// Seeder inserts rows into the tables of a schema without generated code, in the same order as the generated seed
+// function
+type Seeder struct {
+	schema       *nodes.PostgreSQLSchema
+	sortedTables []nodes.Table
+}
+
+func New(schema *nodes.PostgreSQLSchema) (*Seeder, error) {
+	schemaGraph, err := parse.BuildTableGraph(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build table graph: %w", err)
+	}
+	sortedTables, err := schemaGraph.TopologicalSort()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get table relationships: %w", err)
+	}
+	return &Seeder{
+		schema:       schema,
+		sortedTables: sortedTables,
+	}, nil
+}
+
+// Schema returns the schema the rows are inserted into
+func (s *Seeder) Schema() *nodes.PostgreSQLSchema {
+	return s.schema
+}
+
+// Tables returns the tables of the schema in insert order
+func (s *Seeder) Tables() []nodes.Table {
+	return s.sortedTables
+}
+
+// Seed inserts the rows of every table, the tables being in order of dependency and the rows of a table in the given
+// order. It returns the inserted rows as stored, including the values the database generated.
+func (s *Seeder) Seed(ctx context.Context, db Querier, data Dataset) (Dataset, error) {
+	for tableName := range data {
+		if _, ok := s.schema.Tables[tableName]; !ok {
+			return nil, fmt.Errorf("unknown table %q", tableName)
+		}
+	}
+
+	inserted := make(Dataset)
+	for _, table := range s.sortedTables {
+		for i, row := range data[table.Name] {
+			stored, err := s.Insert(ctx, db, table.Name, row)
+			if err != nil {
+				return nil, fmt.Errorf("unable to insert row %d of table %s: %w", i, table.Name, err)
+			}
+			inserted[table.Name] = append(inserted[table.Name], stored)
+		}
+	}
+	return inserted, nil
+}
+
+// FakeRow returns a row of a table with a value from the faker for every column but the foreign keys, which the
+// caller sets, and the columns the database fills: serial and identity columns and columns with a default
+func (s *Seeder) FakeRow(faker *fake.Faker, tableName string) (Row, error) {
+	table, ok := s.schema.Tables[tableName]
+	if !ok {
+		return nil, fmt.Errorf("unknown table %q", tableName)
+	}
+	row := make(Row, len(table.Columns))
+	for _, column := range table.Columns {
+		if isForeignKey(table, column.Name) || isFilledByDatabase(column) {
+			continue
+		}
+		value, ok := faker.Value(table, column, s.schema.Enums)
+		if !ok {
+			if table.IsRequiredColumn(column.Name) {
+				return nil, fmt.Errorf("no fake values for column %s of type %s", column.Name, column.DataType)
+			}
+			continue
+		}
+		row[column.Name] = value
+	}
+	return row, nil
+}
+
+// Insert coerces the values of a row to the types of its columns and inserts it, returning the row as stored
+func (s *Seeder) Insert(ctx context.Context, db Querier, tableName string, row Row) (Row, error) {
+	table, ok := s.schema.Tables[tableName]
+	if !ok {
+		return nil, fmt.Errorf("unknown table %q", tableName)
+	}
+	query, args, err := s.insertQuery(table, row)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, sql.ErrNoRows
+	}
+	stored, err := scanRow(table, rows)
+	if err != nil {
+		return nil, err
+	}
+	return stored, rows.Close()
+}
+
+// maxBatchRows bounds the rows of an InsertMany statement, which PostgreSQL also limits to 65535 parameters
+const maxBatchRows = 1000
+
+// InsertMany coerces and inserts rows of a table with multi-row INSERT statements, which is much faster than Insert
+// for large numbers of rows. The rows don't need to set the same columns: the ones a row leaves out get their default.
+func (s *Seeder) InsertMany(ctx context.Context, db Querier, tableName string, rows []Row) error {
+	table, ok := s.schema.Tables[tableName]
+	if !ok {
+		return fmt.Errorf("unknown table %q", tableName)
+	}
+
+	columnSet := make(map[string]bool)
+	for _, row := range rows {
+		for columnName := range row {
+			columnSet[columnName] = true
+		}
+	}
+	columnNames := slices.Sorted(maps.Keys(columnSet))
+	if len(columnNames) == 0 {
+		for i := range rows {
+			if _, err := s.Insert(ctx, db, tableName, rows[i]); err != nil {
+				return fmt.Errorf("unable to insert row %d of table %s: %w", i, tableName, err)
+			}
+		}
+		return nil
+	}
+
+	batchRows := min(maxBatchRows, math.MaxUint16/len(columnNames))
+	for start := 0; start < len(rows); start += batchRows {
+		batch := rows[start:min(start+batchRows, len(rows))]
+		query, args, err := s.insertManyQuery(table, columnNames, batch)
+		if err != nil {
+			return fmt.Errorf("unable to insert rows %d to %d of table %s: %w", start, start+len(batch)-1, tableName, err)
+		}
+		result, err := db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("unable to insert rows %d to %d of table %s: %w", start, start+len(batch)-1, tableName, err)
+		}
+		if err := result.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Select reads the rows of a table matching an SQL condition, which is inserted in the query as is and can use the
+// args as $1, $2 and so on. An empty condition selects every row. The values are read like Insert returns them.
+func (s *Seeder) Select(ctx context.Context, db Querier, tableName string, condition string, args ...any) ([]Row, error) {
+	table, ok := s.schema.Tables[tableName]
+	if !ok {
+		return nil, fmt.Errorf("unknown table %q", tableName)
+	}
+	columnNames := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		columnNames[i] = column.Name
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(quoteIdentifiers(columnNames), ", "), quoteTable(table))
+	if condition != "" {
+		query += " WHERE " + condition
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	selected := make([]Row, 0)
+	for rows.Next() {
+		row, err := scanRow(table, rows)
+		if err != nil {
+			return nil, err
+		}
+		selected = append(selected, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return selected, rows.Close()
+}
+
+func (s *Seeder) insertManyQuery(table nodes.Table, columnNames []string, rows []Row) (string, []any, error) {
+	columns := make([]nodes.Column, len(columnNames))
+	for i, columnName := range columnNames {
+		column, ok := findColumn(table, columnName)
+		if !ok {
+			return "", nil, fmt.Errorf("table %s has no column %q", table.Name, columnName)
+		}
+		columns[i] = column
+	}
+
+	var query strings.Builder
+	fmt.Fprintf(&query, "INSERT INTO %s (%s) VALUES ", quoteTable(table), strings.Join(quoteIdentifiers(columnNames), ", "))
+	args := make([]any, 0, len(rows)*len(columns))
+	for i, row := range rows {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString("(")
+		for j, column := range columns {
+			if j > 0 {
+				query.WriteString(", ")
+			}
+			value, ok := row[column.Name]
+			if !ok {
+				query.WriteString("DEFAULT")
+				continue
+			}
+			coerced, err := Coerce(column, value, s.schema.Enums)
+			if err != nil {
+				return "", nil, err
+			}
+			args = append(args, coerced)
+			fmt.Fprintf(&query, "$%d", len(args))
+		}
+		query.WriteString(")")
+	}
+	return query.String(), args, nil
+}
+
+func (s *Seeder) insertQuery(table nodes.Table, row Row) (string, []any, error) {
+	columnNames := slices.Sorted(maps.Keys(row))
+	args := make([]any, 0, len(columnNames))
+	for _, columnName := range columnNames {
+		column, ok := findColumn(table, columnName)
+		if !ok {
+			return "", nil, fmt.Errorf("table %s has no column %q", table.Name, columnName)
+		}
+		value, err := Coerce(column, row[columnName], s.schema.Enums)
+		if err != nil {
+			return "", nil, err
+		}
+		args = append(args, value)
+	}
+
+	if len(columnNames) == 0 {
+		return fmt.Sprintf("INSERT INTO %s DEFAULT VALUES RETURNING *", quoteTable(table)), args, nil
+	}
+	placeholders := make([]string, len(columnNames))
+	for i := range placeholders {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) RETURNING *",
+		quoteTable(table),
+		strings.Join(quoteIdentifiers(columnNames), ", "),
+		strings.Join(placeholders, ", "),
+	)
+	return query, args, nil
+}
+
+func scanRow(table nodes.Table, rows *sql.Rows) (Row, error) {
+	columnNames, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]any, len(columnNames))
+	pointers := make([]any, len(columnNames))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	if err := rows.Scan(pointers...); err != nil {
+		return nil, err
+	}
+
+	row := make(Row, len(columnNames))
+	for i, columnName := range columnNames {
+		value := values[i]
+		// drivers return the text of types they don't decode, like numeric or uuid, as bytes
+		if b, ok := value.([]byte); ok {
+			if column, ok := findColumn(table, columnName); !ok || !strings.EqualFold(column.DataType, "bytea") {
+				value = string(b)
+			}
+		}
+		row[columnName] = value
+	}
+	return row, nil
+}
+
+func isForeignKey(table nodes.Table, columnName string) bool {
+	for _, constraint := range table.Constraints {
+		if info, ok := constraint.Constraint.(*nodes.ForeignKeyConstraintInfo); ok && info.TableColumnName == columnName {
+			return true
+		}
+	}
+	return false
+}
+
+// isFilledByDatabase returns whether the database has a value for a column when a row leaves it out
+func isFilledByDatabase(column nodes.Column) bool {
+	switch strings.ToLower(column.DataType) {
+	case "smallserial", "serial", "bigserial":
+		return true
+	}
+	return slices.ContainsFunc(column.Constraints, func(c nodes.ColumnConstraint) bool {
+		return (c.Type == nodes.ConstraintInfoTypeDefault && c.ExpressionValue != "NULL") ||
+			c.Type == nodes.ConstraintInfoTypeIdentity || c.Type == nodes.ConstraintInfoTypeGenerated
+	})
+}
+
+func findColumn(table nodes.Table, columnName string) (nodes.Column, bool) {
+	for _, column := range table.Columns {
+		if column.Name == columnName {
+			return column, true
+		}
+	}
+	return nodes.Column{}, false
+}
+
+func quoteTable(table nodes.Table) string {
+	if table.Schema != "" {
+		return pq.QuoteIdentifier(table.Schema) + "." + pq.QuoteIdentifier(table.Name)
+	}
+	return pq.QuoteIdentifier(table.Name)
+}
+
+func quoteIdentifiers(names []string) []string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = pq.QuoteIdentifier(name)
+	}
+	return quoted
+}
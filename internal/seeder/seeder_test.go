@@ -0,0 +1,287 @@
+package seeder
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+)
+
+const seederSchema = `
+CREATE SCHEMA billing;
+CREATE TYPE mood AS ENUM ('happy', 'sad');
+CREATE TABLE users (id serial PRIMARY KEY, email text NOT NULL, mood mood, created_at timestamptz DEFAULT now());
+CREATE TABLE billing.invoices (id serial PRIMARY KEY, user_id int NOT NULL REFERENCES users (id), total numeric(10, 2));
+`
+
+func newTestSeeder(t *testing.T, sqlSchema string) *Seeder {
+	t.Helper()
+	schema, err := nodes.NewPostgreSQLSchema(sqlSchema)
+	if err != nil {
+		t.Fatalf("NewPostgreSQLSchema() error = %v", err)
+	}
+	s, err := New(schema)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return s
+}
+
+func TestInsertQuery(t *testing.T) {
+	s := newTestSeeder(t, seederSchema)
+	tests := []struct {
+		name      string
+		tableName string
+		row       Row
+		wantQuery string
+		wantArgs  []any
+		wantErr   string
+	}{
+		{
+			name:      "columns in name order",
+			tableName: "users",
+			row:       Row{"mood": "sad", "email": "a@example.com", "id": 1.0},
+			wantQuery: `INSERT INTO "users" ("email", "id", "mood") VALUES ($1, $2, $3) RETURNING *`,
+			wantArgs:  []any{"a@example.com", int64(1), "sad"},
+		},
+		{
+			name:      "table of a PostgreSQL schema",
+			tableName: "invoices",
+			row:       Row{"user_id": "1", "total": "9.90"},
+			wantQuery: `INSERT INTO "billing"."invoices" ("total", "user_id") VALUES ($1, $2) RETURNING *`,
+			wantArgs:  []any{"9.90", int64(1)},
+		},
+		{
+			name:      "empty row",
+			tableName: "users",
+			row:       Row{},
+			wantQuery: `INSERT INTO "users" DEFAULT VALUES RETURNING *`,
+			wantArgs:  []any{},
+		},
+		{
+			name:      "null value",
+			tableName: "users",
+			row:       Row{"mood": nil},
+			wantQuery: `INSERT INTO "users" ("mood") VALUES ($1) RETURNING *`,
+			wantArgs:  []any{nil},
+		},
+		{
+			name:      "unknown column",
+			tableName: "users",
+			row:       Row{"name": "a"},
+			wantErr:   `table users has no column "name"`,
+		},
+		{
+			name:      "value of the wrong type",
+			tableName: "users",
+			row:       Row{"mood": "angry"},
+			wantErr:   `column mood: "angry" is not a label of enum mood`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := s.insertQuery(s.schema.Tables[tt.tableName], tt.row)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("insertQuery() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("insertQuery() error = %v", err)
+			}
+			if query != tt.wantQuery {
+				t.Errorf("insertQuery() query = %s, want %s", query, tt.wantQuery)
+			}
+			if diff := cmp.Diff(tt.wantArgs, args); diff != "" {
+				t.Errorf("insertQuery() args mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestInsertManyQuery(t *testing.T) {
+	s := newTestSeeder(t, seederSchema)
+	tests := []struct {
+		name        string
+		columnNames []string
+		rows        []Row
+		wantQuery   string
+		wantArgs    []any
+		wantErr     string
+	}{
+		{
+			name:        "rows with every column",
+			columnNames: []string{"email", "id"},
+			rows:        []Row{{"email": "a@example.com", "id": 1}, {"email": "b@example.com", "id": 2}},
+			wantQuery:   `INSERT INTO "users" ("email", "id") VALUES ($1, $2), ($3, $4)`,
+			wantArgs:    []any{"a@example.com", int64(1), "b@example.com", int64(2)},
+		},
+		{
+			name:        "rows leaving columns out",
+			columnNames: []string{"created_at", "email", "mood"},
+			rows:        []Row{{"email": "a@example.com", "mood": "happy"}, {"email": "b@example.com", "created_at": "2024-01-02"}},
+			wantQuery:   `INSERT INTO "users" ("created_at", "email", "mood") VALUES (DEFAULT, $1, $2), ($3, $4, DEFAULT)`,
+			wantArgs:    []any{"a@example.com", "happy", mustCoerce(t, "timestamptz", "2024-01-02"), "b@example.com"},
+		},
+		{
+			name:        "explicit null",
+			columnNames: []string{"email", "mood"},
+			rows:        []Row{{"email": "a@example.com", "mood": nil}},
+			wantQuery:   `INSERT INTO "users" ("email", "mood") VALUES ($1, $2)`,
+			wantArgs:    []any{"a@example.com", nil},
+		},
+		{
+			name:        "unknown column",
+			columnNames: []string{"email", "name"},
+			rows:        []Row{{"email": "a@example.com", "name": "a"}},
+			wantErr:     `table users has no column "name"`,
+		},
+		{
+			name:        "value of the wrong type",
+			columnNames: []string{"id"},
+			rows:        []Row{{"id": 1}, {"id": "two"}},
+			wantErr:     `column id: "two" is not an integer`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := s.insertManyQuery(s.schema.Tables["users"], tt.columnNames, tt.rows)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("insertManyQuery() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("insertManyQuery() error = %v", err)
+			}
+			if query != tt.wantQuery {
+				t.Errorf("insertManyQuery() query = %s, want %s", query, tt.wantQuery)
+			}
+			if diff := cmp.Diff(tt.wantArgs, args); diff != "" {
+				t.Errorf("insertManyQuery() args mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestInsertManyBatches(t *testing.T) {
+	var wide strings.Builder
+	wide.WriteString("CREATE TABLE wide (")
+	for i := range 100 {
+		if i > 0 {
+			wide.WriteString(", ")
+		}
+		fmt.Fprintf(&wide, "c%02d int", i)
+	}
+	wide.WriteString(");")
+
+	tests := []struct {
+		name        string
+		sqlSchema   string
+		tableName   string
+		columns     int
+		rows        int
+		wantErr     string
+		wantBatches []int
+	}{
+		{name: "rows of one statement", sqlSchema: seederSchema, tableName: "users", columns: 2, rows: 3, wantBatches: []int{3}},
+		{name: "rows split in batches of 1000", sqlSchema: seederSchema, tableName: "users", columns: 2, rows: 2001, wantBatches: []int{1000, 1000, 1}},
+		{name: "batches bounded by the parameter limit", sqlSchema: wide.String(), tableName: "wide", columns: 100, rows: 1000, wantBatches: []int{655, 345}},
+		{name: "no rows", sqlSchema: seederSchema, tableName: "users", columns: 2, rows: 0},
+		{name: "unknown table", sqlSchema: seederSchema, tableName: "orders", wantErr: `unknown table "orders"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSeeder(t, tt.sqlSchema)
+			table := s.schema.Tables[tt.tableName]
+			rows := make([]Row, tt.rows)
+			for i := range rows {
+				rows[i] = make(Row)
+				for _, column := range table.Columns[:tt.columns] {
+					if column.DataType == "text" {
+						rows[i][column.Name] = fmt.Sprintf("user%d@example.com", i)
+					} else {
+						rows[i][column.Name] = i
+					}
+				}
+			}
+
+			conn := &recordingConn{}
+			db := sql.OpenDB(conn)
+			defer db.Close()
+			err := s.InsertMany(context.Background(), db, tt.tableName, rows)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("InsertMany() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("InsertMany() error = %v", err)
+			}
+
+			var batches []int
+			for _, query := range conn.queries {
+				// a parenthesis opens the column list, then each row
+				rowCount := strings.Count(query.sql, "(") - 1
+				if query.args != rowCount*tt.columns {
+					t.Errorf("statement of %d rows has %d args, want %d", rowCount, query.args, rowCount*tt.columns)
+				}
+				batches = append(batches, rowCount)
+			}
+			if diff := cmp.Diff(tt.wantBatches, batches); diff != "" {
+				t.Errorf("rows per statement mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func mustCoerce(t *testing.T, dataType string, value any) any {
+	t.Helper()
+	coerced, err := Coerce(nodes.Column{Name: "c", DataType: dataType}, value, nil)
+	if err != nil {
+		t.Fatalf("Coerce() error = %v", err)
+	}
+	return coerced
+}
+
+// recordedQuery is a statement a recordingConn ran, with the number of its arguments
+type recordedQuery struct {
+	sql  string
+	args int
+}
+
+// recordingConn is a database/sql driver connection recording the statements it's given, which return no rows
+type recordingConn struct {
+	queries []recordedQuery
+}
+
+func (c *recordingConn) Connect(ctx context.Context) (driver.Conn, error) { return c, nil }
+func (c *recordingConn) Driver() driver.Driver                            { return nil }
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("prepared statements are not supported")
+}
+func (c *recordingConn) Close() error { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error) {
+	return nil, fmt.Errorf("transactions are not supported")
+}
+
+func (c *recordingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.queries = append(c.queries, recordedQuery{sql: query, args: len(args)})
+	return emptyRows{}, nil
+}
+
+// emptyRows is the result of the statements of a recordingConn
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string              { return nil }
+func (emptyRows) Close() error                   { return nil }
+func (emptyRows) Next(dest []driver.Value) error { return io.EOF }
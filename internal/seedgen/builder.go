@@ -1,23 +1,67 @@
 package seedgen
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"go-integral/internal/parse"
-	"go-integral/internal/parse/nodes"
-	"go-integral/internal/utils"
+	"github.com/tab58/go-integral/internal/fake"
+	"github.com/tab58/go-integral/internal/graph"
+	"github.com/tab58/go-integral/internal/introspect"
+	"github.com/tab58/go-integral/internal/parse"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"github.com/tab58/go-integral/internal/source"
+	"github.com/tab58/go-integral/internal/utils"
+	"path"
+	"slices"
 	"strings"
 
 	"github.com/iancoleman/strcase"
 )
 
 type Builder struct {
+	opts         Options
+	namer        namer
 	sortedTables []nodes.Table
+	enums        map[string][]string
+	// fakeOverrides are the kinds of fake values set for columns by the options
+	fakeOverrides []fake.Override
+	// detachedColumns are the columns of the nullable foreign keys to tables that aren't kept, by table
+	detachedColumns map[string][]string
 }
 
-func NewFromSQLSchema(sqlSchema string) (*Builder, error) {
+func NewFromSQLSchema(sqlSchema string, opts Options) (*Builder, error) {
+	return NewFromSource(context.Background(), source.SQL(sqlSchema), opts)
+}
+
+// NewFromMigrations creates a builder from the schema that results from applying the migrations in a directory, or
+// the ones matching a glob
+func NewFromMigrations(pathOrGlob string, opts Options) (*Builder, error) {
+	return NewFromSource(context.Background(), source.Migrations{Pattern: pathOrGlob}, opts)
+}
+
+// NewFromDatabase creates a builder from the schema of the tables in a live PostgreSQL database
+func NewFromDatabase(ctx context.Context, dsn string, introspectOpts introspect.Options, opts Options) (*Builder, error) {
+	return NewFromSource(ctx, source.Database{DSN: dsn, Options: introspectOpts}, opts)
+}
+
+// NewFromSource creates a builder from the schema loaded from any schema source
+func NewFromSource(ctx context.Context, src source.SchemaSource, opts Options) (*Builder, error) {
+	schema, err := src.LoadSchema(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load schema: %w", err)
+	}
+	return NewFromSchema(schema, opts)
+}
+
+// NewFromSchema creates a builder from an already parsed schema
+func NewFromSchema(schema *nodes.PostgreSQLSchema, opts Options) (*Builder, error) {
+	opts = opts.withDefaults()
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+
 	// build dependency graph
-	graph, err := parse.BuildSQLTableGraph(sqlSchema)
+	graph, err := parse.BuildTableGraph(schema)
 	if err != nil {
 		return nil, errors.New("failed to build table graph: " + err.Error())
 	}
@@ -28,35 +72,141 @@ func NewFromSQLSchema(sqlSchema string) (*Builder, error) {
 		return nil, errors.New("failed to get table relationships: " + err.Error())
 	}
 
+	// keep the selected tables
+	sortedTables, detachedColumns, err := selectTables(graph, result, opts.IncludeTables, opts.ExcludeTables)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Builder{
-		sortedTables: result,
+		opts:         opts,
+		namer:        newNamer(opts.Naming),
+		sortedTables: sortedTables,
+		enums:        schema.Enums,
+		fakeOverrides: utils.Map(opts.FakeValues, func(v FakeValue) fake.Override {
+			return fake.Override{Column: v.Column, Kind: fake.Kind(v.Kind)}
+		}),
+		detachedColumns: detachedColumns,
 	}, nil
 }
 
+// Tables returns the tables of the schema in record insert order
+func (b *Builder) Tables() []nodes.Table {
+	return b.sortedTables
+}
+
 func (b *Builder) GenerateTemplateFiles() ([]GolangFile, error) {
 	// generate the table schemas
-	tableSchemas, err := utils.MapErr(b.sortedTables, generateTableSchema)
+	tableSchemas, err := utils.MapErr(b.sortedTables, b.generateTableSchema)
 	if err != nil {
 		return nil, fmt.Errorf("unable to generate table schemas: %w", err)
 	}
 
 	// generate the table record files
-	files, err := utils.MapErr(tableSchemas, generateGoFileFromTableSchema)
+	files, err := utils.MapErr(tableSchemas, b.generateGoFileFromTableSchema)
 	if err != nil {
 		return nil, fmt.Errorf("unable to generate Golang file from table schema: %w", err)
 	}
 
 	// generate the seed script
-	seedScript, err := generateSeedScriptFromTableSchemas(tableSchemas)
+	seedScript, err := b.generateSeedScriptFromTableSchemas(tableSchemas)
 	if err != nil {
 		return nil, fmt.Errorf("unable to generate seed script from table schemas: %w", err)
 	}
 
-	return append(files, seedScript), nil
+	// generate the faker the factories share
+	factory, err := b.generateFactoryFile()
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate factory file: %w", err)
+	}
+
+	// generate the writer of the models as an SQL script
+	sqlScript, err := b.generateSQLScriptFile(tableSchemas)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate SQL script file: %w", err)
+	}
+
+	// generate the comparison of records with the database the Assert functions share
+	assert, err := b.generateAssertFile(tableSchemas)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate assert file: %w", err)
+	}
+
+	return append(files, seedScript, factory, sqlScript, assert), nil
+}
+
+// selectTables keeps the included tables, or every table if none are, along with the tables they require through NOT
+// NULL foreign keys, and then drops the excluded tables. Nullable foreign keys to tables that aren't kept are dropped
+// from the tables referencing them, so their columns become plain nullable inputs, which are returned by table.
+func selectTables(schemaGraph *parse.TableGraph, sortedTables []nodes.Table, include []string, exclude []string) ([]nodes.Table, map[string][]string, error) {
+	selected := make(map[string]bool)
+	if len(include) == 0 {
+		for _, table := range sortedTables {
+			selected[table.Name] = true
+		}
+	}
+	for _, pattern := range include {
+		matched := false
+		for _, n := range schemaGraph.Nodes {
+			if !matchesTable(pattern, n.Value.Name) {
+				continue
+			}
+			matched = true
+			selected[n.Value.Name] = true
+			for _, ancestor := range schemaGraph.Ancestors(n, isRequiredDependency) {
+				selected[ancestor.Value.Name] = true
+			}
+		}
+		if !matched {
+			return nil, nil, fmt.Errorf("included table pattern %q doesn't match any table", pattern)
+		}
+	}
+	for tableName := range selected {
+		if slices.ContainsFunc(exclude, func(pattern string) bool { return matchesTable(pattern, tableName) }) {
+			delete(selected, tableName)
+		}
+	}
+
+	remaining := make([]nodes.Table, 0, len(selected))
+	detached := make(map[string][]string)
+	for _, table := range sortedTables {
+		if !selected[table.Name] {
+			continue
+		}
+		constraints := make([]nodes.TableConstraint, 0, len(table.Constraints))
+		for _, constraint := range table.Constraints {
+			info, ok := constraint.Constraint.(*nodes.ForeignKeyConstraintInfo)
+			if ok && !selected[info.ForeignKeyTableName] {
+				if table.IsNotNullColumn(info.TableColumnName) {
+					return nil, nil, fmt.Errorf("table %q requires excluded table %q through NOT NULL foreign key %q", table.Name, info.ForeignKeyTableName, info.TableColumnName)
+				}
+				detached[table.Name] = append(detached[table.Name], info.TableColumnName)
+				continue
+			}
+			constraints = append(constraints, constraint)
+		}
+		table.Constraints = constraints
+		remaining = append(remaining, table)
+	}
+	return remaining, detached, nil
+}
+
+// isRequiredDependency returns whether the referencing table of a foreign key can't have rows without the referenced
+// table having rows
+func isRequiredDependency(edge *graph.DirectedEdge[nodes.Table, parse.TableDependency]) bool {
+	return edge.To.Value.IsNotNullColumn(edge.Value.ToNode.TableColumn)
 }
 
-func generateSeedScriptFromTableSchemas(schemas []TableSchema) (GolangFile, error) {
-	contents, err := generateSeedScriptContentsFromTableSchemas(schemas)
+func matchesTable(pattern string, tableName string) bool {
+	matched, _ := path.Match(pattern, tableName)
+	return matched
+}
+
+func (b *Builder) generateSeedScriptFromTableSchemas(schemas []TableSchema) (GolangFile, error) {
+	contents, err := generateSeedScriptContentsFromTableSchemas(SeedScriptTemplateData{
+		Options: b.opts,
+		Tables:  schemas,
+	})
 	if err != nil {
 		return GolangFile{}, fmt.Errorf("unable to generate seed script contents from table schemas: %w", err)
 	}
@@ -66,8 +216,39 @@ func generateSeedScriptFromTableSchemas(schemas []TableSchema) (GolangFile, erro
 	}, nil
 }
 
-func generateGoFileFromTableSchema(schema TableSchema) (GolangFile, error) {
-	contents, err := generateFileContentsFromTableSchema(schema)
+func (b *Builder) generateFactoryFile() (GolangFile, error) {
+	contents, err := generateFactoryContents(FactoryTemplateData{
+		Options:   b.opts,
+		WordLists: wordLists(),
+	})
+	if err != nil {
+		return GolangFile{}, fmt.Errorf("unable to generate factory contents: %w", err)
+	}
+	return GolangFile{
+		Filename: "factory.go",
+		Contents: contents,
+	}, nil
+}
+
+func (b *Builder) generateAssertFile(schemas []TableSchema) (GolangFile, error) {
+	contents, err := generateAssertContents(SeedScriptTemplateData{
+		Options: b.opts,
+		Tables:  schemas,
+	})
+	if err != nil {
+		return GolangFile{}, fmt.Errorf("unable to generate assert contents: %w", err)
+	}
+	return GolangFile{
+		Filename: "assert.go",
+		Contents: contents,
+	}, nil
+}
+
+func (b *Builder) generateGoFileFromTableSchema(schema TableSchema) (GolangFile, error) {
+	contents, err := generateFileContentsFromTableSchema(TableRecordTemplateData{
+		Options:     b.opts,
+		TableSchema: schema,
+	})
 	if err != nil {
 		return GolangFile{}, fmt.Errorf("unable to generate file contents from table schema: %w", err)
 	}
@@ -77,22 +258,52 @@ func generateGoFileFromTableSchema(schema TableSchema) (GolangFile, error) {
 	}, nil
 }
 
-func checkGolangDataType(column nodes.Column) string {
-	nullable := false
-	for _, cons := range column.Constraints {
-		if cons.Type == nodes.ConstraintInfoTypeDefault {
-			if cons.ExpressionValue == "NULL" {
-				nullable = true
-			}
+// golangDataType returns the Go type of a column of a table, along with the package that has to be imported for it
+func (b *Builder) golangDataType(table nodes.Table, column nodes.Column) (string, string, error) {
+	columnKey := table.Name + "." + column.Name
+	for _, override := range b.opts.TypeOverrides {
+		if override.Column == columnKey {
+			return override.GoType, override.Import, nil
 		}
-		if cons.Type == nodes.ConstraintInfoTypeNotNull {
-			if nullable {
-				panic("column is nullable and has not null constraint")
+	}
+	nullable, err := isNullableColumn(table, column)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", columnKey, err)
+	}
+	for _, override := range b.opts.TypeOverrides {
+		if override.DBType != "" && strings.EqualFold(override.DBType, column.DataType) {
+			goType := override.GoType
+			if nullable && !isNilableGoType(goType) {
+				goType = "*" + goType
 			}
-			nullable = false
+			return goType, override.Import, nil
 		}
 	}
+	return checkGolangDataType(column, nullable), "", nil
+}
+
+// isNullableColumn returns whether a column of a table can be null, that is when it's neither NOT NULL nor part of the
+// primary key. A DEFAULT NULL contradicts a NOT NULL constraint.
+func isNullableColumn(table nodes.Table, column nodes.Column) (bool, error) {
+	hasDefaultNull := slices.ContainsFunc(column.Constraints, func(cons nodes.ColumnConstraint) bool {
+		return cons.Type == nodes.ConstraintInfoTypeDefault && cons.ExpressionValue == "NULL"
+	})
+	hasNotNull := slices.ContainsFunc(column.Constraints, func(cons nodes.ColumnConstraint) bool {
+		return cons.Type == nodes.ConstraintInfoTypeNotNull
+	})
+	if hasDefaultNull && hasNotNull {
+		return false, errors.New("column has both a DEFAULT NULL and a NOT NULL constraint")
+	}
+	return !table.IsNotNullColumn(column.Name), nil
+}
+
+// isNilableGoType returns whether the values of a Go type can already be nil, so that the type of a nullable column
+// isn't a pointer to it
+func isNilableGoType(goType string) bool {
+	return goType == "any" || strings.HasPrefix(goType, "*") || strings.HasPrefix(goType, "[]") || strings.HasPrefix(goType, "map[")
+}
 
+func checkGolangDataType(column nodes.Column, nullable bool) string {
 	goType := "any"
 	switch strings.ToLower(column.DataType) {
 	case "text":
@@ -144,7 +355,7 @@ func checkGolangDataType(column nodes.Column) string {
 		goType = "any"
 	}
 
-	if nullable && !strings.HasPrefix(goType, "[]") {
+	if nullable && !isNilableGoType(goType) {
 		goType = "*" + goType
 	}
 	return goType
@@ -0,0 +1,126 @@
+package seedgen
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"github.com/tab58/go-integral/internal/utils"
+)
+
+const selectionSchema = `
+CREATE TABLE users (id serial PRIMARY KEY, email text NOT NULL);
+CREATE TABLE orgs (id serial PRIMARY KEY, name text NOT NULL);
+CREATE TABLE members (
+  id serial PRIMARY KEY,
+  org_id int NOT NULL REFERENCES orgs (id),
+  reviewer_id int REFERENCES users (id),
+  role text NOT NULL
+);
+`
+
+func TestSelectTables(t *testing.T) {
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		// wantTables are the generated tables, in insert order
+		wantTables []string
+		// wantInputs are the Go types of the input columns of members, and wantFactory the fields its factory fills
+		wantInputs  map[string]string
+		wantFactory []string
+		wantErr     string
+	}{
+		{
+			name:        "every table",
+			wantTables:  []string{"orgs", "users", "members"},
+			wantInputs:  map[string]string{"Id": "int32", "Role": "string"},
+			wantFactory: []string{"Id", "Role"},
+		},
+		{
+			name:        "included table with the tables it requires",
+			include:     []string{"members"},
+			wantTables:  []string{"orgs", "members"},
+			wantInputs:  map[string]string{"Id": "int32", "ReviewerId": "*int32", "Role": "string"},
+			wantFactory: []string{"Id", "Role"},
+		},
+		{
+			name:        "included glob",
+			include:     []string{"mem*"},
+			wantTables:  []string{"orgs", "members"},
+			wantInputs:  map[string]string{"Id": "int32", "ReviewerId": "*int32", "Role": "string"},
+			wantFactory: []string{"Id", "Role"},
+		},
+		{
+			name:        "excluded table referenced through a nullable foreign key",
+			exclude:     []string{"users"},
+			wantTables:  []string{"orgs", "members"},
+			wantInputs:  map[string]string{"Id": "int32", "ReviewerId": "*int32", "Role": "string"},
+			wantFactory: []string{"Id", "Role"},
+		},
+		{
+			name:        "included and excluded table",
+			include:     []string{"members", "users"},
+			exclude:     []string{"users"},
+			wantTables:  []string{"orgs", "members"},
+			wantInputs:  map[string]string{"Id": "int32", "ReviewerId": "*int32", "Role": "string"},
+			wantFactory: []string{"Id", "Role"},
+		},
+		{
+			name:       "excluded table without references",
+			exclude:    []string{"members"},
+			wantTables: []string{"orgs", "users"},
+		},
+		{
+			name:    "excluded table referenced through a NOT NULL foreign key",
+			include: []string{"members"},
+			exclude: []string{"orgs"},
+			wantErr: `table "members" requires excluded table "orgs" through NOT NULL foreign key "org_id"`,
+		},
+		{
+			name:    "included pattern matching no table",
+			include: []string{"accounts"},
+			wantErr: `included table pattern "accounts" doesn't match any table`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := NewFromSQLSchema(selectionSchema, Options{IncludeTables: tt.include, ExcludeTables: tt.exclude})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("NewFromSQLSchema() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewFromSQLSchema() error = %v", err)
+			}
+
+			tableNames := utils.Map(b.Tables(), func(table nodes.Table) string { return table.Name })
+			if !slices.Equal(tableNames, tt.wantTables) {
+				t.Errorf("Tables() = %v, want %v", tableNames, tt.wantTables)
+			}
+			index := slices.Index(tableNames, "members")
+			if index < 0 {
+				return
+			}
+			schema, err := b.generateTableSchema(b.Tables()[index])
+			if err != nil {
+				t.Fatalf("generateTableSchema() error = %v", err)
+			}
+			inputs := make(map[string]string)
+			for _, column := range schema.RecordInputColumns {
+				inputs[column.Name.Golang] = column.GoType
+			}
+			if diff := cmp.Diff(tt.wantInputs, inputs); diff != "" {
+				t.Errorf("input columns mismatch (-want +got):\n%s", diff)
+			}
+			factory := utils.Map(schema.FactoryFields, func(field FactoryField) string { return field.Name })
+			if !slices.Equal(factory, tt.wantFactory) {
+				t.Errorf("factory fields = %v, want %v", factory, tt.wantFactory)
+			}
+		})
+	}
+}
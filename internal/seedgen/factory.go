@@ -0,0 +1,171 @@
+package seedgen
+
+import (
+	"fmt"
+	"github.com/tab58/go-integral/internal/fake"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+)
+
+// fakeValue is a Go expression of the generated Faker that evaluates to a random value, along with its Go type
+type fakeValue struct {
+	Expr   string
+	GoType string
+}
+
+var numericGoTypes = []string{"int16", "int32", "int64", "float32", "float64"}
+
+// factoryFields returns how the generated factory of a table fills each input column. Columns whose Go type the
+// Faker has no values for are left out and keep their zero value, like the columns of the foreign keys to tables that
+// aren't generated, which stay nil as any other value would violate the foreign key.
+func (b *Builder) factoryFields(table nodes.Table, inputColumns []RawTableSchemaColumn) []FactoryField {
+	fields := make([]FactoryField, 0, len(inputColumns))
+	for _, inputColumn := range inputColumns {
+		column, ok := findColumn(table, inputColumn.Name)
+		if !ok || slices.Contains(b.detachedColumns[table.Name], column.Name) {
+			continue
+		}
+		goType, pointer := strings.CutPrefix(inputColumn.GoType, "*")
+
+		var value fakeValue
+		if elemType, ok := strings.CutPrefix(goType, "[]"); ok && goType != "[]byte" {
+			elem, ok := b.fakeScalar(table.Name, column, strings.TrimSuffix(strings.ToLower(column.DataType), "[]"), elemType, false)
+			if !ok {
+				continue
+			}
+			value = fakeValue{Expr: fmt.Sprintf("%s{%s}", goType, elem.Expr), GoType: goType}
+		} else {
+			value, ok = b.fakeScalar(table.Name, column, strings.ToLower(column.DataType), goType, fake.IsUniqueColumn(table, column.Name))
+			if !ok {
+				continue
+			}
+		}
+		if pointer {
+			value.Expr = fmt.Sprintf("fakePtr(%s)", value.Expr)
+		}
+		fields = append(fields, FactoryField{
+			Name:     b.namer.camel(column.Name),
+			Value:    value.Expr,
+			Nullable: !table.IsNotNullColumn(column.Name),
+		})
+	}
+	return fields
+}
+
+// fakeScalar returns the expression of a random value of a column converted to goType. Unique values are derived
+// from the sequence number of the record, which the generated factory keeps in f.seq.
+func (b *Builder) fakeScalar(tableName string, column nodes.Column, dataType string, goType string, unique bool) (fakeValue, bool) {
+	value, ok := b.fakeDataType(tableName, column, dataType, unique)
+	if !ok {
+		return fakeValue{}, false
+	}
+	switch {
+	case goType == "any" && value.GoType == "map[string]any":
+		// the driver can't encode maps, but it can encode the JSON text
+		return fakeValue{Expr: "f.faker.JSONText()", GoType: "string"}, true
+	case goType == value.GoType || goType == "any":
+		return value, true
+	case slices.Contains(numericGoTypes, goType) && slices.Contains(numericGoTypes, value.GoType):
+		return fakeValue{Expr: fmt.Sprintf("%s(%s)", goType, value.Expr), GoType: goType}, true
+	}
+	return fakeValue{}, false
+}
+
+func (b *Builder) fakeDataType(tableName string, column nodes.Column, dataType string, unique bool) (fakeValue, bool) {
+	if labels, ok := b.enums[dataType]; ok && len(labels) > 0 {
+		quoted := make([]string, len(labels))
+		for i, label := range labels {
+			quoted[i] = strconv.Quote(label)
+		}
+		return fakeValue{Expr: fmt.Sprintf("f.faker.Enum(%s)", strings.Join(quoted, ", ")), GoType: "string"}, true
+	}
+
+	modifier := func(i int) int {
+		if i < len(column.TypeModifiers) {
+			return int(column.TypeModifiers[i])
+		}
+		return 0
+	}
+	switch dataType {
+	case "smallint", "int2", "smallserial":
+		return fakeInt(unique, 32767), true
+	case "integer", "int", "int4", "serial":
+		return fakeInt(unique, 2147483647), true
+	case "bigint", "int8", "bigserial":
+		return fakeInt(unique, 9007199254740991), true
+	case "real", "float4", "double precision", "float8", "float":
+		if unique {
+			return fakeValue{Expr: "float64(f.seq)", GoType: "float64"}, true
+		}
+		return fakeValue{Expr: "f.faker.Float()", GoType: "float64"}, true
+	case "numeric", "decimal", "money":
+		if unique {
+			return fakeValue{Expr: "float64(f.seq)", GoType: "float64"}, true
+		}
+		return fakeValue{Expr: fmt.Sprintf("f.faker.Decimal(%d, %d)", modifier(0), modifier(1)), GoType: "float64"}, true
+	case "bool", "boolean":
+		return fakeValue{Expr: "f.faker.Bool()", GoType: "bool"}, true
+	case "date":
+		kind := b.fakeKind(tableName, column, dataType)
+		return fakeValue{Expr: fmt.Sprintf("f.faker.Date(%q)", kind), GoType: "time.Time"}, true
+	case "timestamp", "timestamptz":
+		kind := b.fakeKind(tableName, column, dataType)
+		return fakeValue{Expr: fmt.Sprintf("f.faker.Time(%q)", kind), GoType: "time.Time"}, true
+	case "time", "timetz":
+		return fakeValue{Expr: "f.faker.TimeOfDay()", GoType: "string"}, true
+	case "text", "varchar", "char", "bpchar", "citext", "name":
+		maxLength := modifier(0)
+		if (dataType == "char" || dataType == "bpchar") && maxLength == 0 {
+			maxLength = 1
+		}
+		kind := b.fakeKind(tableName, column, dataType)
+		if unique {
+			return fakeValue{Expr: fmt.Sprintf("f.faker.UniqueText(%q, %d, f.seq)", kind, maxLength), GoType: "string"}, true
+		}
+		return fakeValue{Expr: fmt.Sprintf("f.faker.Text(%q, %d)", kind, maxLength), GoType: "string"}, true
+	case "uuid":
+		return fakeValue{Expr: "f.faker.UUID()", GoType: "string"}, true
+	case "inet", "cidr":
+		return fakeValue{Expr: `f.faker.Text("ip_address", 0)`, GoType: "string"}, true
+	case "json", "jsonb":
+		return fakeValue{Expr: "f.faker.JSON()", GoType: "map[string]any"}, true
+	case "bytea":
+		return fakeValue{Expr: "f.faker.Bytes(16)", GoType: "[]byte"}, true
+	}
+	return fakeValue{}, false
+}
+
+func fakeInt(unique bool, max int64) fakeValue {
+	if unique {
+		return fakeValue{Expr: "f.seq", GoType: "int64"}
+	}
+	return fakeValue{Expr: fmt.Sprintf("f.faker.Int(1, %d)", max), GoType: "int64"}
+}
+
+// fakeKind returns the kind of fake values of a column, which is looked up by its element type for arrays
+func (b *Builder) fakeKind(tableName string, column nodes.Column, dataType string) fake.Kind {
+	column.DataType = dataType
+	return fake.KindOf(tableName, column, b.fakeOverrides)
+}
+
+// wordLists returns the word lists of the fake values, ten words to a line
+func wordLists() []WordList {
+	lists := make([]WordList, 0, len(fake.WordListNames))
+	for _, name := range fake.WordListNames {
+		words := fake.Words(name)
+		list := WordList{VarName: "fake" + strcase.ToCamel(name)}
+		for chunk := range slices.Chunk(words, 10) {
+			quoted := make([]string, len(chunk))
+			for i, word := range chunk {
+				quoted[i] = strconv.Quote(word)
+			}
+			list.Lines = append(list.Lines, strings.Join(quoted, ", ")+",")
+		}
+		lists = append(lists, list)
+	}
+	return lists
+}
@@ -0,0 +1,133 @@
+package seedgen
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tab58/go-integral/internal/fake"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"github.com/tab58/go-integral/internal/utils"
+)
+
+// fakerSeeds are the seeds both fakers are compared with
+var fakerSeeds = []uint64{1, 42, 2025}
+
+// fakerCheckProgram prints the values of the generated Faker, one to a line, in the order fakeValues reads them from
+// the runtime faker
+const fakerCheckProgram = `package main
+
+import "fmt"
+
+func main() {
+  for _, seed := range %#v {
+    f := NewFaker(seed)
+    for _, kind := range %#v {
+      fmt.Println(f.Text(kind, 0))
+      fmt.Println(f.Text(kind, 6))
+      fmt.Println(f.UniqueText(kind, 0, 7))
+      fmt.Println(f.UniqueText(kind, 12, 123))
+    }
+    for _, kind := range %#v {
+      fmt.Println(f.Time(kind))
+      fmt.Println(f.Date(kind))
+    }
+    fmt.Println(f.Int(1, 100))
+    fmt.Println(f.Decimal(10, 2))
+    fmt.Println(f.Decimal(5, 3))
+    fmt.Println(f.Float())
+    fmt.Println(f.Bool())
+    fmt.Println(f.TimeOfDay())
+    fmt.Println(f.UUID())
+    fmt.Println(f.Bytes(16))
+    fmt.Println(f.JSON())
+  }
+}
+`
+
+// TestGeneratedFaker checks that the Faker of the generated code, which is written separately from the runtime faker
+// of package fake, generates the same values for the same seed. It builds and runs a program using the generated
+// Faker, and is skipped when the go command isn't available.
+func TestGeneratedFaker(t *testing.T) {
+	goCommand, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("the go command is not available")
+	}
+	textKinds := append(utils.Map(fake.TextKinds, func(kind fake.Kind) string { return string(kind) }), string(fake.KindRandom))
+	timeKinds := append(utils.Map(fake.TimeKinds, func(kind fake.Kind) string { return string(kind) }), string(fake.KindRandom))
+
+	factory, err := generateFactoryContents(FactoryTemplateData{
+		Options:   Options{}.withDefaults(),
+		WordLists: wordLists(),
+	})
+	if err != nil {
+		t.Fatalf("generateFactoryContents() error = %v", err)
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":     "module fakercheck\n\ngo 1.24\n",
+		"factory.go": strings.Replace(factory, "package "+DefaultPackageName, "package main", 1),
+		"main.go":    fmt.Sprintf(fakerCheckProgram, fakerSeeds, textKinds, timeKinds),
+	}
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o644); err != nil {
+			t.Fatalf("unable to write %s: %v", name, err)
+		}
+	}
+	cmd := exec.Command(goCommand, "run", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("unable to run the generated faker: %v\n%s", err, output)
+	}
+
+	want := fakeValues(t, textKinds, timeKinds)
+	if diff := cmp.Diff(want, strings.Split(strings.TrimSuffix(string(output), "\n"), "\n")); diff != "" {
+		t.Errorf("generated faker values differ from the runtime faker values (-runtime +generated):\n%s", diff)
+	}
+}
+
+// fakeValues returns the values of the runtime faker, written like fakerCheckProgram writes the values of the
+// generated Faker. The values the runtime faker only generates for columns are read with Value.
+func fakeValues(t *testing.T, textKinds []string, timeKinds []string) []string {
+	t.Helper()
+	table := nodes.Table{Name: "t"}
+	var lines []string
+	for _, seed := range fakerSeeds {
+		f := fake.New(seed)
+		value := func(dataType string, kind string) any {
+			t.Helper()
+			f.Overrides = []fake.Override{{Column: "t.c", Kind: fake.Kind(kind)}}
+			v, ok := f.Value(table, nodes.Column{Name: "c", DataType: dataType}, nil)
+			if !ok {
+				t.Fatalf("Value() of %s ok = false", dataType)
+			}
+			return v
+		}
+		for _, kind := range textKinds {
+			lines = append(lines,
+				f.Text(fake.Kind(kind), 0),
+				f.Text(fake.Kind(kind), 6),
+				f.UniqueText(fake.Kind(kind), 0, 7),
+				f.UniqueText(fake.Kind(kind), 12, 123),
+			)
+		}
+		for _, kind := range timeKinds {
+			lines = append(lines, fmt.Sprint(f.Time(fake.Kind(kind))), fmt.Sprint(value("date", kind)))
+		}
+		lines = append(lines,
+			fmt.Sprint(f.Int(1, 100)),
+			fmt.Sprint(f.Decimal(10, 2)),
+			fmt.Sprint(f.Decimal(5, 3)),
+		)
+		for _, dataType := range []string{"real", "boolean", "time", "uuid", "bytea", "json"} {
+			lines = append(lines, fmt.Sprint(value(dataType, string(fake.KindRandom))))
+		}
+	}
+	return lines
+}
@@ -0,0 +1,284 @@
+package seedgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/tab58/go-integral/internal/fixtures"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"github.com/tab58/go-integral/internal/seeder"
+	"go/token"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DefaultFixturesVarName = "Fixtures"
+
+// GenerateFixturesFile generates a Go file declaring a variable of the models type that holds the fixture records.
+// Records without a value for a single column integer primary key are numbered from 1, skipping the values other
+// records use, so that references to them can be resolved.
+func (b *Builder) GenerateFixturesFile(f *fixtures.Fixtures, varName string) (GolangFile, error) {
+	if varName == "" {
+		varName = DefaultFixturesVarName
+	}
+	if !token.IsIdentifier(varName) || !token.IsExported(varName) {
+		return GolangFile{}, fmt.Errorf("%q is not a valid exported Go identifier", varName)
+	}
+
+	values, err := b.resolveFixtures(f)
+	if err != nil {
+		return GolangFile{}, err
+	}
+
+	writer := &literalWriter{}
+	data := FixturesTemplateData{
+		Options: b.opts,
+		VarName: varName,
+	}
+	for _, table := range b.sortedTables {
+		if len(values[table.Name]) == 0 {
+			continue
+		}
+		fixturesTable := FixturesTable{GolangTableName: b.namer.table(table.Name)}
+		for i, record := range f.Tables[table.Name] {
+			fixturesRecord := FixturesRecord{Label: record.Label}
+			for _, column := range table.Columns {
+				value, ok := values[table.Name][i][column.Name]
+				if !ok {
+					continue
+				}
+				goType, _, err := b.golangDataType(table, column)
+				if err != nil {
+					return GolangFile{}, err
+				}
+				literal, err := writer.literal(goType, strings.ToLower(column.DataType), value)
+				if err != nil {
+					return GolangFile{}, fmt.Errorf("%s.%s of record %d: %w", table.Name, column.Name, i, err)
+				}
+				fixturesRecord.Fields = append(fixturesRecord.Fields, FixturesField{
+					Name:  b.namer.camel(column.Name),
+					Value: literal,
+				})
+			}
+			fixturesTable.Records = append(fixturesTable.Records, fixturesRecord)
+		}
+		data.Tables = append(data.Tables, fixturesTable)
+	}
+	if writer.usesTime {
+		data.Imports = append(data.Imports, "time")
+	}
+	data.UsesPointers = writer.usesPointers
+
+	contents, err := generateFixturesContents(data)
+	if err != nil {
+		return GolangFile{}, fmt.Errorf("unable to generate fixtures contents: %w", err)
+	}
+	return GolangFile{
+		Filename: "fixtures.go",
+		Contents: contents,
+	}, nil
+}
+
+// resolveFixtures validates the fixtures against the generated tables and returns the values of each record by table
+func (b *Builder) resolveFixtures(f *fixtures.Fixtures) (map[string][]map[string]any, error) {
+	schema := &nodes.PostgreSQLSchema{Tables: make(map[string]nodes.Table)}
+	for _, table := range b.sortedTables {
+		schema.Tables[table.Name] = table
+	}
+	if err := f.Validate(schema); err != nil {
+		return nil, fmt.Errorf("invalid fixtures: %w", err)
+	}
+	return b.fixtureValues(f)
+}
+
+// fixtureValues numbers the records missing an integer primary key and resolves the references, returning the values
+// of each record by table
+func (b *Builder) fixtureValues(f *fixtures.Fixtures) (map[string][]map[string]any, error) {
+	values := make(map[string][]map[string]any)
+	for _, table := range b.sortedTables {
+		records := f.Tables[table.Name]
+		tableValues := make([]map[string]any, len(records))
+		for i, record := range records {
+			tableValues[i] = maps.Clone(record.Values)
+		}
+		if err := b.numberPrimaryKeys(table, tableValues); err != nil {
+			return nil, err
+		}
+		values[table.Name] = tableValues
+	}
+
+	// the referenced tables come first, so their values are final when they are referenced
+	for _, table := range b.sortedTables {
+		for i, record := range values[table.Name] {
+			for columnName, value := range record {
+				ref, ok := value.(fixtures.Reference)
+				if !ok {
+					continue
+				}
+				info, _ := fixtures.ForeignKey(table, columnName)
+				index := slices.IndexFunc(f.Tables[info.ForeignKeyTableName], func(r fixtures.Record) bool { return r.Label == ref.Label })
+				referenced, ok := values[info.ForeignKeyTableName][index][info.ForeignKeyColumnName]
+				if !ok {
+					return nil, fmt.Errorf("%s record %q has no value for %s, which %s.%s of record %d references", info.ForeignKeyTableName, ref.Label, info.ForeignKeyColumnName, table.Name, columnName, i)
+				}
+				record[columnName] = referenced
+			}
+		}
+	}
+	return values, nil
+}
+
+func (b *Builder) numberPrimaryKeys(table nodes.Table, records []map[string]any) error {
+	if len(table.PrimaryKey) != 1 {
+		return nil
+	}
+	columnName := table.PrimaryKey[0]
+	column, ok := findColumn(table, columnName)
+	if !ok {
+		return nil
+	}
+	goType, _, err := b.golangDataType(table, column)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains([]string{"int16", "int32", "int64"}, goType) {
+		return nil
+	}
+
+	used := make(map[int64]bool)
+	for i, record := range records {
+		value, ok := record[columnName]
+		if !ok {
+			continue
+		}
+		if _, isRef := value.(fixtures.Reference); isRef {
+			continue
+		}
+		coerced, err := seeder.Coerce(column, value, nil)
+		if err != nil {
+			return fmt.Errorf("%s record %d: %w", table.Name, i, err)
+		}
+		if n, ok := coerced.(int64); ok {
+			used[n] = true
+		}
+	}
+
+	next := int64(1)
+	for _, record := range records {
+		if _, ok := record[columnName]; ok {
+			continue
+		}
+		for used[next] {
+			next++
+		}
+		record[columnName] = next
+		used[next] = true
+	}
+	return nil
+}
+
+func findColumn(table nodes.Table, columnName string) (nodes.Column, bool) {
+	for _, column := range table.Columns {
+		if column.Name == columnName {
+			return column, true
+		}
+	}
+	return nodes.Column{}, false
+}
+
+// literalWriter writes values as Go literals of the type of their column, keeping track of what the literals need
+type literalWriter struct {
+	usesPointers bool
+	usesTime     bool
+}
+
+func (w *literalWriter) literal(goType string, dataType string, value any) (string, error) {
+	if value == nil {
+		if strings.HasPrefix(goType, "*") || strings.HasPrefix(goType, "[]") || strings.HasPrefix(goType, "map[") || goType == "any" {
+			return "nil", nil
+		}
+		return "", fmt.Errorf("null isn't a valid %s", goType)
+	}
+
+	if elemType, ok := strings.CutPrefix(goType, "*"); ok {
+		literal, err := w.literal(elemType, dataType, value)
+		if err != nil {
+			return "", err
+		}
+		w.usesPointers = true
+		return fmt.Sprintf("fixturePtr[%s](%s)", elemType, literal), nil
+	}
+	if elemType, ok := strings.CutPrefix(goType, "[]"); ok && elemType != "byte" {
+		elements, ok := value.([]any)
+		if !ok {
+			return "", fmt.Errorf("%T isn't a valid %s", value, goType)
+		}
+		literals := make([]string, len(elements))
+		for i, element := range elements {
+			literal, err := w.literal(elemType, strings.TrimSuffix(dataType, "[]"), element)
+			if err != nil {
+				return "", fmt.Errorf("element %d: %w", i, err)
+			}
+			literals[i] = literal
+		}
+		return fmt.Sprintf("%s{%s}", goType, strings.Join(literals, ", ")), nil
+	}
+
+	coerced, err := seeder.Coerce(nodes.Column{DataType: dataType}, value, nil)
+	if err != nil {
+		return "", err
+	}
+	switch goType {
+	case "string":
+		s, ok := coerced.(string)
+		if !ok {
+			s = fmt.Sprint(coerced)
+		}
+		return strconv.Quote(s), nil
+	case "[]byte":
+		return fmt.Sprintf("[]byte(%s)", strconv.Quote(fmt.Sprint(value))), nil
+	case "bool":
+		if b, ok := coerced.(bool); ok {
+			return strconv.FormatBool(b), nil
+		}
+	case "int16", "int32", "int64":
+		if n, ok := coerced.(int64); ok {
+			return strconv.FormatInt(n, 10), nil
+		}
+	case "float32", "float64":
+		switch v := coerced.(type) {
+		case float64:
+			return strconv.FormatFloat(v, 'g', -1, 64), nil
+		case string:
+			if _, err := strconv.ParseFloat(v, 64); err == nil {
+				return v, nil
+			}
+		}
+	case "time.Time":
+		if t, ok := coerced.(time.Time); ok {
+			w.usesTime = true
+			t = t.UTC()
+			return fmt.Sprintf("time.Date(%d, %d, %d, %d, %d, %d, %d, time.UTC)", t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond()), nil
+		}
+	case "any":
+		// JSON documents are written as their text, like the factories write them, which the database driver accepts
+		if s, ok := coerced.(string); ok && (dataType == "json" || dataType == "jsonb") {
+			return strconv.Quote(s), nil
+		}
+		return fmt.Sprintf("%#v", seeder.Normalize(value)), nil
+	case "map[string]any":
+		// the field can only hold the decoded object, which the insert functions encode back to its text
+		if s, ok := coerced.(string); ok {
+			var decoded map[string]any
+			if err := json.Unmarshal([]byte(s), &decoded); err != nil || decoded == nil {
+				return "", fmt.Errorf("%s isn't a JSON object", s)
+			}
+			return fmt.Sprintf("%#v", decoded), nil
+		}
+	default:
+		return "", fmt.Errorf("values of type %s can't be written in fixtures", goType)
+	}
+	return "", fmt.Errorf("%v isn't a valid %s", value, goType)
+}
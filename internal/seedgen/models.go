@@ -7,10 +7,16 @@ type RawTableSchema struct {
 	InputColumns     []RawTableSchemaColumn
 	DependencyTables map[string][]string
 	InputToOutputMap map[string]OutputMapData
+	FactoryFields    []FactoryField
+	SQLScript        SQLScriptTable
+	Imports          []string
+	// UniqueKeys are the columns of the primary key and of the unique constraints and indexes
+	UniqueKeys [][]string
 }
 type RawTableSchemaColumn struct {
-	Name   string
-	GoType string
+	Name     string
+	GoType   string
+	DataType string
 }
 
 type TableSchema struct {
@@ -21,11 +27,32 @@ type TableSchema struct {
 	RecordInputColumns []TableSchemaColumn
 	DependencyTables   []DependencyTable
 	InputToOutputMap   map[string]OutputMapData
+	FactoryFields      []FactoryField
+	SQLScript          SQLScriptTable
+	Imports            []string
+	// Keys are the sets of columns a record can be looked up by, the primary key first
+	Keys []TableKey
+}
+
+// TableKey is a primary key, unique constraint or unique index of a table, named after its columns like EmailAndOrgId
+type TableKey struct {
+	Name    string
+	Columns []TableKeyColumn
+}
+
+// TableKeyColumn is a column of a key, with the name of the parameter of its value in the generated functions
+type TableKeyColumn struct {
+	TableSchemaColumn
+	Param string
 }
 
 type TableSchemaColumn struct {
 	Name   SQLGolangStringValue
 	GoType string
+	// JSON is set for json and jsonb columns, which the Assert functions compare by their decoded documents
+	JSON bool
+	// ScanJSON is set for the JSON columns whose Go type can't be scanned from the database, which are decoded instead
+	ScanJSON bool
 }
 
 type SQLGolangStringValue struct {
@@ -39,12 +66,92 @@ type DependencyTable struct {
 	ColumnNames     []string
 }
 
+// FactoryField is an input column the generated factory fills with a random value
+type FactoryField struct {
+	Name  string
+	Value string
+	// Nullable is set for the columns that can be null, which the factory sometimes leaves nil
+	Nullable bool
+}
+
+// SQLScriptTable is what the generated SQL script writer needs to write the records of a table
+type SQLScriptTable struct {
+	// QuotedName is the quoted SQL name of the table
+	QuotedName string
+	Columns    []SQLScriptColumn
+	// SequenceResets are the statements moving the sequences of the columns past the values of the records
+	SequenceResets []string
+}
+
+// SQLScriptColumn is a column of a table in the generated SQL script writer
+type SQLScriptColumn struct {
+	QuotedName string
+	Field      string
+	DataType   string
+}
+
 type OutputMapData struct {
 	ObjectName string
 	FieldName  string
+	// Pointer is set for the nullable columns referencing a column that isn't, whose field points to a copy of its value
+	Pointer bool
+	// Dereference is set for the columns that aren't nullable referencing a nullable column
+	Dereference bool
 }
 
 type GolangFile struct {
 	Filename string
 	Contents string
 }
+
+type SeedScriptTemplateData struct {
+	Options
+	Tables []TableSchema
+}
+
+// SeedtestTemplateData is what the companion package of the test helpers needs to import the generated package
+type SeedtestTemplateData struct {
+	Options
+	SeedtestPackageName string
+	ImportPath          string
+	Tables              []TableSchema
+}
+
+type FactoryTemplateData struct {
+	Options
+	WordLists []WordList
+}
+
+// WordList is a list of words of the fake values, written as a Go slice of strings
+type WordList struct {
+	VarName string
+	Lines   []string
+}
+
+type TableRecordTemplateData struct {
+	Options
+	TableSchema
+}
+
+type FixturesTemplateData struct {
+	Options
+	VarName      string
+	Tables       []FixturesTable
+	Imports      []string
+	UsesPointers bool
+}
+
+type FixturesTable struct {
+	GolangTableName string
+	Records         []FixturesRecord
+}
+
+type FixturesRecord struct {
+	Label  string
+	Fields []FixturesField
+}
+
+type FixturesField struct {
+	Name  string
+	Value string
+}
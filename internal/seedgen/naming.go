@@ -0,0 +1,76 @@
+package seedgen
+
+import (
+	"strings"
+
+	"github.com/iancoleman/strcase"
+)
+
+// namer converts SQL identifiers to Go identifiers according to the naming options
+type namer struct {
+	acronyms           map[string]bool
+	stripTablePrefixes []string
+}
+
+func newNamer(opts NamingOptions) namer {
+	acronyms := make(map[string]bool)
+	for _, acronym := range opts.Acronyms {
+		acronyms[strings.ToLower(acronym)] = true
+	}
+	return namer{
+		acronyms:           acronyms,
+		stripTablePrefixes: opts.StripTablePrefixes,
+	}
+}
+
+// camel converts an identifier to an exported Go identifier
+func (n namer) camel(s string) string {
+	if len(n.acronyms) == 0 {
+		return strcase.ToCamel(s)
+	}
+	words := strings.Split(strcase.ToSnake(s), "_")
+	for i, word := range words {
+		words[i] = n.word(word)
+	}
+	return strings.Join(words, "")
+}
+
+// lowerCamel converts an identifier to an unexported Go identifier
+func (n namer) lowerCamel(s string) string {
+	if len(n.acronyms) == 0 {
+		return strcase.ToLowerCamel(s)
+	}
+	words := strings.Split(strcase.ToSnake(s), "_")
+	for i, word := range words {
+		if i == 0 {
+			words[i] = strings.ToLower(word)
+		} else {
+			words[i] = n.word(word)
+		}
+	}
+	return strings.Join(words, "")
+}
+
+// table converts a table name to a Go identifier, without any of the configured prefixes
+func (n namer) table(tableName string) string {
+	for _, prefix := range n.stripTablePrefixes {
+		if trimmed, ok := strings.CutPrefix(tableName, prefix); ok && trimmed != "" {
+			tableName = trimmed
+			break
+		}
+	}
+	return n.camel(tableName)
+}
+
+// lowerTable converts a table name to an unexported Go identifier, without any of the configured prefixes
+func (n namer) lowerTable(tableName string) string {
+	table := n.table(tableName)
+	return n.lowerCamel(strcase.ToSnake(table))
+}
+
+func (n namer) word(word string) string {
+	if n.acronyms[word] {
+		return strings.ToUpper(word)
+	}
+	return strcase.ToCamel(word)
+}
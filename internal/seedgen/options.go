@@ -0,0 +1,131 @@
+package seedgen
+
+import (
+	"errors"
+	"fmt"
+	"github.com/tab58/go-integral/internal/fake"
+	"go/token"
+	"path"
+	"slices"
+	"strings"
+)
+
+const (
+	DefaultPackageName    = "seed"
+	DefaultSeedFuncName   = "Seed"
+	DefaultModelsTypeName = "SchemaModels"
+)
+
+// Options controls which tables are generated and how the generated Go package looks
+type Options struct {
+	// PackageName is the package clause of every generated file
+	PackageName string
+	// SeedFuncName is the name of the exported function that seeds the whole schema
+	SeedFuncName string
+	// ModelsTypeName is the name of the exported struct holding the records for every table
+	ModelsTypeName string
+	// IncludeTables lists the tables, by name or glob, that code is generated for along with the tables they require
+	// through NOT NULL foreign keys. Every table is included when empty.
+	IncludeTables []string
+	// ExcludeTables lists the tables, by name or glob, that no code is generated for
+	ExcludeTables []string
+	// TypeOverrides replaces the Go type inferred for a column or a database type
+	TypeOverrides []TypeOverride
+	// Naming tweaks how SQL identifiers are converted to Go identifiers
+	Naming NamingOptions
+	// FakeValues sets the kinds of the values the factories fill columns with, taking precedence over the kinds
+	// guessed from the column names
+	FakeValues []FakeValue
+}
+
+// FakeValue sets the kind of fake values, like email or past_time, of the columns matching Column, a "table.column"
+// name or glob. The kind random turns off realistic values for the columns.
+type FakeValue struct {
+	Column string
+	Kind   string
+}
+
+// TypeOverride sets the Go type of either a single column (Column, as "table.column") or of every column with a
+// database type (DBType). Import is the package path that has to be imported for GoType, if any.
+type TypeOverride struct {
+	Column string
+	DBType string
+	GoType string
+	Import string
+}
+
+type NamingOptions struct {
+	// Acronyms are words that are fully upper-cased in Go identifiers, e.g. "id" turns user_id into UserID
+	Acronyms []string
+	// StripTablePrefixes are removed from table names before they are converted to Go identifiers
+	StripTablePrefixes []string
+}
+
+func (o Options) withDefaults() Options {
+	if o.PackageName == "" {
+		o.PackageName = DefaultPackageName
+	}
+	if o.SeedFuncName == "" {
+		o.SeedFuncName = DefaultSeedFuncName
+	}
+	if o.ModelsTypeName == "" {
+		o.ModelsTypeName = DefaultModelsTypeName
+	}
+	return o
+}
+
+// Validate checks the options, with the defaults set for the ones that aren't, reporting every problem at once
+func (o Options) Validate() error {
+	o = o.withDefaults()
+	var errs []error
+	if !token.IsIdentifier(o.PackageName) || o.PackageName == "_" {
+		errs = append(errs, fmt.Errorf("package name %q is not a valid Go identifier", o.PackageName))
+	}
+	for _, name := range []struct{ option, value string }{{"seed function name", o.SeedFuncName}, {"models type name", o.ModelsTypeName}} {
+		if !token.IsIdentifier(name.value) || !token.IsExported(name.value) {
+			errs = append(errs, fmt.Errorf("%s %q is not a valid exported Go identifier", name.option, name.value))
+		}
+	}
+	for _, pattern := range slices.Concat(o.IncludeTables, o.ExcludeTables) {
+		if pattern == "" {
+			errs = append(errs, errors.New("table pattern must not be empty"))
+		} else if _, err := path.Match(pattern, ""); err != nil {
+			errs = append(errs, fmt.Errorf("invalid table pattern %q: %w", pattern, err))
+		}
+	}
+	for i, override := range o.TypeOverrides {
+		if err := override.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("type override %d: %w", i, err))
+		}
+	}
+	for i, value := range o.FakeValues {
+		if _, err := path.Match(value.Column, ""); err != nil || value.Column == "" {
+			errs = append(errs, fmt.Errorf("fake value %d: invalid column pattern %q", i, value.Column))
+		}
+		if !fake.IsKind(value.Kind) {
+			errs = append(errs, fmt.Errorf("fake value %d: unknown kind %q", i, value.Kind))
+		}
+	}
+	for _, prefix := range o.Naming.StripTablePrefixes {
+		if prefix == "" {
+			errs = append(errs, errors.New("stripped table prefix must not be empty"))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t TypeOverride) validate() error {
+	if (t.Column == "") == (t.DBType == "") {
+		return errors.New("exactly one of column or database type must be set")
+	}
+	if t.Column != "" {
+		table, column, ok := strings.Cut(t.Column, ".")
+		if !ok || table == "" || column == "" {
+			return fmt.Errorf("column %q must be written as table.column", t.Column)
+		}
+	}
+	if t.GoType == "" {
+		return errors.New("go type must be set")
+	}
+	return nil
+}
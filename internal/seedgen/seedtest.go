@@ -0,0 +1,39 @@
+package seedgen
+
+import (
+	"errors"
+	"fmt"
+	"github.com/tab58/go-integral/internal/utils"
+	"path"
+)
+
+// SeedtestPackageName is the name of the companion package of the test helpers, and of its directory in the
+// directory of the generated package
+const SeedtestPackageName = "seedtest"
+
+// GenerateSeedtestFile generates the companion package of the generated package holding helpers for go test: they
+// take a testing.TB, fail the test on errors instead of returning them and delete the records they insert when the
+// test ends. importPath is the import path of the generated package, and the file is named relative to its directory.
+func (b *Builder) GenerateSeedtestFile(importPath string) (GolangFile, error) {
+	if importPath == "" {
+		return GolangFile{}, errors.New("the import path of the generated package is required")
+	}
+	tableSchemas, err := utils.MapErr(b.sortedTables, b.generateTableSchema)
+	if err != nil {
+		return GolangFile{}, fmt.Errorf("unable to generate table schemas: %w", err)
+	}
+
+	contents, err := generateSeedtestContents(SeedtestTemplateData{
+		Options:             b.opts,
+		SeedtestPackageName: SeedtestPackageName,
+		ImportPath:          importPath,
+		Tables:              tableSchemas,
+	})
+	if err != nil {
+		return GolangFile{}, fmt.Errorf("unable to generate seedtest contents: %w", err)
+	}
+	return GolangFile{
+		Filename: path.Join(SeedtestPackageName, SeedtestPackageName+".go"),
+		Contents: contents,
+	}, nil
+}
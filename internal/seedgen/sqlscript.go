@@ -0,0 +1,65 @@
+package seedgen
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/tab58/go-integral/internal/fixtures"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"github.com/tab58/go-integral/internal/seeder"
+	"github.com/tab58/go-integral/internal/sqlscript"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// GenerateFixturesSQL renders the fixture records as an SQL script inserting them in order of dependency, for the
+// environments that only take .sql files. The records are resolved like GenerateFixturesFile does, and the values are
+// written as literals of the types of their columns.
+func (b *Builder) GenerateFixturesSQL(f *fixtures.Fixtures, opts sqlscript.Options) ([]byte, error) {
+	values, err := b.resolveFixtures(f)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make(seeder.Dataset)
+	for tableName, records := range values {
+		for _, record := range records {
+			data[tableName] = append(data[tableName], record)
+		}
+	}
+	var buf bytes.Buffer
+	if err := sqlscript.Write(&buf, b.sortedTables, data, b.enums, opts); err != nil {
+		return nil, fmt.Errorf("unable to write fixtures script: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// sqlScriptTable describes a table for the generated SQL script writer, which writes every column of the records
+func (b *Builder) sqlScriptTable(table nodes.Table) SQLScriptTable {
+	scriptTable := SQLScriptTable{QuotedName: sqlscript.QuoteTable(table)}
+	for _, column := range table.Columns {
+		scriptTable.Columns = append(scriptTable.Columns, SQLScriptColumn{
+			QuotedName: pq.QuoteIdentifier(column.Name),
+			Field:      b.namer.camel(column.Name),
+			DataType:   strings.ToLower(column.DataType),
+		})
+		if sqlscript.HasSequence(column) {
+			scriptTable.SequenceResets = append(scriptTable.SequenceResets, sqlscript.SequenceReset(table, column.Name))
+		}
+	}
+	return scriptTable
+}
+
+func (b *Builder) generateSQLScriptFile(schemas []TableSchema) (GolangFile, error) {
+	contents, err := generateSQLScriptContents(SeedScriptTemplateData{
+		Options: b.opts,
+		Tables:  schemas,
+	})
+	if err != nil {
+		return GolangFile{}, fmt.Errorf("unable to generate SQL script contents: %w", err)
+	}
+	return GolangFile{
+		Filename: "seed_sql.go",
+		Contents: contents,
+	}, nil
+}
@@ -2,14 +2,14 @@ package seedgen
 
 import (
 	"fmt"
-	"go-integral/internal/parse/nodes"
-	"go-integral/internal/utils"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"github.com/tab58/go-integral/internal/utils"
+	"go/token"
 	"slices"
-
-	"github.com/iancoleman/strcase"
+	"strings"
 )
 
-func generateTableSchema(table nodes.Table) (TableSchema, error) {
+func (b *Builder) generateTableSchema(table nodes.Table) (TableSchema, error) {
 	// get the constraint columns
 	constraintColumnNames, err := generateConstraintColumnNames(table.Constraints)
 	if err != nil {
@@ -23,7 +23,28 @@ func generateTableSchema(table nodes.Table) (TableSchema, error) {
 	}
 
 	// convert all the columns to schema columns
-	allColumns := utils.Map(table.Columns, convertToSchemaColumn)
+	imports := make([]string, 0)
+	allColumns, err := utils.MapErr(table.Columns, func(column nodes.Column) (RawTableSchemaColumn, error) {
+		goType, importPath, err := b.golangDataType(table, column)
+		if err != nil {
+			return RawTableSchemaColumn{}, err
+		}
+		if importPath != "" && !slices.Contains(imports, importPath) {
+			imports = append(imports, importPath)
+		}
+		if strings.Contains(goType, "time.") && !slices.Contains(imports, "time") {
+			imports = append(imports, "time")
+		}
+		return RawTableSchemaColumn{
+			Name:     column.Name,
+			GoType:   goType,
+			DataType: strings.ToLower(column.DataType),
+		}, nil
+	})
+	if err != nil {
+		return TableSchema{}, err
+	}
+	slices.Sort(imports)
 
 	// filter on the constraints to get the input columns
 	inputColumns := utils.Filter(allColumns, func(column RawTableSchemaColumn) bool {
@@ -31,7 +52,7 @@ func generateTableSchema(table nodes.Table) (TableSchema, error) {
 	})
 
 	// map the Golang input names to the SQL output names
-	inputToOutputMap, err := createInputOutputMap(allColumns, table.Constraints)
+	inputToOutputMap, err := b.createInputOutputMap(allColumns, table.Constraints)
 	if err != nil {
 		return TableSchema{}, fmt.Errorf("unable to get input-output map: %w", err)
 	}
@@ -43,66 +64,104 @@ func generateTableSchema(table nodes.Table) (TableSchema, error) {
 		InputColumns:     inputColumns,
 		DependencyTables: dependencyTables,
 		InputToOutputMap: inputToOutputMap,
+		FactoryFields:    b.factoryFields(table, inputColumns),
+		SQLScript:        b.sqlScriptTable(table),
+		Imports:          imports,
+		UniqueKeys:       table.UniqueKeys(),
 	}
-	return refineTableSchema(tableSchema), nil
+	return b.refineTableSchema(tableSchema), nil
 }
 
 // refineTableSchema "massages" the format of the table schema to make it more Golang-friendly
-func refineTableSchema(tableSchema RawTableSchema) TableSchema {
+func (b *Builder) refineTableSchema(tableSchema RawTableSchema) TableSchema {
 	refinedTableSchema := TableSchema{
 		TableName: SQLGolangStringValue{
 			SQL:    tableSchema.TableName,
-			Golang: strcase.ToCamel(tableSchema.TableName),
+			Golang: b.namer.table(tableSchema.TableName),
 		},
 		SQLTablePrimaryKey: utils.Map(tableSchema.TablePrimaryKey, func(column string) SQLGolangStringValue {
 			return SQLGolangStringValue{
 				SQL:    column,
-				Golang: strcase.ToCamel(column),
+				Golang: b.namer.camel(column),
 			}
 		}),
 		TableColumns: utils.Map(tableSchema.TableColumns, func(column RawTableSchemaColumn) TableSchemaColumn {
+			isJSON := column.DataType == "json" || column.DataType == "jsonb"
 			return TableSchemaColumn{
 				Name: SQLGolangStringValue{
 					SQL:    column.Name,
-					Golang: strcase.ToCamel(column.Name),
+					Golang: b.namer.camel(column.Name),
 				},
-				GoType: column.GoType,
+				GoType:   column.GoType,
+				JSON:     isJSON,
+				ScanJSON: isJSON && slices.Contains([]string{"any", "map[string]any", "*map[string]any", "[]any"}, column.GoType),
 			}
 		}),
 		RecordInputColumns: utils.Map(tableSchema.InputColumns, func(column RawTableSchemaColumn) TableSchemaColumn {
 			return TableSchemaColumn{
 				Name: SQLGolangStringValue{
 					SQL:    column.Name,
-					Golang: strcase.ToCamel(column.Name),
+					Golang: b.namer.camel(column.Name),
 				},
 				GoType: column.GoType,
 			}
 		}),
-		DependencyTables: refineDependencyTables(tableSchema.DependencyTables),
+		DependencyTables: b.refineDependencyTables(tableSchema.DependencyTables),
 		InputToOutputMap: tableSchema.InputToOutputMap,
+		FactoryFields:    tableSchema.FactoryFields,
+		SQLScript:        tableSchema.SQLScript,
+		Imports:          tableSchema.Imports,
 	}
+	refinedTableSchema.Keys = utils.Map(tableSchema.UniqueKeys, func(columnNames []string) TableKey {
+		columns := utils.Filter(refinedTableSchema.TableColumns, func(column TableSchemaColumn) bool {
+			return slices.Contains(columnNames, column.Name.SQL)
+		})
+		// the columns of a key are in the order it lists them, rather than in the order of the table
+		slices.SortStableFunc(columns, func(a, b TableSchemaColumn) int {
+			return slices.Index(columnNames, a.Name.SQL) - slices.Index(columnNames, b.Name.SQL)
+		})
+		return TableKey{
+			Name: strings.Join(utils.Map(columns, func(column TableSchemaColumn) string {
+				return column.Name.Golang
+			}), "And"),
+			Columns: utils.Map(columns, func(column TableSchemaColumn) TableKeyColumn {
+				return TableKeyColumn{TableSchemaColumn: column, Param: b.paramName(column.Name.SQL)}
+			}),
+		}
+	})
 	return refinedTableSchema
 }
 
-func refineDependencyTables(dependencyTables map[string][]string) []DependencyTable {
+// reservedParamNames are the names the generated functions use besides their parameters
+var reservedParamNames = []string{"ctx", "db", "query", "row", "record", "err", "context", "sql", "errors", "fmt"}
+
+// paramName converts a column name to the name of a parameter of the generated functions, suffixed when it's a Go
+// keyword or a name the functions use
+func (b *Builder) paramName(columnName string) string {
+	name := b.namer.lowerCamel(columnName)
+	if token.IsKeyword(name) || slices.Contains(reservedParamNames, name) {
+		return name + "Value"
+	}
+	return name
+}
+
+func (b *Builder) refineDependencyTables(dependencyTables map[string][]string) []DependencyTable {
 	newDependencyTables := make([]DependencyTable, 0)
 	for key, value := range dependencyTables {
-		depTableKey := strcase.ToCamel(key)
+		depTableKey := b.namer.table(key)
 		newDependencyTables = append(newDependencyTables, DependencyTable{
 			GolangTableName: depTableKey,
-			InputRecordName: strcase.ToLowerCamel(key),
-			ColumnNames: utils.Map(value, func(column string) string {
-				return strcase.ToCamel(column)
-			}),
+			InputRecordName: b.namer.lowerTable(key),
+			ColumnNames:     utils.Map(value, b.namer.camel),
 		})
 	}
 	return newDependencyTables
 }
 
-func createInputOutputMap(columns []RawTableSchemaColumn, tableConstraints []nodes.TableConstraint) (map[string]OutputMapData, error) {
+func (b *Builder) createInputOutputMap(columns []RawTableSchemaColumn, tableConstraints []nodes.TableConstraint) (map[string]OutputMapData, error) {
 	inputToOutputMap := make(map[string]OutputMapData)
 	for _, column := range columns {
-		recordColName := strcase.ToCamel(column.Name)
+		recordColName := b.namer.camel(column.Name)
 
 		added := false
 		for _, x := range tableConstraints {
@@ -114,9 +173,15 @@ func createInputOutputMap(columns []RawTableSchemaColumn, tableConstraints []nod
 				if info.TableColumnName == column.Name {
 					fkTableName := info.ForeignKeyTableName
 					fkColumnName := info.ForeignKeyColumnName
+					referencedGoType, err := b.referencedGoType(fkTableName, fkColumnName)
+					if err != nil {
+						return nil, err
+					}
 					inputToOutputMap[recordColName] = OutputMapData{
-						ObjectName: strcase.ToLowerCamel(fkTableName) + "Model",
-						FieldName:  strcase.ToCamel(fkColumnName),
+						ObjectName:  b.namer.lowerTable(fkTableName) + "Model",
+						FieldName:   b.namer.camel(fkColumnName),
+						Pointer:     column.GoType == "*"+referencedGoType,
+						Dereference: referencedGoType == "*"+column.GoType,
 					}
 					added = true
 				}
@@ -133,12 +198,20 @@ func createInputOutputMap(columns []RawTableSchemaColumn, tableConstraints []nod
 	return inputToOutputMap, nil
 }
 
-func convertToSchemaColumn(column nodes.Column) RawTableSchemaColumn {
-	goType := checkGolangDataType(column)
-	return RawTableSchemaColumn{
-		Name:   column.Name,
-		GoType: goType,
+// referencedGoType returns the Go type of the column a foreign key references
+func (b *Builder) referencedGoType(tableName string, columnName string) (string, error) {
+	for _, table := range b.sortedTables {
+		if table.Name != tableName {
+			continue
+		}
+		column, ok := findColumn(table, columnName)
+		if !ok {
+			return "", fmt.Errorf("table %q has no column %q", tableName, columnName)
+		}
+		goType, _, err := b.golangDataType(table, column)
+		return goType, err
 	}
+	return "", fmt.Errorf("table %q not found", tableName)
 }
 
 func getDependentTables(constraints []nodes.TableConstraint) (map[string][]string, error) {
@@ -3,13 +3,15 @@ package seedgen
 import (
 	"bytes"
 	_ "embed"
+	"strconv"
+	"strings"
 	"text/template"
 )
 
 //go:embed templates/seed_script.tmpl
 var seedScriptTemplate string
 
-func generateSeedScriptContentsFromTableSchemas(schemas []TableSchema) (string, error) {
+func generateSeedScriptContentsFromTableSchemas(data SeedScriptTemplateData) (string, error) {
 	funcMap := template.FuncMap{
 		"inc": func(i int) int {
 			return i + 1
@@ -21,7 +23,7 @@ func generateSeedScriptContentsFromTableSchemas(schemas []TableSchema) (string,
 	}
 
 	buf := bytes.Buffer{}
-	err = tmpl.Execute(&buf, schemas)
+	err = tmpl.Execute(&buf, data)
 	if err != nil {
 		return "", err
 	}
@@ -32,7 +34,7 @@ func generateSeedScriptContentsFromTableSchemas(schemas []TableSchema) (string,
 //go:embed templates/table_record.tmpl
 var tableRecordTemplate string
 
-func generateFileContentsFromTableSchema(schema TableSchema) (string, error) {
+func generateFileContentsFromTableSchema(data TableRecordTemplateData) (string, error) {
 	funcMap := template.FuncMap{
 		// The name "inc" is what the function will be called in the template text.
 		"inc": func(i int) int {
@@ -46,7 +48,106 @@ func generateFileContentsFromTableSchema(schema TableSchema) (string, error) {
 	}
 
 	buf := bytes.Buffer{}
-	err = tmpl.Execute(&buf, schema)
+	err = tmpl.Execute(&buf, data)
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+//go:embed templates/fixtures.tmpl
+var fixturesTemplate string
+
+func generateFixturesContents(data FixturesTemplateData) (string, error) {
+	tmpl, err := template.New("fixtures").Parse(fixturesTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	buf := bytes.Buffer{}
+	err = tmpl.Execute(&buf, data)
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+//go:embed templates/factory.tmpl
+var factoryTemplate string
+
+func generateFactoryContents(data FactoryTemplateData) (string, error) {
+	tmpl, err := template.New("factory").Parse(factoryTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	buf := bytes.Buffer{}
+	err = tmpl.Execute(&buf, data)
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+//go:embed templates/seed_sql.tmpl
+var sqlScriptTemplate string
+
+func generateSQLScriptContents(data SeedScriptTemplateData) (string, error) {
+	funcMap := template.FuncMap{
+		// goString writes SQL as a raw string literal when it can, as SQL is full of double quotes
+		"goString": func(s string) string {
+			if strings.Contains(s, "`") {
+				return strconv.Quote(s)
+			}
+			return "`" + s + "`"
+		},
+	}
+	tmpl, err := template.New("seed_sql").Funcs(funcMap).Parse(sqlScriptTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	buf := bytes.Buffer{}
+	err = tmpl.Execute(&buf, data)
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+//go:embed templates/assert.tmpl
+var assertTemplate string
+
+func generateAssertContents(data SeedScriptTemplateData) (string, error) {
+	tmpl, err := template.New("assert").Parse(assertTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	buf := bytes.Buffer{}
+	err = tmpl.Execute(&buf, data)
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+//go:embed templates/seedtest.tmpl
+var seedtestTemplate string
+
+func generateSeedtestContents(data SeedtestTemplateData) (string, error) {
+	tmpl, err := template.New("seedtest").Parse(seedtestTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	buf := bytes.Buffer{}
+	err = tmpl.Execute(&buf, data)
 	if err != nil {
 		return "", err
 	}
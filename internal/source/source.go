@@ -0,0 +1,181 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/tab58/go-integral/internal/introspect"
+	"github.com/tab58/go-integral/internal/migrations"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaSource provides a parsed schema, decoupling where the schema comes from (SQL, migrations, a live database,
+// ...) from what is built with it
+type SchemaSource interface {
+	LoadSchema(ctx context.Context) (*nodes.PostgreSQLSchema, error)
+}
+
+// SchemaSourceFunc adapts a function to a SchemaSource
+type SchemaSourceFunc func(ctx context.Context) (*nodes.PostgreSQLSchema, error)
+
+func (f SchemaSourceFunc) LoadSchema(ctx context.Context) (*nodes.PostgreSQLSchema, error) {
+	return f(ctx)
+}
+
+// ParseError is returned when a schema input can't be parsed or applied
+type ParseError struct {
+	Input string
+	Err   error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("unable to parse schema %s: %s", e.Input, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// SQL is a schema source of SQL statements held in memory
+type SQL string
+
+func (s SQL) LoadSchema(ctx context.Context) (*nodes.PostgreSQLSchema, error) {
+	schema, err := nodes.NewPostgreSQLSchema(string(s))
+	if err != nil {
+		return nil, &ParseError{Input: "<sql>", Err: err}
+	}
+	return schema, nil
+}
+
+// Files is a schema source of SQL files and migration directories or globs, applied in order. The input "-" reads
+// SQL from Stdin.
+type Files struct {
+	Inputs []string
+	Stdin  io.Reader
+}
+
+func (f Files) LoadSchema(ctx context.Context) (*nodes.PostgreSQLSchema, error) {
+	// apply the inputs one at a time so that errors point at the file they come from
+	schema := &nodes.PostgreSQLSchema{Tables: make(map[string]nodes.Table), Enums: make(map[string][]string)}
+	readStdin := false
+	for _, input := range f.Inputs {
+		if input != "-" && migrations.IsPattern(input) {
+			loaded, err := migrations.Load(input)
+			if err != nil {
+				return nil, err
+			}
+			if err := migrations.Apply(schema, loaded); err != nil {
+				return nil, &ParseError{Input: input, Err: err}
+			}
+			continue
+		}
+
+		var data []byte
+		var err error
+		if input == "-" {
+			if readStdin {
+				return nil, errors.New("stdin can only be used as an input once")
+			}
+			readStdin = true
+			data, err = io.ReadAll(f.Stdin)
+		} else {
+			data, err = os.ReadFile(input)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema %s: %w", input, err)
+		}
+		if err := schema.Apply(string(data)); err != nil {
+			return nil, &ParseError{Input: input, Err: err}
+		}
+	}
+	return schema, nil
+}
+
+// Migrations is a schema source of the migrations in a directory, or the ones matching a glob
+type Migrations struct {
+	Pattern string
+}
+
+func (m Migrations) LoadSchema(ctx context.Context) (*nodes.PostgreSQLSchema, error) {
+	return Files{Inputs: []string{m.Pattern}}.LoadSchema(ctx)
+}
+
+// Database is a schema source that introspects a live PostgreSQL database
+type Database struct {
+	DSN     string
+	Options introspect.Options
+}
+
+func (d Database) LoadSchema(ctx context.Context) (*nodes.PostgreSQLSchema, error) {
+	return introspect.LoadSchemaFromDSN(ctx, d.DSN, d.Options)
+}
+
+// Static is a schema source of an already loaded schema
+type Static struct {
+	Schema *nodes.PostgreSQLSchema
+}
+
+func (s Static) LoadSchema(ctx context.Context) (*nodes.PostgreSQLSchema, error) {
+	return s.Schema, nil
+}
+
+// Snapshot is a schema source of a schema serialized as JSON, or YAML when the file has a .yaml or .yml extension
+type Snapshot struct {
+	Path string
+}
+
+func (s Snapshot) LoadSchema(ctx context.Context) (*nodes.PostgreSQLSchema, error) {
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema snapshot %s: %w", s.Path, err)
+	}
+	schema := &nodes.PostgreSQLSchema{}
+	switch strings.ToLower(filepath.Ext(s.Path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, schema)
+	default:
+		err = json.Unmarshal(data, schema)
+	}
+	if err != nil {
+		return nil, &ParseError{Input: s.Path, Err: err}
+	}
+	return schema, nil
+}
+
+// IsSnapshot returns whether an input is a serialized schema rather than SQL
+func IsSnapshot(input string) bool {
+	switch strings.ToLower(filepath.Ext(input)) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// FromInputs picks the schema source for command line style inputs: a database DSN, a schema snapshot, or any number of
+// SQL files and migration directories or globs
+func FromInputs(inputs []string, stdin io.Reader, introspectOpts introspect.Options) (SchemaSource, error) {
+	if len(inputs) == 0 {
+		return nil, errors.New("no schema inputs")
+	}
+	if slices.ContainsFunc(inputs, introspect.IsDSN) {
+		if len(inputs) > 1 {
+			return nil, errors.New("a database DSN must be the only schema input")
+		}
+		return Database{DSN: inputs[0], Options: introspectOpts}, nil
+	}
+	if slices.ContainsFunc(inputs, IsSnapshot) {
+		if len(inputs) > 1 {
+			return nil, errors.New("a schema snapshot must be the only schema input")
+		}
+		return Snapshot{Path: inputs[0]}, nil
+	}
+	return Files{Inputs: inputs, Stdin: stdin}, nil
+}
@@ -0,0 +1,135 @@
+package sqlscript
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Literal returns the SQL literal of a value coerced for a column by the seeder, like 42, TRUE, NULL or a quoted string.
+// Strings are escaped for standard_conforming_strings, the default since PostgreSQL 9.1.
+func Literal(column nodes.Column, value any) (string, error) {
+	if value == nil {
+		return "NULL", nil
+	}
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		if !math.IsNaN(v) && !math.IsInf(v, 0) {
+			return strconv.FormatFloat(v, 'g', -1, 64), nil
+		}
+	}
+	text, err := Text(column, value)
+	if err != nil {
+		return "", err
+	}
+	return QuoteString(text), nil
+}
+
+// Text returns the PostgreSQL text representation of a non-NULL value coerced for a column by the seeder, the form
+// the value has in COPY data
+func Text(column nodes.Column, value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			return "NaN", nil
+		case math.IsInf(v, 1):
+			return "Infinity", nil
+		case math.IsInf(v, -1):
+			return "-Infinity", nil
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case time.Time:
+		return formatTime(strings.ToLower(column.DataType), v), nil
+	case []byte:
+		return `\x` + hex.EncodeToString(v), nil
+	case pq.GenericArray:
+		return arrayText(column, v)
+	case driver.Valuer:
+		converted, err := v.Value()
+		if err != nil {
+			return "", fmt.Errorf("column %s: %w", column.Name, err)
+		}
+		if converted == nil {
+			return "", fmt.Errorf("column %s: unexpected NULL array", column.Name)
+		}
+		return Text(column, converted)
+	}
+	return fmt.Sprint(value), nil
+}
+
+// QuoteString quotes a string as an SQL string literal, doubling its single quotes
+func QuoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// QuoteTable returns the quoted name of a table, qualified by its PostgreSQL schema when it has one
+func QuoteTable(table nodes.Table) string {
+	if table.Schema != "" {
+		return pq.QuoteIdentifier(table.Schema) + "." + pq.QuoteIdentifier(table.Name)
+	}
+	return pq.QuoteIdentifier(table.Name)
+}
+
+func formatTime(dataType string, t time.Time) string {
+	switch dataType {
+	case "date":
+		return t.Format("2006-01-02")
+	case "time":
+		return t.Format("15:04:05.999999")
+	case "timetz":
+		return t.Format("15:04:05.999999-07:00")
+	case "timestamp":
+		return t.Format("2006-01-02 15:04:05.999999")
+	}
+	return t.Format("2006-01-02 15:04:05.999999-07:00")
+}
+
+// arrayText formats the elements of an array like the values of the column element type, which the driver would
+// otherwise format with their Go representation
+func arrayText(column nodes.Column, array pq.GenericArray) (string, error) {
+	element := column
+	element.DataType = strings.TrimSuffix(column.DataType, "[]")
+	elements, ok := array.A.([]any)
+	if !ok {
+		value, err := array.Value()
+		if err != nil {
+			return "", fmt.Errorf("column %s: %w", column.Name, err)
+		}
+		return fmt.Sprint(value), nil
+	}
+
+	texts := make([]string, len(elements))
+	for i, value := range elements {
+		if value == nil {
+			texts[i] = "NULL"
+			continue
+		}
+		text, err := Text(element, value)
+		if err != nil {
+			return "", err
+		}
+		texts[i] = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(text) + `"`
+	}
+	return "{" + strings.Join(texts, ",") + "}", nil
+}
@@ -0,0 +1,102 @@
+package sqlscript
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+)
+
+func TestLiteral(t *testing.T) {
+	tests := []struct {
+		name     string
+		dataType string
+		value    any
+		want     string
+	}{
+		{name: "null", dataType: "text", value: nil, want: "NULL"},
+		{name: "boolean", dataType: "boolean", value: true, want: "TRUE"},
+		{name: "integer", dataType: "int8", value: int64(-42), want: "-42"},
+		{name: "real", dataType: "float8", value: 1.5, want: "1.5"},
+		{name: "not a number", dataType: "float8", value: math.NaN(), want: "'NaN'"},
+		{name: "infinity", dataType: "float4", value: math.Inf(-1), want: "'-Infinity'"},
+		{name: "numeric string", dataType: "numeric", value: "19.90", want: "'19.90'"},
+		{name: "single quotes", dataType: "text", value: "it's o'clock", want: "'it''s o''clock'"},
+		{name: "backslashes left as is", dataType: "text", value: `C:\temp\n`, want: `'C:\temp\n'`},
+		{name: "line breaks left as is", dataType: "text", value: "a\nb", want: "'a\nb'"},
+		{name: "bytea", dataType: "bytea", value: []byte{0xde, 0xad, 0xbe, 0xef}, want: `'\xdeadbeef'`},
+		{name: "date", dataType: "date", value: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), want: "'2024-01-02'"},
+		{name: "time", dataType: "time", value: time.Date(0, 1, 1, 13, 14, 15, 0, time.UTC), want: "'13:14:15'"},
+		{
+			name:     "timestamp",
+			dataType: "timestamp",
+			value:    time.Date(2024, 1, 2, 3, 4, 5, 5e8, time.UTC),
+			want:     "'2024-01-02 03:04:05.5'",
+		},
+		{
+			name:     "timestamptz",
+			dataType: "timestamptz",
+			value:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("", 2*60*60)),
+			want:     "'2024-01-02 03:04:05+02:00'",
+		},
+		{
+			name:     "text array",
+			dataType: "text[]",
+			value:    pq.GenericArray{A: []any{"a b", `say "hi"`, nil, `back\slash`, "it's", "NULL"}},
+			want:     `'{"a b","say \"hi\"",NULL,"back\\slash","it''s","NULL"}'`,
+		},
+		{name: "empty array", dataType: "text[]", value: pq.GenericArray{A: []any{}}, want: "'{}'"},
+		{name: "integer array", dataType: "int4[]", value: pq.GenericArray{A: []any{int64(1), int64(2)}}, want: `'{"1","2"}'`},
+		{name: "boolean array", dataType: "bool[]", value: pq.GenericArray{A: []any{true, false}}, want: `'{"true","false"}'`},
+		{name: "bytea array", dataType: "bytea[]", value: pq.GenericArray{A: []any{[]byte{0xab}}}, want: `'{"\\xab"}'`},
+		{
+			name:     "date array",
+			dataType: "date[]",
+			value:    pq.GenericArray{A: []any{time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}},
+			want:     `'{"2024-01-02"}'`,
+		},
+		{name: "typed slice array", dataType: "int8[]", value: pq.GenericArray{A: []int64{1, 2}}, want: "'{1,2}'"},
+		{name: "array literal string", dataType: "text[]", value: `{a,"b c"}`, want: `'{a,"b c"}'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Literal(nodes.Column{Name: "c", DataType: tt.dataType}, tt.value)
+			if err != nil {
+				t.Fatalf("Literal() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Literal() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		name     string
+		dataType string
+		value    any
+		want     string
+	}{
+		{name: "string", dataType: "text", value: "it's", want: "it's"},
+		{name: "boolean", dataType: "boolean", value: false, want: "false"},
+		{name: "infinity", dataType: "float8", value: math.Inf(1), want: "Infinity"},
+		{name: "timetz", dataType: "timetz", value: time.Date(0, 1, 1, 13, 14, 15, 0, time.FixedZone("", -5*60*60)), want: "13:14:15-05:00"},
+		{name: "upper case type", dataType: "DATE", value: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), want: "2024-01-02"},
+		{name: "array", dataType: "text[]", value: pq.GenericArray{A: []any{"a,b", nil}}, want: `{"a,b",NULL}`},
+		{name: "other values", dataType: "point", value: struct{ X, Y int }{1, 2}, want: "{1 2}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Text(nodes.Column{Name: "c", DataType: tt.dataType}, tt.value)
+			if err != nil {
+				t.Fatalf("Text() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Text() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package sqlscript
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"github.com/tab58/go-integral/internal/seeder"
+	"io"
+	"slices"
+)
+
+// maxInsertRows bounds the rows of an INSERT statement of a script, so that large tables don't make huge statements
+const maxInsertRows = 1000
+
+// Options controls how seed scripts are written
+type Options struct {
+	// Transaction wraps the script in BEGIN and COMMIT, so that it inserts every row or none of them
+	Transaction bool
+	// CopyRows is the number of rows from which a table is written as a COPY ... FROM stdin block instead of INSERT
+	// statements, which psql loads much faster. 0 never uses COPY.
+	CopyRows int
+}
+
+// Writer writes a seed script: the rows of each table, given in order of dependency, as INSERT statements or COPY
+// blocks, followed by the statements moving the sequences past the values of the rows
+type Writer struct {
+	w       *bufio.Writer
+	enums   map[string][]string
+	opts    Options
+	started bool
+}
+
+// NewWriter creates a script writer. enums are the labels of the enum types of the schema. Close must be called once
+// the rows are written.
+func NewWriter(w io.Writer, enums map[string][]string, opts Options) *Writer {
+	return &Writer{w: bufio.NewWriter(w), enums: enums, opts: opts}
+}
+
+// Write writes the rows of the tables, in the given order, as a whole script
+func Write(w io.Writer, tables []nodes.Table, data seeder.Dataset, enums map[string][]string, opts Options) error {
+	for tableName := range data {
+		if !slices.ContainsFunc(tables, func(table nodes.Table) bool { return table.Name == tableName }) {
+			return fmt.Errorf("unknown table %q", tableName)
+		}
+	}
+
+	writer := NewWriter(w, enums, opts)
+	for _, table := range tables {
+		if err := writer.WriteTable(table, data[table.Name]); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// WriteTable writes rows of a table, then moves the sequences of the columns the rows set
+func (s *Writer) WriteTable(table nodes.Table, rows []seeder.Row) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	if err := s.WriteRows(table, rows); err != nil {
+		return err
+	}
+	columns, err := rowColumns(table, rows)
+	if err != nil {
+		return err
+	}
+	sequenceColumns := make([]string, 0)
+	for _, column := range columns {
+		if HasSequence(column) {
+			sequenceColumns = append(sequenceColumns, column.Name)
+		}
+	}
+	return s.WriteSequenceResets(table, sequenceColumns)
+}
+
+// WriteRows writes rows of a table, as a COPY block when there are at least Options.CopyRows of them and they set the
+// same columns, and as INSERT statements otherwise, which rows setting no columns always are
+func (s *Writer) WriteRows(table nodes.Table, rows []seeder.Row) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	if err := s.begin(); err != nil {
+		return err
+	}
+	if s.opts.CopyRows > 0 && len(rows) >= s.opts.CopyRows {
+		columns, err := rowColumns(table, rows)
+		if err != nil {
+			return err
+		}
+		if len(columns) > 0 && setSameColumns(rows, len(columns)) {
+			return writeCopy(s.w, table, columns, rows, s.enums)
+		}
+	}
+	for start := 0; start < len(rows); start += maxInsertRows {
+		if err := writeInsert(s.w, table, rows[start:min(start+maxInsertRows, len(rows))], s.enums); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteSequenceResets writes the statements moving the sequences of columns of a table past their largest values
+func (s *Writer) WriteSequenceResets(table nodes.Table, columnNames []string) error {
+	if len(columnNames) == 0 {
+		return nil
+	}
+	if err := s.begin(); err != nil {
+		return err
+	}
+	for _, columnName := range columnNames {
+		if _, err := fmt.Fprintln(s.w, SequenceReset(table, columnName)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close ends the script, committing the transaction if any, and flushes it to the underlying writer
+func (s *Writer) Close() error {
+	if s.started && s.opts.Transaction {
+		if _, err := fmt.Fprintln(s.w, "COMMIT;"); err != nil {
+			return err
+		}
+	}
+	return s.w.Flush()
+}
+
+func (s *Writer) begin() error {
+	if s.started {
+		return nil
+	}
+	s.started = true
+	if !s.opts.Transaction {
+		return nil
+	}
+	_, err := fmt.Fprintln(s.w, "BEGIN;")
+	return err
+}
+
+// setSameColumns returns whether each of the rows sets the given number of columns, which is the number of columns
+// set by any of them
+func setSameColumns(rows []seeder.Row, columnCount int) bool {
+	for _, row := range rows {
+		if len(row) != columnCount {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,175 @@
+package sqlscript
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"github.com/tab58/go-integral/internal/seeder"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of the tests")
+
+const scriptSchema = `
+CREATE SCHEMA app;
+CREATE TYPE mood AS ENUM ('happy', 'sad');
+CREATE TABLE app.users (
+  id serial PRIMARY KEY,
+  email text NOT NULL,
+  note text,
+  mood mood,
+  tags text[],
+  avatar bytea,
+  born date,
+  score float8
+);
+CREATE TABLE posts (
+  id int GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  user_id int NOT NULL REFERENCES app.users (id),
+  body text
+);
+CREATE TABLE events (id bigserial PRIMARY KEY, at timestamptz DEFAULT now());
+`
+
+var scriptData = seeder.Dataset{
+	"users": {
+		{
+			"id":     1,
+			"email":  "o'brien@example.com",
+			"note":   "line\nbreak\ttab\\",
+			"mood":   "happy",
+			"tags":   []any{`say "hi"`, nil, `back\slash`},
+			"avatar": "ab",
+			"born":   "2024-01-02",
+			"score":  1.5,
+		},
+		{"id": 2, "email": "b@example.com", "note": nil, "mood": nil, "tags": nil, "avatar": nil, "born": nil, "score": "NaN"},
+	},
+	"posts":  {{"user_id": 1, "body": "first"}, {"id": 10, "user_id": 2}},
+	"events": {{}, {}},
+}
+
+func scriptTables(t *testing.T) ([]nodes.Table, map[string][]string) {
+	t.Helper()
+	schema, err := nodes.NewPostgreSQLSchema(scriptSchema)
+	if err != nil {
+		t.Fatalf("NewPostgreSQLSchema() error = %v", err)
+	}
+	tables := []nodes.Table{schema.Tables["users"], schema.Tables["posts"], schema.Tables["events"]}
+	return tables, schema.Enums
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   Options
+		golden string
+	}{
+		{name: "insert statements", opts: Options{}, golden: "insert.sql"},
+		{name: "rows below the copy threshold", opts: Options{CopyRows: 3}, golden: "insert.sql"},
+		{name: "copy blocks", opts: Options{CopyRows: 2}, golden: "copy.sql"},
+		{name: "transaction", opts: Options{Transaction: true, CopyRows: 2}, golden: "copy_transaction.sql"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tables, enums := scriptTables(t)
+			var got bytes.Buffer
+			if err := Write(&got, tables, scriptData, enums, tt.opts); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+
+			path := filepath.Join("testdata", tt.golden)
+			if *update {
+				if err := os.WriteFile(path, got.Bytes(), 0o644); err != nil {
+					t.Fatalf("unable to write golden file: %v", err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("unable to read golden file: %v", err)
+			}
+			if diff := cmp.Diff(string(want), got.String()); diff != "" {
+				t.Errorf("output differs from %s (-want +got):\n%s", path, diff)
+			}
+		})
+	}
+}
+
+func TestWriteStatements(t *testing.T) {
+	tables, enums := scriptTables(t)
+	manyUsers := make([]seeder.Row, 2001)
+	for i := range manyUsers {
+		manyUsers[i] = seeder.Row{"email": "a@example.com"}
+	}
+	tests := []struct {
+		name    string
+		data    seeder.Dataset
+		opts    Options
+		want    map[string]int
+		wantErr string
+	}{
+		{
+			name: "no rows in a transaction",
+			data: seeder.Dataset{},
+			opts: Options{Transaction: true},
+			want: map[string]int{},
+		},
+		{
+			name: "insert statements of 1000 rows at most",
+			data: seeder.Dataset{"users": manyUsers},
+			want: map[string]int{"INSERT INTO": 3},
+		},
+		{
+			name: "copy block of any number of rows",
+			data: seeder.Dataset{"users": manyUsers},
+			opts: Options{Transaction: true, CopyRows: 1},
+			want: map[string]int{"BEGIN;": 1, "COPY": 1, "COMMIT;": 1},
+		},
+		{
+			name:    "unknown table",
+			data:    seeder.Dataset{"comments": {{"body": "a"}}},
+			wantErr: `unknown table "comments"`,
+		},
+		{
+			name:    "unknown column",
+			data:    seeder.Dataset{"posts": {{"title": "a"}}},
+			wantErr: `table posts has no column "title"`,
+		},
+		{
+			name:    "value of the wrong type",
+			data:    seeder.Dataset{"users": {{"email": "a@example.com"}, {"email": "b@example.com", "mood": "angry"}}},
+			wantErr: `row 1 of table users: column mood: "angry" is not a label of enum mood`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var script bytes.Buffer
+			err := Write(&script, tables, tt.data, enums, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Write() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			got := make(map[string]int)
+			for _, line := range strings.Split(script.String(), "\n") {
+				for _, prefix := range []string{"BEGIN;", "COMMIT;", "INSERT INTO", "COPY"} {
+					if strings.HasPrefix(line, prefix) {
+						got[prefix]++
+					}
+				}
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("statements mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package sqlscript
+
+import (
+	"fmt"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"github.com/tab58/go-integral/internal/seeder"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// writeInsert writes a multi-row INSERT statement of rows of a table, with their values coerced to the types of their
+// columns. The columns a row leaves out are DEFAULT.
+func writeInsert(w io.Writer, table nodes.Table, rows []seeder.Row, enums map[string][]string) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	columns, err := rowColumns(table, rows)
+	if err != nil {
+		return err
+	}
+	if len(columns) == 0 {
+		for range rows {
+			if _, err := fmt.Fprintf(w, "INSERT INTO %s DEFAULT VALUES;\n", QuoteTable(table)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = pq.QuoteIdentifier(column.Name)
+	}
+	var statement strings.Builder
+	fmt.Fprintf(&statement, "INSERT INTO %s (%s) VALUES\n", QuoteTable(table), strings.Join(names, ", "))
+	for i, row := range rows {
+		literals := make([]string, len(columns))
+		for j, column := range columns {
+			value, ok := row[column.Name]
+			if !ok {
+				literals[j] = "DEFAULT"
+				continue
+			}
+			coerced, err := seeder.Coerce(column, value, enums)
+			if err != nil {
+				return fmt.Errorf("row %d of table %s: %w", i, table.Name, err)
+			}
+			if literals[j], err = Literal(column, coerced); err != nil {
+				return fmt.Errorf("row %d of table %s: %w", i, table.Name, err)
+			}
+		}
+		separator := ",\n"
+		if i == len(rows)-1 {
+			separator = ";\n"
+		}
+		fmt.Fprintf(&statement, "  (%s)%s", strings.Join(literals, ", "), separator)
+	}
+	_, err = io.WriteString(w, statement.String())
+	return err
+}
+
+// SequenceReset returns the statement that moves the sequence of a serial or identity column past the largest value
+// of the column, so that the rows inserted afterwards don't reuse the values of rows inserted with explicit ones
+func SequenceReset(table nodes.Table, columnName string) string {
+	column := pq.QuoteIdentifier(columnName)
+	return fmt.Sprintf("SELECT setval(pg_get_serial_sequence(%s, %s), COALESCE(MAX(%s), 0) + 1, false) FROM %s;",
+		QuoteString(QuoteTable(table)), QuoteString(columnName), column, QuoteTable(table))
+}
+
+// writeCopy writes a COPY ... FROM stdin block of rows of a table that all set the same columns, with their values
+// coerced to the types of their columns
+func writeCopy(w io.Writer, table nodes.Table, columns []nodes.Column, rows []seeder.Row, enums map[string][]string) error {
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = pq.QuoteIdentifier(column.Name)
+	}
+	var block strings.Builder
+	fmt.Fprintf(&block, "COPY %s (%s) FROM stdin;\n", QuoteTable(table), strings.Join(names, ", "))
+	for i, row := range rows {
+		fields := make([]string, len(columns))
+		for j, column := range columns {
+			coerced, err := seeder.Coerce(column, row[column.Name], enums)
+			if err != nil {
+				return fmt.Errorf("row %d of table %s: %w", i, table.Name, err)
+			}
+			if coerced == nil {
+				fields[j] = `\N`
+				continue
+			}
+			text, err := Text(column, coerced)
+			if err != nil {
+				return fmt.Errorf("row %d of table %s: %w", i, table.Name, err)
+			}
+			fields[j] = copyEscaper.Replace(text)
+		}
+		block.WriteString(strings.Join(fields, "\t"))
+		block.WriteString("\n")
+	}
+	block.WriteString("\\.\n")
+	_, err := io.WriteString(w, block.String())
+	return err
+}
+
+// copyEscaper escapes the characters the text format of COPY gives a meaning to
+var copyEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+
+// HasSequence returns whether a column is filled from a sequence when a row leaves it out: serial and identity columns
+// and columns defaulting to nextval()
+func HasSequence(column nodes.Column) bool {
+	switch strings.ToLower(column.DataType) {
+	case "smallserial", "serial", "bigserial":
+		return true
+	}
+	return slices.ContainsFunc(column.Constraints, func(c nodes.ColumnConstraint) bool {
+		return c.Type == nodes.ConstraintInfoTypeIdentity ||
+			(c.Type == nodes.ConstraintInfoTypeDefault && strings.Contains(strings.ToLower(c.ExpressionValue), "nextval("))
+	})
+}
+
+// rowColumns returns the columns set by any of the rows, in table order
+func rowColumns(table nodes.Table, rows []seeder.Row) ([]nodes.Column, error) {
+	set := make(map[string]bool)
+	for _, row := range rows {
+		for columnName := range row {
+			set[columnName] = true
+		}
+	}
+	columns := make([]nodes.Column, 0, len(set))
+	for _, column := range table.Columns {
+		if set[column.Name] {
+			columns = append(columns, column)
+			delete(set, column.Name)
+		}
+	}
+	for columnName := range set {
+		return nil, fmt.Errorf("table %s has no column %q", table.Name, columnName)
+	}
+	return columns, nil
+}
@@ -0,0 +1,11 @@
+INSERT INTO "app"."users" ("id", "email", "note", "mood", "tags", "avatar", "born", "score") VALUES
+  (1, 'o''brien@example.com', 'line
+break	tab\', 'happy', '{"say \"hi\"",NULL,"back\\slash"}', '\x6162', '2024-01-02', 1.5),
+  (2, 'b@example.com', NULL, NULL, NULL, NULL, NULL, 'NaN');
+SELECT setval(pg_get_serial_sequence('"app"."users"', 'id'), COALESCE(MAX("id"), 0) + 1, false) FROM "app"."users";
+INSERT INTO "posts" ("id", "user_id", "body") VALUES
+  (DEFAULT, 1, 'first'),
+  (10, 2, DEFAULT);
+SELECT setval(pg_get_serial_sequence('"posts"', 'id'), COALESCE(MAX("id"), 0) + 1, false) FROM "posts";
+INSERT INTO "events" DEFAULT VALUES;
+INSERT INTO "events" DEFAULT VALUES;
@@ -0,0 +1,87 @@
+package integral
+
+import (
+	"github.com/tab58/go-integral/internal/config"
+	"github.com/tab58/go-integral/internal/fake"
+	"github.com/tab58/go-integral/internal/utils"
+)
+
+// Config is a go-integral.yaml project configuration, converted to the options of the functions of this package. The
+// zero value is the configuration of a project without a configuration file.
+type Config struct {
+	// Path is the file the configuration was loaded from, empty if it wasn't loaded from a file
+	Path string
+	// Schema are the schema inputs, in the form SourceFromInputs takes
+	Schema []string
+	// Fixtures are the fixture files or directories the models literal is generated from
+	Fixtures []string
+	Output   ConfigOutput
+	// Options are the options of the generation of the seed package, from the output and the table, type, naming and
+	// fake value settings of the file
+	Options    Options
+	Introspect IntrospectOptions
+	// FakeOverrides are the fake values of the file, as the overrides of a runtime faker
+	FakeOverrides []FakeOverride
+	// MaskSecret and MaskRules are the masking settings of the rows extract reads
+	MaskSecret string
+	MaskRules  []MaskRule
+}
+
+// ConfigOutput is where the generated files are written
+type ConfigOutput struct {
+	// Dir is the directory of the generated package
+	Dir string
+	// Seedtest also generates the seedtest package, importing the generated package from ImportPath, or from the
+	// import path ImportPath finds when it's empty
+	Seedtest   bool
+	ImportPath string
+}
+
+func newConfig(cfg *config.Config) *Config {
+	return &Config{
+		Path:     cfg.Path,
+		Schema:   cfg.Schema,
+		Fixtures: cfg.Fixtures,
+		Output: ConfigOutput{
+			Dir:        cfg.Output.Dir,
+			Seedtest:   cfg.Output.Seedtest,
+			ImportPath: cfg.Output.ImportPath,
+		},
+		Options:    newOptions(cfg.SeedgenOptions()),
+		Introspect: IntrospectOptions(cfg.IntrospectOptions()),
+		FakeOverrides: utils.Map(cfg.FakeOverrides(), func(override fake.Override) FakeOverride {
+			return FakeOverride{Column: override.Column, Kind: FakeKind(override.Kind)}
+		}),
+		MaskSecret: cfg.Masking.Secret,
+		MaskRules: utils.Map(cfg.Masking.Rules, func(rule config.MaskRule) MaskRule {
+			return MaskRule{Column: rule.Column, Type: rule.Type, Strategy: MaskStrategy(rule.Strategy), Kind: FakeKind(rule.Kind)}
+		}),
+	}
+}
+
+// ConfigFileNames are the names a project configuration file is discovered by, in order of preference
+var ConfigFileNames = config.FileNames
+
+// DiscoverConfig looks for a configuration file in dir and then in each of its parents. It returns an empty path if
+// no configuration file is found.
+func DiscoverConfig(dir string) (string, error) {
+	return config.Discover(dir)
+}
+
+// LoadConfig reads and validates a configuration file. Relative paths in the file are resolved against its directory.
+func LoadConfig(path string) (*Config, error) {
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return newConfig(cfg), nil
+}
+
+// ParseConfig decodes and validates the YAML contents of a configuration file
+func ParseConfig(data []byte) (*Config, error) {
+	cfg, err := config.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return newConfig(cfg), nil
+}
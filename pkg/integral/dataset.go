@@ -0,0 +1,130 @@
+package integral
+
+import (
+	"context"
+	"github.com/tab58/go-integral/internal/dataset"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"github.com/tab58/go-integral/internal/seeder"
+	"io"
+)
+
+// DatasetPlan sets the number of rows generated for each table, either as a count or relative to a table it
+// references. Tables the plan leaves out get no rows.
+type DatasetPlan struct {
+	// Counts are the numbers of rows of tables, keyed by table name
+	Counts map[string]int
+	// PerParent are the numbers of rows of tables for each row of the table a foreign key references, keyed by the
+	// "table.column" name of the foreign key column, or by the table name when it has a single foreign key
+	PerParent map[string]Cardinality
+}
+
+// Cardinality is the range of the number of rows generated per row of a referenced table, drawn uniformly between Min
+// and Max, inclusive
+type Cardinality struct {
+	Min int
+	Max int
+}
+
+func (p DatasetPlan) dataset() dataset.Plan {
+	plan := dataset.Plan{Counts: p.Counts}
+	if p.PerParent != nil {
+		plan.PerParent = make(map[string]dataset.Cardinality, len(p.PerParent))
+		for key, cardinality := range p.PerParent {
+			plan.PerParent[key] = dataset.Cardinality(cardinality)
+		}
+	}
+	return plan
+}
+
+// DatasetSink receives the generated rows of a dataset, table by table in insert order
+type DatasetSink interface {
+	// WriteRow receives a generated row of a table
+	WriteRow(table Table, row Row) error
+	// EndTable is called after the last row of a table, with the serial and identity columns the rows set explicitly,
+	// whose sequences must be moved past the generated values
+	EndTable(table Table, sequenceColumns []string) error
+}
+
+// DatasetWriter is a dataset sink writing the rows to a database, an SQL script or CSV files
+type DatasetWriter struct {
+	sink   dataset.Sink
+	tables map[string]nodes.Table
+	close  func() error
+}
+
+// WriteRow writes a row of a table of the schema of the writer
+func (w *DatasetWriter) WriteRow(table Table, row Row) error {
+	return w.sink.WriteRow(w.tables[table.Name], seeder.Row(row))
+}
+
+// EndTable writes the rows of a table the writer holds, and moves the sequences of the columns past their values
+func (w *DatasetWriter) EndTable(table Table, sequenceColumns []string) error {
+	return w.sink.EndTable(w.tables[table.Name], sequenceColumns)
+}
+
+// Close ends the SQL script or the CSV files, once the rows are generated
+func (w *DatasetWriter) Close() error {
+	if w.close == nil {
+		return nil
+	}
+	return w.close()
+}
+
+// sinkAdapter passes the rows of the generator to a DatasetSink
+type sinkAdapter struct {
+	sink DatasetSink
+}
+
+func (a sinkAdapter) WriteRow(table nodes.Table, row seeder.Row) error {
+	return a.sink.WriteRow(newTable(table), Row(row))
+}
+
+func (a sinkAdapter) EndTable(table nodes.Table, sequenceColumns []string) error {
+	return a.sink.EndTable(newTable(table), sequenceColumns)
+}
+
+// GenerateDataset generates the rows of a plan with values from the faker, with valid foreign keys, and streams them to
+// the sink. It returns the number of rows generated for each table.
+func GenerateDataset(seeder *Seeder, faker *Faker, plan DatasetPlan, sink DatasetSink) (map[string]int, error) {
+	var internalSink dataset.Sink = sinkAdapter{sink: sink}
+	if writer, ok := sink.(*DatasetWriter); ok {
+		internalSink = writer.sink
+	}
+	return dataset.Generate(seeder.seeder, faker.faker, plan.dataset(), internalSink)
+}
+
+// ParseDatasetCount parses a table row count of the form "users=10000"
+func ParseDatasetCount(s string) (tableName string, count int, err error) {
+	return dataset.ParseCount(s)
+}
+
+// ParseCardinality parses a per-parent cardinality of the form "orders.user_id=3-8", or "orders=3" for exactly 3 rows
+// per parent
+func ParseCardinality(s string) (key string, cardinality Cardinality, err error) {
+	key, c, err := dataset.ParseCardinality(s)
+	return key, Cardinality(c), err
+}
+
+// NewDatabaseSink creates a dataset sink inserting the rows into a database with multi-row INSERT statements
+func NewDatabaseSink(ctx context.Context, seeder *Seeder, db Querier) *DatasetWriter {
+	return &DatasetWriter{
+		sink:   dataset.NewDatabaseSink(ctx, seeder.seeder, db),
+		tables: seeder.schema.schema.Tables,
+	}
+}
+
+// NewSQLSink creates a dataset sink writing the rows as an SQL script, to be closed once the rows are generated
+func NewSQLSink(w io.Writer, schema *Schema, opts SQLScriptOptions) *DatasetWriter {
+	sink := dataset.NewSQLSink(w, schema.schema.Enums, opts.sqlscript())
+	return &DatasetWriter{sink: sink, tables: schema.schema.Tables, close: sink.Close}
+}
+
+// NewCSVSink creates a dataset sink writing a <table>.csv file per table into a directory, in the format
+// LoadCSVFixtures reads, to be closed once the rows are generated
+func NewCSVSink(dir string, schema *Schema) (*DatasetWriter, error) {
+	sink, err := dataset.NewCSVSink(dir, schema.schema.Enums)
+	if err != nil {
+		return nil, err
+	}
+	return &DatasetWriter{sink: sink, tables: schema.schema.Tables, close: sink.Close}, nil
+}
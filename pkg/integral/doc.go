@@ -0,0 +1,10 @@
+// Package integral is the public API of go-integral: parsing a PostgreSQL schema from SQL, migrations, a live
+// database or a snapshot, building the graph of table dependencies, and generating the Go seed package in memory or
+// on disk. cmd/generate is a thin command line wrapper around it.
+//
+// This package follows semantic versioning: within a major version, its functions, types, and the fields and methods
+// of its types are only added, never removed or changed incompatibly. Its types are declared here rather than
+// aliased from the packages under internal/, which may change in any release, so that the promise covers everything
+// the package exposes. The types holding parsed or loaded state, like Schema, Generator, Seeder and Fixtures, are
+// opaque and only offer their methods.
+package integral
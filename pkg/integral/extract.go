@@ -0,0 +1,59 @@
+package integral
+
+import (
+	"context"
+	"github.com/tab58/go-integral/internal/extract"
+	"github.com/tab58/go-integral/internal/fixtures"
+	"io"
+)
+
+// RowSelection is a set of rows an extract starts from
+type RowSelection struct {
+	Table string
+	// Where is the SQL condition of the rows, inserted in the query as is. Empty selects every row of the table.
+	Where string
+}
+
+// ExtractOptions controls which rows an extract takes besides the selected ones and the rows they reference
+type ExtractOptions struct {
+	// Children also takes the rows referencing the selected rows, recursively, along with the rows they reference
+	Children bool
+}
+
+// ParseRowSelection parses a selection like "orders WHERE id = 42", or a table name alone for all of its rows
+func ParseRowSelection(s string) (RowSelection, error) {
+	selection, err := extract.ParseSelection(s)
+	return RowSelection(selection), err
+}
+
+// ExtractRows reads the selected rows from a database along with the rows they reference, recursively, and with
+// ExtractOptions.Children the rows referencing them, so that the result can re-seed another database on its own. The
+// result can be inserted with Seeder.Seed, written with WriteSQLScript, or turned into fixtures with
+// FixturesFromDataset.
+func ExtractRows(ctx context.Context, seeder *Seeder, db Querier, selections []RowSelection, opts ExtractOptions) (Dataset, error) {
+	selected := make([]extract.Selection, len(selections))
+	for i, selection := range selections {
+		selected[i] = extract.Selection(selection)
+	}
+	data, err := extract.Rows(ctx, seeder.seeder, db, selected, extract.Options(opts))
+	if err != nil {
+		return nil, err
+	}
+	return newDataset(data), nil
+}
+
+// FixturesFromDataset turns rows read from a database into fixtures, labelled after their tables and primary keys
+// like orders_42, with the foreign keys written as references to these labels. Generator.GenerateFixturesFile turns
+// them into a models literal, and WriteFixturesYAML into a fixture file.
+func FixturesFromDataset(seeder *Seeder, data Dataset) (*Fixtures, error) {
+	f, err := fixtures.FromDataset(seeder.seeder.Tables(), data.seeder())
+	if err != nil {
+		return nil, err
+	}
+	return &Fixtures{fixtures: f}, nil
+}
+
+// WriteFixturesYAML writes fixtures as a fixture file, with the tables in insert order
+func WriteFixturesYAML(w io.Writer, seeder *Seeder, f *Fixtures) error {
+	return f.fixtures.WriteYAML(w, seeder.seeder.Tables())
+}
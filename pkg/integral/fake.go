@@ -0,0 +1,51 @@
+package integral
+
+import (
+	"github.com/tab58/go-integral/internal/fake"
+	"github.com/tab58/go-integral/internal/utils"
+	"time"
+)
+
+// Faker generates realistic values for columns whose kind is known from their name, like emails, names or creation
+// times, and random values of their type for the others. Fakers created with the same seed and options generate the
+// same values.
+type Faker struct {
+	faker *fake.Faker
+}
+
+// FakerOptions controls the values of a faker
+type FakerOptions struct {
+	// Now is the time past and future times are relative to, 2025-01-01 UTC when it's zero so that the values are the
+	// same on any day
+	Now time.Time
+	// Overrides set the kinds of columns, taking precedence over the kinds guessed from their names
+	Overrides []FakeOverride
+}
+
+// FakeKind is the sort of realistic value a column holds, like email or past_time. The kind random turns off realistic
+// values.
+type FakeKind string
+
+// FakeOverride sets the kind of the columns matching Column, a "table.column" name or glob like "*.phone"
+type FakeOverride struct {
+	Column string
+	Kind   FakeKind
+}
+
+// FakeTextKinds and FakeTimeKinds are the kinds of realistic values of text columns and of date and time columns
+var (
+	FakeTextKinds = utils.Map(fake.TextKinds, func(kind fake.Kind) FakeKind { return FakeKind(kind) })
+	FakeTimeKinds = utils.Map(fake.TimeKinds, func(kind fake.Kind) FakeKind { return FakeKind(kind) })
+)
+
+// NewFaker creates a faker whose values are determined by the seed and the options
+func NewFaker(seed uint64, opts FakerOptions) *Faker {
+	faker := fake.New(seed)
+	if !opts.Now.IsZero() {
+		faker.Now = opts.Now
+	}
+	faker.Overrides = utils.Map(opts.Overrides, func(override FakeOverride) fake.Override {
+		return fake.Override{Column: override.Column, Kind: fake.Kind(override.Kind)}
+	})
+	return &Faker{faker: faker}
+}
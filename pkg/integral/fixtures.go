@@ -0,0 +1,72 @@
+package integral
+
+import (
+	"context"
+	"github.com/tab58/go-integral/internal/fixtures"
+	"github.com/tab58/go-integral/internal/seedgen"
+)
+
+// Fixtures holds the records of fixture files, by table. In a fixture file, each top level key is a table holding
+// either a mapping of labels to records or a sequence of records, and a foreign key column can reference a record of
+// the referenced table by label with a "$" prefix, like `author_id: $alice`.
+type Fixtures struct {
+	fixtures *fixtures.Fixtures
+}
+
+// DefaultFixturesVarName is the name of the variable Generator.GenerateFixturesFile declares by default
+const DefaultFixturesVarName = seedgen.DefaultFixturesVarName
+
+// LoadFixtures reads YAML or JSON fixture files, or the fixture files of directories, in order
+func LoadFixtures(paths ...string) (*Fixtures, error) {
+	f, err := fixtures.Load(paths...)
+	if err != nil {
+		return nil, err
+	}
+	return &Fixtures{fixtures: f}, nil
+}
+
+// ParseFixtures decodes a YAML or JSON fixture document
+func ParseFixtures(data []byte) (*Fixtures, error) {
+	f, err := fixtures.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Fixtures{fixtures: f}, nil
+}
+
+// SeedFixtures inserts fixtures at runtime, resolving references to the values the referenced records were stored
+// with. It returns the stored rows by table and label.
+func SeedFixtures(ctx context.Context, seeder *Seeder, db Querier, f *Fixtures) (map[string]map[string]Row, error) {
+	stored, err := f.fixtures.Seed(ctx, seeder.seeder, db)
+	if err != nil {
+		return nil, err
+	}
+	rows := make(map[string]map[string]Row, len(stored))
+	for tableName, labelled := range stored {
+		rows[tableName] = make(map[string]Row, len(labelled))
+		for label, row := range labelled {
+			rows[tableName][label] = Row(row)
+		}
+	}
+	return rows, nil
+}
+
+// CSVOptions controls how LoadCSVFixtures reads CSV files
+type CSVOptions struct {
+	// Comma is the field delimiter, a comma by default
+	Comma rune
+	// NullValues are the cell values read as NULL, an empty cell and \N by default. A NULL in a NOT NULL column that
+	// has a default leaves the column out of the row, so the database fills it.
+	NullValues []string
+}
+
+// LoadCSVFixtures reads the <table>.csv files of a directory as fixtures of the tables of a schema, matching the
+// header row to the columns and parsing the cells according to the column types. The result is seeded like any other
+// fixtures, in dependency order.
+func LoadCSVFixtures(dir string, schema *Schema, opts CSVOptions) (*Fixtures, error) {
+	f, err := fixtures.LoadCSV(dir, schema.schema, fixtures.CSVOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	return &Fixtures{fixtures: f}, nil
+}
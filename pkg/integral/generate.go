@@ -0,0 +1,240 @@
+package integral
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/tab58/go-integral/internal/seedgen"
+	"github.com/tab58/go-integral/internal/utils"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Options controls which tables are generated and how the generated Go package looks. The zero value generates every
+// table with the default names.
+type Options struct {
+	// PackageName is the package clause of every generated file
+	PackageName string
+	// SeedFuncName is the name of the exported function that seeds the whole schema
+	SeedFuncName string
+	// ModelsTypeName is the name of the exported struct holding the records for every table
+	ModelsTypeName string
+	// IncludeTables lists the tables, by name or glob, that code is generated for along with the tables they require
+	// through NOT NULL foreign keys. Every table is included when empty.
+	IncludeTables []string
+	// ExcludeTables lists the tables, by name or glob, that no code is generated for
+	ExcludeTables []string
+	// TypeOverrides replaces the Go type inferred for a column or a database type
+	TypeOverrides []TypeOverride
+	// Naming tweaks how SQL identifiers are converted to Go identifiers
+	Naming NamingOptions
+	// FakeValues sets the kinds of the values the factories fill columns with, taking precedence over the kinds
+	// guessed from the column names
+	FakeValues []FakeValue
+}
+
+// TypeOverride sets the Go type of either a single column (Column, as "table.column") or of every column with a
+// database type (DBType). Import is the package path that has to be imported for GoType, if any.
+type TypeOverride struct {
+	Column string
+	DBType string
+	GoType string
+	Import string
+}
+
+// NamingOptions tweaks how SQL identifiers are converted to Go identifiers
+type NamingOptions struct {
+	// Acronyms are words that are fully upper-cased in Go identifiers, e.g. "id" turns user_id into UserID
+	Acronyms []string
+	// StripTablePrefixes are removed from table names before they are converted to Go identifiers
+	StripTablePrefixes []string
+}
+
+// FakeValue sets the kind of fake values, like email or past_time, of the columns matching Column, a "table.column"
+// name or glob. The kind random turns off realistic values for the columns.
+type FakeValue struct {
+	Column string
+	Kind   string
+}
+
+func (o Options) seedgen() seedgen.Options {
+	return seedgen.Options{
+		PackageName:    o.PackageName,
+		SeedFuncName:   o.SeedFuncName,
+		ModelsTypeName: o.ModelsTypeName,
+		IncludeTables:  o.IncludeTables,
+		ExcludeTables:  o.ExcludeTables,
+		TypeOverrides: utils.Map(o.TypeOverrides, func(override TypeOverride) seedgen.TypeOverride {
+			return seedgen.TypeOverride(override)
+		}),
+		Naming: seedgen.NamingOptions(o.Naming),
+		FakeValues: utils.Map(o.FakeValues, func(value FakeValue) seedgen.FakeValue {
+			return seedgen.FakeValue(value)
+		}),
+	}
+}
+
+func newOptions(opts seedgen.Options) Options {
+	return Options{
+		PackageName:    opts.PackageName,
+		SeedFuncName:   opts.SeedFuncName,
+		ModelsTypeName: opts.ModelsTypeName,
+		IncludeTables:  opts.IncludeTables,
+		ExcludeTables:  opts.ExcludeTables,
+		TypeOverrides: utils.Map(opts.TypeOverrides, func(override seedgen.TypeOverride) TypeOverride {
+			return TypeOverride(override)
+		}),
+		Naming: NamingOptions(opts.Naming),
+		FakeValues: utils.Map(opts.FakeValues, func(value seedgen.FakeValue) FakeValue {
+			return FakeValue(value)
+		}),
+	}
+}
+
+const (
+	DefaultPackageName    = seedgen.DefaultPackageName
+	DefaultSeedFuncName   = seedgen.DefaultSeedFuncName
+	DefaultModelsTypeName = seedgen.DefaultModelsTypeName
+	// SeedtestPackageName is the name of the package Generator.GenerateSeedtestFile generates, in a directory of the
+	// same name
+	SeedtestPackageName = seedgen.SeedtestPackageName
+)
+
+// Generator generates the Go seed package of a schema
+type Generator struct {
+	builder *seedgen.Builder
+}
+
+// File is a generated Go file, held in memory. Filename is relative to the directory of the generated package.
+type File struct {
+	Filename string
+	Contents string
+}
+
+// NewGenerator validates the options and prepares the generation of the seed package of a schema
+func NewGenerator(schema *Schema, opts Options) (*Generator, error) {
+	builder, err := seedgen.NewFromSchema(schema.schema, opts.seedgen())
+	if err != nil {
+		return nil, err
+	}
+	return &Generator{builder: builder}, nil
+}
+
+// NewGeneratorFromSource loads the schema from a source and prepares the generation of its seed package
+func NewGeneratorFromSource(ctx context.Context, src SchemaSource, opts Options) (*Generator, error) {
+	schema, err := src.LoadSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewGenerator(schema, opts)
+}
+
+// Tables returns the tables code is generated for, in record insert order
+func (g *Generator) Tables() []Table {
+	return utils.Map(g.builder.Tables(), newTable)
+}
+
+// GenerateFiles generates the files of the seed package
+func (g *Generator) GenerateFiles() ([]File, error) {
+	files, err := g.builder.GenerateTemplateFiles()
+	if err != nil {
+		return nil, err
+	}
+	return utils.Map(files, newFile), nil
+}
+
+// GenerateSeedtestFile generates the seedtest package of helpers for go test, which imports the seed package from
+// importPath
+func (g *Generator) GenerateSeedtestFile(importPath string) (File, error) {
+	file, err := g.builder.GenerateSeedtestFile(importPath)
+	if err != nil {
+		return File{}, err
+	}
+	return newFile(file), nil
+}
+
+// GenerateFixturesFile generates a file of the seed package declaring a variable of the models type, named varName,
+// holding the records of fixtures
+func (g *Generator) GenerateFixturesFile(f *Fixtures, varName string) (File, error) {
+	file, err := g.builder.GenerateFixturesFile(f.fixtures, varName)
+	if err != nil {
+		return File{}, err
+	}
+	return newFile(file), nil
+}
+
+// GenerateFixturesSQL renders fixtures as an SQL script inserting their records in dependency order
+func (g *Generator) GenerateFixturesSQL(f *Fixtures, opts SQLScriptOptions) ([]byte, error) {
+	return g.builder.GenerateFixturesSQL(f.fixtures, opts.sqlscript())
+}
+
+func newFile(file seedgen.GolangFile) File {
+	return File(file)
+}
+
+// Generate generates the files of the seed package of a schema in memory
+func Generate(schema *Schema, opts Options) ([]File, error) {
+	generator, err := NewGenerator(schema, opts)
+	if err != nil {
+		return nil, err
+	}
+	return generator.GenerateFiles()
+}
+
+// WriteFiles writes generated files to a directory, creating it and the directories of the files, like the seedtest
+// package, if needed
+func WriteFiles(dir string, files []File) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	for _, file := range files {
+		path := filepath.Join(dir, file.Filename)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create directory of %s: %w", path, err)
+		}
+		if err := os.WriteFile(path, []byte(file.Contents), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// ImportPath returns the import path of the package in a directory, from the module path of the nearest go.mod file
+// of the directory or of its parents
+func ImportPath(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for moduleDir := abs; ; moduleDir = filepath.Dir(moduleDir) {
+		data, err := os.ReadFile(filepath.Join(moduleDir, "go.mod"))
+		if errors.Is(err, fs.ErrNotExist) {
+			if filepath.Dir(moduleDir) == moduleDir {
+				return "", fmt.Errorf("no go.mod file found for %s", dir)
+			}
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+
+		modulePath := ""
+		for _, line := range strings.Split(string(data), "\n") {
+			if rest, ok := strings.CutPrefix(strings.TrimSpace(line), "module"); ok && rest != strings.TrimLeft(rest, " \t") {
+				modulePath = strings.Trim(strings.TrimSpace(rest), `"`)
+				break
+			}
+		}
+		if modulePath == "" {
+			return "", fmt.Errorf("no module path in %s", filepath.Join(moduleDir, "go.mod"))
+		}
+		rel, err := filepath.Rel(moduleDir, abs)
+		if err != nil {
+			return "", err
+		}
+		return path.Join(modulePath, filepath.ToSlash(rel)), nil
+	}
+}
@@ -0,0 +1,97 @@
+package integral
+
+import (
+	"github.com/tab58/go-integral/internal/graph"
+	"github.com/tab58/go-integral/internal/parse"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"github.com/tab58/go-integral/internal/utils"
+	"io"
+)
+
+// Graph is the graph of the tables of a schema, with an edge from each referenced table to the tables referencing it
+type Graph struct {
+	graph *parse.TableGraph
+}
+
+// Tables returns the tables of the graph, sorted by name
+func (g *Graph) Tables() []Table {
+	return utils.Map(g.graph.Nodes, func(node *graph.Node[nodes.Table]) Table {
+		return newTable(node.Value)
+	})
+}
+
+// InsertOrder returns the tables of the graph in an order where every table comes after the tables it references
+func (g *Graph) InsertOrder() ([]Table, error) {
+	tables, err := g.graph.TopologicalSort()
+	if err != nil {
+		return nil, err
+	}
+	return utils.Map(tables, newTable), nil
+}
+
+// Dependencies returns the edges of the graph, one for each column of a foreign key
+func (g *Graph) Dependencies() []Dependency {
+	return utils.Map(g.graph.Edges, func(edge *graph.DirectedEdge[nodes.Table, parse.TableDependency]) Dependency {
+		return Dependency{
+			Table:            edge.Value.ToNode.TableName,
+			Column:           edge.Value.ToNode.TableColumn,
+			ReferencedTable:  edge.Value.FromNode.TableName,
+			ReferencedColumn: edge.Value.FromNode.TableColumn,
+		}
+	})
+}
+
+// Dependency is a column of a foreign key, from the referencing table to the referenced one
+type Dependency struct {
+	Table            string
+	Column           string
+	ReferencedTable  string
+	ReferencedColumn string
+}
+
+// ExportOptions controls how the graph is rendered as DOT or Mermaid
+type ExportOptions struct {
+	// ClusterBySchema groups the tables of each PostgreSQL schema in a DOT cluster
+	ClusterBySchema bool
+}
+
+// ErrCycle is returned when the insert order of tables can't be found because their foreign keys form a cycle
+var ErrCycle = graph.ErrCycle
+
+// BuildGraph builds the graph of the tables of a schema
+func BuildGraph(schema *Schema) (*Graph, error) {
+	schemaGraph, err := parse.BuildTableGraph(schema.schema)
+	if err != nil {
+		return nil, err
+	}
+	return &Graph{graph: schemaGraph}, nil
+}
+
+// InsertOrder returns the tables of a schema in an order where every table comes after the tables it references
+func InsertOrder(schema *Schema) ([]Table, error) {
+	schemaGraph, err := BuildGraph(schema)
+	if err != nil {
+		return nil, err
+	}
+	return schemaGraph.InsertOrder()
+}
+
+// FocusGraph restricts the graph to the given tables and the tables within depth foreign keys of them, in either
+// direction. A negative depth keeps every connected table.
+func FocusGraph(schemaGraph *Graph, tableNames []string, depth int) (*Graph, error) {
+	focused, err := parse.FocusTableGraph(schemaGraph.graph, tableNames, depth)
+	if err != nil {
+		return nil, err
+	}
+	return &Graph{graph: focused}, nil
+}
+
+// WriteDOT renders the graph as a Graphviz digraph
+func WriteDOT(w io.Writer, schemaGraph *Graph, opts ExportOptions) error {
+	return parse.WriteDOT(w, schemaGraph.graph, parse.ExportOptions(opts))
+}
+
+// WriteMermaid renders the graph as a Mermaid ER diagram
+func WriteMermaid(w io.Writer, schemaGraph *Graph, opts ExportOptions) error {
+	return parse.WriteMermaid(w, schemaGraph.graph, parse.ExportOptions(opts))
+}
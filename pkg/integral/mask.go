@@ -0,0 +1,60 @@
+package integral
+
+import (
+	"github.com/tab58/go-integral/internal/fake"
+	"github.com/tab58/go-integral/internal/mask"
+	"github.com/tab58/go-integral/internal/utils"
+)
+
+// MaskRule masks the values of the columns matching Column, a "table.column" name or glob like "*.email", or of the
+// columns of type Type, like "inet"
+type MaskRule struct {
+	Column   string
+	Type     string
+	Strategy MaskStrategy
+	// Kind is the kind of the fake values of MaskFake, guessed from the column name when empty
+	Kind FakeKind
+}
+
+// MaskStrategy is how a rule masks the values of the columns it applies to
+type MaskStrategy string
+
+const (
+	// MaskHash replaces values with a keyed hash: hex text, a UUID, a non-negative integer or bytes, depending on the
+	// column type
+	MaskHash MaskStrategy = "hash"
+	// MaskFake replaces values with fake values of the kind of the column
+	MaskFake MaskStrategy = "fake"
+	// MaskNull replaces values with null
+	MaskNull MaskStrategy = "null"
+	// MaskKeepFormat replaces the letters and digits of values with other letters and digits, keeping their case, the
+	// other characters and the length. It doesn't apply to key columns, whose distinct values it could make equal.
+	MaskKeepFormat MaskStrategy = "keep_format"
+)
+
+// Masker masks the values of rows consistently, so that a value masked in several tables stays the same
+type Masker struct {
+	masker *mask.Masker
+}
+
+// NewMasker validates the rules and creates a masker, whose masked values are derived from keyed hashes of the
+// source values
+func NewMasker(rules []MaskRule, secret string) (*Masker, error) {
+	masker, err := mask.New(utils.Map(rules, func(rule MaskRule) mask.Rule {
+		return mask.Rule{Column: rule.Column, Type: rule.Type, Strategy: mask.Strategy(rule.Strategy), Kind: fake.Kind(rule.Kind)}
+	}), secret)
+	if err != nil {
+		return nil, err
+	}
+	return &Masker{masker: masker}, nil
+}
+
+// Mask returns the rows of a dataset of the schema, like the ones of ExtractRows, with the values of the columns the
+// rules match masked
+func (m *Masker) Mask(schema *Schema, data Dataset) (Dataset, error) {
+	masked, err := m.masker.Mask(schema.schema, data.seeder())
+	if err != nil {
+		return nil, err
+	}
+	return newDataset(masked), nil
+}
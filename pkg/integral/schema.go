@@ -0,0 +1,244 @@
+package integral
+
+import (
+	"context"
+	"errors"
+	"github.com/tab58/go-integral/internal/introspect"
+	"github.com/tab58/go-integral/internal/parse/nodes"
+	"github.com/tab58/go-integral/internal/source"
+	"github.com/tab58/go-integral/internal/utils"
+	"io"
+	"maps"
+	"slices"
+)
+
+// Schema is a parsed PostgreSQL schema. It serializes to a versioned JSON or YAML document, which SnapshotSource
+// reads back.
+type Schema struct {
+	schema *nodes.PostgreSQLSchema
+}
+
+// Tables returns the tables of the schema, sorted by name
+func (s *Schema) Tables() []Table {
+	return utils.Map(slices.Sorted(maps.Keys(s.schema.Tables)), func(tableName string) Table {
+		return newTable(s.schema.Tables[tableName])
+	})
+}
+
+// Table returns the table of the schema with the given name
+func (s *Schema) Table(name string) (Table, bool) {
+	table, ok := s.schema.Tables[name]
+	if !ok {
+		return Table{}, false
+	}
+	return newTable(table), true
+}
+
+// Enums returns the labels of each enum type of the schema, in sort order
+func (s *Schema) Enums() map[string][]string {
+	enums := make(map[string][]string, len(s.schema.Enums))
+	for name, labels := range s.schema.Enums {
+		enums[name] = slices.Clone(labels)
+	}
+	return enums
+}
+
+// MarshalJSON writes the schema as a versioned JSON document
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	return s.schema.MarshalJSON()
+}
+
+// MarshalYAML writes the schema as a versioned YAML document
+func (s *Schema) MarshalYAML() (any, error) {
+	return s.schema.MarshalYAML()
+}
+
+// Table is a table of a schema
+type Table struct {
+	Name string
+	// Schema is the PostgreSQL schema the table belongs to, empty when the table name isn't qualified
+	Schema     string
+	PrimaryKey []string
+	Columns    []Column
+	// ForeignKeys are the foreign keys of the table, with the columns of a composite foreign key grouped together
+	ForeignKeys []ForeignKey
+	// UniqueKeys are the sets of columns identifying a row: the primary key first, then the columns of the unique
+	// constraints and unique indexes
+	UniqueKeys [][]string
+	// Checks are the expressions of the CHECK constraints of the table
+	Checks []string
+}
+
+// Column is a column of a table
+type Column struct {
+	Name     string
+	DataType string
+	// TypeModifiers are the parameters of the data type, e.g. [255] for varchar(255) or [10, 2] for numeric(10, 2)
+	TypeModifiers []int32
+	// NotNull is set for the columns that can't be null, including the columns of the primary key
+	NotNull bool
+	// Default is the expression of the DEFAULT clause of the column, if HasDefault is set
+	Default    string
+	HasDefault bool
+	// Identity is set for GENERATED AS IDENTITY columns
+	Identity bool
+	// Generated is the expression of a GENERATED ALWAYS AS column, empty for the other columns
+	Generated string
+	// Checks are the expressions of the CHECK constraints of the column
+	Checks []string
+}
+
+// ForeignKey is a foreign key of a table
+type ForeignKey struct {
+	Name              string
+	Columns           []string
+	ReferencedTable   string
+	ReferencedColumns []string
+}
+
+func newTable(table nodes.Table) Table {
+	return Table{
+		Name:       table.Name,
+		Schema:     table.Schema,
+		PrimaryKey: slices.Clone(table.PrimaryKey),
+		Columns: utils.Map(table.Columns, func(column nodes.Column) Column {
+			return newColumn(table, column)
+		}),
+		ForeignKeys: utils.Map(table.ForeignKeys(), func(foreignKey nodes.ForeignKey) ForeignKey {
+			return ForeignKey(foreignKey)
+		}),
+		UniqueKeys: utils.Map(table.UniqueKeys(), slices.Clone[[]string]),
+		Checks: utils.Reduce(table.Constraints, func(checks []string, constraint nodes.TableConstraint) []string {
+			if info, ok := constraint.Constraint.(*nodes.CheckConstraintInfo); ok {
+				return append(checks, info.Expression)
+			}
+			return checks
+		}, []string(nil)),
+	}
+}
+
+func newColumn(table nodes.Table, column nodes.Column) Column {
+	c := Column{
+		Name:          column.Name,
+		DataType:      column.DataType,
+		TypeModifiers: slices.Clone(column.TypeModifiers),
+		NotNull:       table.IsNotNullColumn(column.Name),
+	}
+	for _, constraint := range column.Constraints {
+		switch constraint.Type {
+		case nodes.ConstraintInfoTypeDefault:
+			c.Default, c.HasDefault = constraint.ExpressionValue, true
+		case nodes.ConstraintInfoTypeIdentity:
+			c.Identity = true
+		case nodes.ConstraintInfoTypeGenerated:
+			c.Generated = constraint.ExpressionValue
+		case nodes.ConstraintInfoTypeCheck:
+			c.Checks = append(c.Checks, constraint.ExpressionValue)
+		}
+	}
+	return c
+}
+
+// SchemaFormatVersion is the version of the JSON and YAML documents a Schema serializes to
+const SchemaFormatVersion = nodes.SchemaFormatVersion
+
+// SchemaSource provides a parsed schema from wherever it's defined
+type SchemaSource interface {
+	LoadSchema(ctx context.Context) (*Schema, error)
+}
+
+// SchemaSourceFunc adapts a function to a SchemaSource
+type SchemaSourceFunc func(ctx context.Context) (*Schema, error)
+
+// LoadSchema calls the function
+func (f SchemaSourceFunc) LoadSchema(ctx context.Context) (*Schema, error) {
+	return f(ctx)
+}
+
+// internalSource is a SchemaSource of the sources of package source
+type internalSource struct {
+	source source.SchemaSource
+}
+
+func (s internalSource) LoadSchema(ctx context.Context) (*Schema, error) {
+	schema, err := s.source.LoadSchema(ctx)
+	if err != nil {
+		var parseErr *source.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, &ParseError{Input: parseErr.Input, Err: parseErr.Err}
+		}
+		return nil, err
+	}
+	return &Schema{schema: schema}, nil
+}
+
+// ParseError is returned when a schema input can't be parsed or applied
+type ParseError struct {
+	Input string
+	Err   error
+}
+
+func (e *ParseError) Error() string {
+	return (&source.ParseError{Input: e.Input, Err: e.Err}).Error()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// IntrospectOptions controls what is loaded from a live database
+type IntrospectOptions struct {
+	// Schemas are the PostgreSQL schemas whose tables are loaded, public by default
+	Schemas []string
+}
+
+func (o IntrospectOptions) introspect() introspect.Options {
+	return introspect.Options{Schemas: o.Schemas}
+}
+
+// ParseSchema parses SQL statements into a schema
+func ParseSchema(sql string) (*Schema, error) {
+	return LoadSchema(context.Background(), SQLSource(sql))
+}
+
+// LoadSchema loads the schema from a source
+func LoadSchema(ctx context.Context, src SchemaSource) (*Schema, error) {
+	return src.LoadSchema(ctx)
+}
+
+// SQLSource is a schema source of SQL statements held in memory
+func SQLSource(sql string) SchemaSource {
+	return internalSource{source: source.SQL(sql)}
+}
+
+// FileSource is a schema source of SQL files and migration directories or globs, applied in order. The input "-"
+// reads SQL from stdin.
+func FileSource(inputs []string, stdin io.Reader) SchemaSource {
+	return internalSource{source: source.Files{Inputs: inputs, Stdin: stdin}}
+}
+
+// MigrationsSource is a schema source of the migrations in a directory, or the ones matching a glob
+func MigrationsSource(pathOrGlob string) SchemaSource {
+	return internalSource{source: source.Migrations{Pattern: pathOrGlob}}
+}
+
+// DatabaseSource is a schema source that introspects a live PostgreSQL database
+func DatabaseSource(dsn string, opts IntrospectOptions) SchemaSource {
+	return internalSource{source: source.Database{DSN: dsn, Options: opts.introspect()}}
+}
+
+// SnapshotSource is a schema source of a schema serialized as JSON, or YAML when the file has a .yaml or .yml
+// extension
+func SnapshotSource(path string) SchemaSource {
+	return internalSource{source: source.Snapshot{Path: path}}
+}
+
+// SourceFromInputs picks the schema source for command line style inputs: a database DSN, a schema snapshot, or any
+// number of SQL files and migration directories or globs
+func SourceFromInputs(inputs []string, stdin io.Reader, opts IntrospectOptions) (SchemaSource, error) {
+	src, err := source.FromInputs(inputs, stdin, opts.introspect())
+	if err != nil {
+		return nil, err
+	}
+	return internalSource{source: src}, nil
+}
@@ -0,0 +1,130 @@
+package integral
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const apiSchema = `
+CREATE TABLE accounts (org int NOT NULL, num int NOT NULL, name text DEFAULT '', PRIMARY KEY (org, num));
+CREATE TABLE invoices (
+  id int GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+  account_org int NOT NULL,
+  account_num int NOT NULL,
+  code varchar(12) UNIQUE CHECK (code <> ''),
+  total numeric(10, 2) NOT NULL,
+  doubled numeric GENERATED ALWAYS AS (total * 2) STORED,
+  FOREIGN KEY (account_org, account_num) REFERENCES accounts (org, num),
+  CHECK (total >= 0)
+);
+`
+
+func TestSchemaTables(t *testing.T) {
+	schema, err := ParseSchema(apiSchema)
+	if err != nil {
+		t.Fatalf("ParseSchema() error = %v", err)
+	}
+	want := []Table{
+		{
+			Name:       "accounts",
+			PrimaryKey: []string{"org", "num"},
+			Columns: []Column{
+				{Name: "org", DataType: "int4", NotNull: true},
+				{Name: "num", DataType: "int4", NotNull: true},
+				{Name: "name", DataType: "text", HasDefault: true},
+			},
+			ForeignKeys: []ForeignKey{},
+			UniqueKeys:  [][]string{{"org", "num"}},
+		},
+		{
+			Name:       "invoices",
+			PrimaryKey: []string{"id"},
+			Columns: []Column{
+				{Name: "id", DataType: "int4", NotNull: true, Identity: true},
+				{Name: "account_org", DataType: "int4", NotNull: true},
+				{Name: "account_num", DataType: "int4", NotNull: true},
+				{Name: "code", DataType: "varchar", TypeModifiers: []int32{12}, Checks: []string{"code <> ''"}},
+				{Name: "total", DataType: "numeric", TypeModifiers: []int32{10, 2}, NotNull: true},
+				{Name: "doubled", DataType: "numeric", Generated: "total * 2"},
+			},
+			ForeignKeys: []ForeignKey{{
+				Name:              "invoices_account_org_account_num_fkey",
+				Columns:           []string{"account_org", "account_num"},
+				ReferencedTable:   "accounts",
+				ReferencedColumns: []string{"org", "num"},
+			}},
+			UniqueKeys: [][]string{{"id"}, {"code"}},
+			Checks:     []string{"total >= 0"},
+		},
+	}
+	if diff := cmp.Diff(want, schema.Tables()); diff != "" {
+		t.Errorf("Tables() mismatch (-want +got):\n%s", diff)
+	}
+
+	table, ok := schema.Table("invoices")
+	if !ok {
+		t.Fatalf("Table(invoices) ok = false")
+	}
+	if diff := cmp.Diff(want[1], table); diff != "" {
+		t.Errorf("Table(invoices) mismatch (-want +got):\n%s", diff)
+	}
+	if _, ok := schema.Table("orders"); ok {
+		t.Errorf("Table(orders) ok = true, want false")
+	}
+}
+
+// recordingSink records the rows a dataset is generated with, by table
+type recordingSink struct {
+	rows  map[string][]Row
+	ended []string
+}
+
+func (s *recordingSink) WriteRow(table Table, row Row) error {
+	s.rows[table.Name] = append(s.rows[table.Name], row)
+	return nil
+}
+
+func (s *recordingSink) EndTable(table Table, sequenceColumns []string) error {
+	s.ended = append(s.ended, table.Name)
+	return nil
+}
+
+func TestGenerateDatasetSink(t *testing.T) {
+	schema, err := ParseSchema(apiSchema)
+	if err != nil {
+		t.Fatalf("ParseSchema() error = %v", err)
+	}
+	seeder, err := NewSeeder(schema)
+	if err != nil {
+		t.Fatalf("NewSeeder() error = %v", err)
+	}
+	sink := &recordingSink{rows: make(map[string][]Row)}
+	plan := DatasetPlan{Counts: map[string]int{"accounts": 2}, PerParent: map[string]Cardinality{"invoices": {Min: 3, Max: 3}}}
+	counts, err := GenerateDataset(seeder, NewFaker(1, FakerOptions{}), plan, sink)
+	if err != nil {
+		t.Fatalf("GenerateDataset() error = %v", err)
+	}
+
+	if diff := cmp.Diff(map[string]int{"accounts": 2, "invoices": 6}, counts); diff != "" {
+		t.Errorf("counts mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"accounts", "invoices"}, sink.ended); diff != "" {
+		t.Errorf("ended tables mismatch (-want +got):\n%s", diff)
+	}
+	for _, invoice := range sink.rows["invoices"] {
+		account := Row{"org": invoice["account_org"], "num": invoice["account_num"]}
+		if !containsAccount(sink.rows["accounts"], account) {
+			t.Errorf("invoice %v references no generated account", invoice)
+		}
+	}
+}
+
+func containsAccount(accounts []Row, key Row) bool {
+	for _, account := range accounts {
+		if account["org"] == key["org"] && account["num"] == key["num"] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package integral
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/tab58/go-integral/internal/seeder"
+	"github.com/tab58/go-integral/internal/utils"
+)
+
+// Seeder inserts rows expressed as maps into the tables of a schema at runtime, without generated code
+type Seeder struct {
+	seeder *seeder.Seeder
+	schema *Schema
+}
+
+// Row is a record of a table, with the values keyed by column name. Columns that are left out get their default value.
+type Row map[string]any
+
+// Dataset holds the rows to insert in each table, keyed by table name
+type Dataset map[string][]Row
+
+// Querier is the database interface rows are inserted with, satisfied by *sql.DB, *sql.Tx, *sql.Conn and their sqlx
+// counterparts
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// NewSeeder prepares the runtime seeding of a schema, ordering its tables by dependency
+func NewSeeder(schema *Schema) (*Seeder, error) {
+	s, err := seeder.New(schema.schema)
+	if err != nil {
+		return nil, err
+	}
+	return &Seeder{seeder: s, schema: schema}, nil
+}
+
+// Schema returns the schema the rows are inserted into
+func (s *Seeder) Schema() *Schema {
+	return s.schema
+}
+
+// Tables returns the tables of the schema in insert order
+func (s *Seeder) Tables() []Table {
+	return utils.Map(s.seeder.Tables(), newTable)
+}
+
+// Seed inserts the rows of every table, the tables being in order of dependency and the rows of a table in the given
+// order. It returns the inserted rows as stored, including the values the database generated.
+func (s *Seeder) Seed(ctx context.Context, db Querier, data Dataset) (Dataset, error) {
+	inserted, err := s.seeder.Seed(ctx, db, data.seeder())
+	if err != nil {
+		return nil, err
+	}
+	return newDataset(inserted), nil
+}
+
+// Insert coerces the values of a row to the types of its columns and inserts it, returning the row as stored
+func (s *Seeder) Insert(ctx context.Context, db Querier, tableName string, row Row) (Row, error) {
+	stored, err := s.seeder.Insert(ctx, db, tableName, seeder.Row(row))
+	if err != nil {
+		return nil, err
+	}
+	return Row(stored), nil
+}
+
+// InsertMany coerces and inserts rows of a table with multi-row INSERT statements, which is much faster than Insert
+// for large numbers of rows. The rows don't need to set the same columns: the ones a row leaves out get their default.
+func (s *Seeder) InsertMany(ctx context.Context, db Querier, tableName string, rows []Row) error {
+	return s.seeder.InsertMany(ctx, db, tableName, utils.Map(rows, func(row Row) seeder.Row { return seeder.Row(row) }))
+}
+
+// Select reads the rows of a table matching an SQL condition, which is inserted in the query as is and can use the
+// args as $1, $2 and so on. An empty condition selects every row. The values are read like Insert returns them.
+func (s *Seeder) Select(ctx context.Context, db Querier, tableName string, condition string, args ...any) ([]Row, error) {
+	rows, err := s.seeder.Select(ctx, db, tableName, condition, args...)
+	if err != nil {
+		return nil, err
+	}
+	return utils.Map(rows, func(row seeder.Row) Row { return Row(row) }), nil
+}
+
+// FakeRow returns a row of a table with a value from the faker for every column but the foreign keys, which the
+// caller sets, and the columns the database fills: serial and identity columns and columns with a default
+func (s *Seeder) FakeRow(faker *Faker, tableName string) (Row, error) {
+	row, err := s.seeder.FakeRow(faker.faker, tableName)
+	if err != nil {
+		return nil, err
+	}
+	return Row(row), nil
+}
+
+// CoerceValue converts a value decoded from JSON, YAML or CSV to the Go type the database driver expects for a column
+// of a table
+func CoerceValue(schema *Schema, tableName string, columnName string, value any) (any, error) {
+	table, ok := schema.schema.Tables[tableName]
+	if !ok {
+		return nil, fmt.Errorf("unknown table %q", tableName)
+	}
+	for _, column := range table.Columns {
+		if column.Name == columnName {
+			return seeder.Coerce(column, value, schema.schema.Enums)
+		}
+	}
+	return nil, fmt.Errorf("unknown column %q of table %s", columnName, tableName)
+}
+
+func (d Dataset) seeder() seeder.Dataset {
+	if d == nil {
+		return nil
+	}
+	data := make(seeder.Dataset, len(d))
+	for tableName, rows := range d {
+		data[tableName] = utils.Map(rows, func(row Row) seeder.Row { return seeder.Row(row) })
+	}
+	return data
+}
+
+func newDataset(data seeder.Dataset) Dataset {
+	if data == nil {
+		return nil
+	}
+	d := make(Dataset, len(data))
+	for tableName, rows := range data {
+		d[tableName] = utils.Map(rows, func(row seeder.Row) Row { return Row(row) })
+	}
+	return d
+}
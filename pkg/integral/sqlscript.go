@@ -0,0 +1,25 @@
+package integral
+
+import (
+	"github.com/tab58/go-integral/internal/sqlscript"
+	"io"
+)
+
+// SQLScriptOptions controls how seed scripts are written
+type SQLScriptOptions struct {
+	// Transaction wraps the script in BEGIN and COMMIT
+	Transaction bool
+	// CopyRows is the number of rows from which the rows of a table are written as a COPY block instead of INSERT
+	// statements, 0 for never
+	CopyRows int
+}
+
+func (o SQLScriptOptions) sqlscript() sqlscript.Options {
+	return sqlscript.Options(o)
+}
+
+// WriteSQLScript writes rows expressed as maps as an SQL script inserting them in dependency order, with the values
+// coerced to the types of their columns and written as literals of these types
+func WriteSQLScript(w io.Writer, seeder *Seeder, data Dataset, opts SQLScriptOptions) error {
+	return sqlscript.Write(w, seeder.seeder.Tables(), data.seeder(), seeder.schema.schema.Enums, opts.sqlscript())
+}